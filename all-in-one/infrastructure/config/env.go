@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Environment struct {
+	DB       DB
+	Mastodon Mastodon
+	Queue    Queue
+	External External
+	TTL      TTL
+
+	LogLevel slog.Level
+	Platform string
+	Port     string
+	TLSCert  string
+	TLSKey   string
+	UserID   int64
+}
+
+type DB struct {
+	Path string
+}
+
+type Mastodon struct {
+	UserID            string
+	ServerURL         string
+	AccessToken       string
+	Streams           []string
+	WelcomeMessage    string
+	DeleteReplies     bool
+	FallbackThreshold int64
+	PollingInterval   time.Duration
+	PollingStateFile  string
+}
+
+type Queue struct {
+	MaxRetries    int64
+	RetryInterval time.Duration
+}
+
+type External struct {
+	MpywAPIURL                 string
+	ShindanmakerDefinitionsDir string
+	ShindanmakerCacheSize      int64
+	ShindanmakerCachePersisted bool
+	BreakerThreshold           int64
+	BreakerTimeout             time.Duration
+	FallbackMessage            string
+}
+
+type TTL struct {
+	Packets        map[string]time.Duration
+	Actions        map[string]time.Duration
+	ApologyMessage string
+}
+
+func Get() (env Environment, errs error) {
+	for _, entry := range []struct {
+		name     string
+		field    any
+		optional bool
+	}{
+		{name: "DB_PATH", field: &env.DB.Path},
+		{name: "MASTODON_USER_ID", field: &env.Mastodon.UserID},
+		{name: "MASTODON_SERVER_URL", field: &env.Mastodon.ServerURL},
+		{name: "MASTODON_ACCESS_TOKEN", field: &env.Mastodon.AccessToken},
+		{name: "MASTODON_STREAM", field: &env.Mastodon.Streams},
+		{name: "MASTODON_WELCOME_MESSAGE", field: &env.Mastodon.WelcomeMessage, optional: true},
+		{name: "MASTODON_DELETE_REPLIES", field: &env.Mastodon.DeleteReplies, optional: true},
+		{name: "MASTODON_FALLBACK_THRESHOLD", field: &env.Mastodon.FallbackThreshold, optional: true},
+		{name: "MASTODON_POLLING_INTERVAL_SEC", field: &env.Mastodon.PollingInterval, optional: true},
+		{name: "MASTODON_POLLING_STATE_FILE", field: &env.Mastodon.PollingStateFile, optional: true},
+		{name: "MQ_MAX_RETRIES", field: &env.Queue.MaxRetries, optional: true},
+		{name: "MQ_RETRY_INTERVAL_SEC", field: &env.Queue.RetryInterval, optional: true},
+		{name: "EXT_MPYW_API_URL", field: &env.External.MpywAPIURL},
+		{name: "EXT_SHINDANMAKER_DEFINITIONS_DIR", field: &env.External.ShindanmakerDefinitionsDir, optional: true},
+		{name: "EXT_SHINDANMAKER_CACHE_SIZE", field: &env.External.ShindanmakerCacheSize, optional: true},
+		{name: "EXT_SHINDANMAKER_CACHE_PERSISTED", field: &env.External.ShindanmakerCachePersisted, optional: true},
+		{name: "EXT_BREAKER_THRESHOLD", field: &env.External.BreakerThreshold, optional: true},
+		{name: "EXT_BREAKER_TIMEOUT_SEC", field: &env.External.BreakerTimeout, optional: true},
+		{name: "EXT_FALLBACK_MESSAGE", field: &env.External.FallbackMessage, optional: true},
+		{name: "TTL_PACKETS_SEC", field: &env.TTL.Packets, optional: true},
+		{name: "TTL_ACTIONS_SEC", field: &env.TTL.Actions, optional: true},
+		{name: "TTL_APOLOGY_MESSAGE", field: &env.TTL.ApologyMessage, optional: true},
+		{name: "LOG_LEVEL", field: &env.LogLevel, optional: true},
+		{name: "PLATFORM", field: &env.Platform, optional: true},
+		{name: "PORT", field: &env.Port},
+		{name: "TLS_CERT", field: &env.TLSCert, optional: true},
+		{name: "TLS_KEY", field: &env.TLSKey, optional: true},
+		{name: "USER_ID", field: &env.UserID},
+	} {
+		v := os.Getenv(entry.name)
+		if v == "" {
+			if !entry.optional {
+				errs = errors.Join(errs, fmt.Errorf("missing: %v", entry.name))
+			}
+			continue
+		}
+
+		switch field := entry.field.(type) {
+		case *string:
+			*field = v
+
+		case *[]string:
+			for s := range strings.SplitSeq(v, ",") {
+				s = strings.TrimSpace(s)
+				if s != "" {
+					*field = append(*field, s)
+				}
+			}
+
+		case *bool:
+			v, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
+		case *int64:
+			v, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
+		case *time.Duration:
+			v, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = time.Duration(v) * time.Second
+
+		case *map[string]time.Duration:
+			v, err := parseDurations(v)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
+		case *slog.Level:
+			v, err := parseLogLevel(v)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+		}
+	}
+
+	return
+}
+
+// parseDurations parses comma-separated pairs of a name and seconds, such as "packets.tick=0,寿司職人=300".
+func parseDurations(s string) (map[string]time.Duration, error) {
+	durations := map[string]time.Duration{}
+	for pair := range strings.SplitSeq(s, ",") {
+		name, sec, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("not a valid pair: %q", pair)
+		}
+
+		v, err := strconv.ParseInt(sec, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		durations[name] = time.Duration(v) * time.Second
+	}
+	return durations, nil
+}
+
+func parseLogLevel(lvl string) (slog.Level, error) {
+	switch {
+	case strings.EqualFold(lvl, "error"):
+		return slog.LevelError, nil
+	case strings.EqualFold(lvl, "warn"), strings.EqualFold(lvl, "warning"):
+		return slog.LevelWarn, nil
+	case strings.EqualFold(lvl, "info"):
+		return slog.LevelInfo, nil
+	case strings.EqualFold(lvl, "debug"):
+		return slog.LevelDebug, nil
+	}
+
+	var l slog.Level
+	return l, fmt.Errorf("not a valid log level: %q", lvl)
+}
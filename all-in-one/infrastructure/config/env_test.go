@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/all-in-one/infrastructure/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environment", func() {
+	Describe("Get()", func() {
+		BeforeEach(func() {
+			os.Clearenv()
+		})
+
+		Context("some vars missing", func() {
+			It("returns an error", func() {
+				_, err := config.Get()
+				Expect(err).To(MatchError(HavePrefix("missing:")))
+			})
+		})
+
+		Context("TTL is invalid", func() {
+			It("returns an error", func() {
+				err := os.Setenv("TTL_ACTIONS_SEC", "寿司職人")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = config.Get()
+				Expect(err).To(MatchError(ContainSubstring(`TTL_ACTIONS_SEC is invalid: not a valid pair: "寿司職人"`)))
+			})
+		})
+
+		Context("all required vars set", func() {
+			BeforeEach(func() {
+				for k, v := range map[string]string{
+					"DB_PATH":               "/var/lib/ejaculation-counter/ejaculation.db",
+					"MASTODON_USER_ID":      "1",
+					"MASTODON_SERVER_URL":   "https://mastodon.example.com",
+					"MASTODON_ACCESS_TOKEN": "token",
+					"MASTODON_STREAM":       "user, hashtag:ejaculation_counter",
+					"MQ_RETRY_INTERVAL_SEC": "60",
+					"EXT_MPYW_API_URL":      "https://mpyw.hinanawi.net/api",
+					"TTL_PACKETS_SEC":       "packets.message=1800",
+					"TTL_ACTIONS_SEC":       "寿司職人=300, 駿河茶=0",
+					"PORT":                  "8080",
+					"USER_ID":               "1",
+				} {
+					err := os.Setenv(k, v)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("returns config", func() {
+				env, err := config.Get()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(env).To(Equal(config.Environment{
+					DB: config.DB{
+						Path: "/var/lib/ejaculation-counter/ejaculation.db",
+					},
+					Mastodon: config.Mastodon{
+						UserID:      "1",
+						ServerURL:   "https://mastodon.example.com",
+						AccessToken: "token",
+						Streams:     []string{"user", "hashtag:ejaculation_counter"},
+					},
+					Queue: config.Queue{
+						RetryInterval: 60 * time.Second,
+					},
+					External: config.External{
+						MpywAPIURL: "https://mpyw.hinanawi.net/api",
+					},
+					TTL: config.TTL{
+						Packets: map[string]time.Duration{
+							"packets.message": 30 * time.Minute,
+						},
+						Actions: map[string]time.Duration{
+							"寿司職人": 5 * time.Minute,
+							"駿河茶":  0,
+						},
+					},
+					Port:   "8080",
+					UserID: 1,
+				}))
+			})
+		})
+	})
+})
@@ -0,0 +1,227 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	reactor "github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	rs "github.com/chitoku-k/ejaculation-counter/reactor/service"
+	supplier "github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/queue"
+	ss "github.com/chitoku-k/ejaculation-counter/supplier/service"
+)
+
+const (
+	QueueSize         = 1024
+	CacheTTL          = 1 * time.Minute
+	RedeliveryTimeout = 1 * time.Minute
+)
+
+type message struct {
+	typ       string
+	timestamp time.Time
+	body      []byte
+	priority  ss.Priority
+	deaths    int64
+}
+
+type memory struct {
+	queue         chan message
+	priority      chan message
+	ch            chan rs.Packet
+	mu            sync.Mutex
+	tag           uint64
+	messages      map[uint64]message
+	seen          map[string]time.Time
+	Now           func() time.Time
+	RetryInterval time.Duration
+	MaxRetries    int64
+}
+
+type memoryWriter struct {
+	m *memory
+}
+
+type memoryReader struct {
+	m *memory
+}
+
+// NewMemory returns a writer and a reader connected through an in-process queue in place of the broker.
+// Packets are passed in the encoded form so that they are delivered the same way as through the broker:
+// duplicates published within CacheTTL are dropped, rejected packets are redelivered after retryInterval,
+// and packets that cannot be decoded, exceed maxRetries, or find the queue full for RedeliveryTimeout on redelivery
+// are discarded, as there is no parking queue.
+// Packets with high priority are passed in another queue that is consumed first.
+func NewMemory(retryInterval time.Duration, maxRetries int64, now func() time.Time) (ss.QueueWriter, rs.QueueReader) {
+	m := &memory{
+		queue:         make(chan message, QueueSize),
+		priority:      make(chan message, QueueSize),
+		ch:            make(chan rs.Packet, QueueSize),
+		messages:      map[uint64]message{},
+		seen:          map[string]time.Time{},
+		Now:           now,
+		RetryInterval: retryInterval,
+		MaxRetries:    maxRetries,
+	}
+	return &memoryWriter{m}, &memoryReader{m}
+}
+
+// duplicate reports whether the packet with the key has been published within CacheTTL.
+func (m *memory) duplicate(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.Now()
+	for k, t := range m.seen {
+		if now.Sub(t) >= CacheTTL {
+			delete(m.seen, k)
+		}
+	}
+
+	if _, ok := m.seen[key]; ok {
+		return true
+	}
+	m.seen[key] = now
+	return false
+}
+
+// lane returns the queue for the priority.
+func (m *memory) lane(priority ss.Priority) chan message {
+	if priority == ss.PriorityHigh {
+		return m.priority
+	}
+	return m.queue
+}
+
+func (m *memory) track(msg message) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tag++
+	m.messages[m.tag] = msg
+	return m.tag
+}
+
+func (m *memory) untrack(tag uint64) (message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msg, ok := m.messages[tag]
+	delete(m.messages, tag)
+	return msg, ok
+}
+
+func (w *memoryWriter) Publish(ctx context.Context, p ss.Packet, priority ss.Priority) error {
+	body, err := supplier.EncodePacket(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal packet: %w", err)
+	}
+	supplier.QueuedMessageTotal.Inc()
+
+	if w.m.duplicate(fmt.Sprintf("%v-%v", p.Name(), p.HashCode())) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		supplier.QueuedMessageErrorTotal.Inc()
+		return fmt.Errorf("failed to publish message: %w", ctx.Err())
+
+	case w.m.lane(priority) <- message{typ: p.Name(), timestamp: p.Timestamp(), body: body, priority: priority}:
+		return nil
+	}
+}
+
+func (w *memoryWriter) Close() error {
+	return nil
+}
+
+func (r *memoryReader) Consume(ctx context.Context) {
+	defer close(r.m.ch)
+
+	for {
+		var msg message
+		select {
+		case msg = <-r.m.priority:
+		default:
+			select {
+			case <-ctx.Done():
+				return
+
+			case msg = <-r.m.priority:
+			case msg = <-r.m.queue:
+			}
+		}
+
+		reactor.DeliveredMessageTotal.WithLabelValues(msg.typ).Inc()
+
+		tag := r.m.track(msg)
+		decoded, err := reactor.DecodePacket(msg.typ, packet.SchemaVersion, tag, msg.timestamp, msg.body)
+		if err != nil {
+			r.m.untrack(tag)
+			reactor.DeliveredMessageErrorTotal.WithLabelValues(msg.typ).Inc()
+			slog.Error("Failed to decode message", slog.String("packet-type", msg.typ), slog.Any("err", err))
+			continue
+		}
+
+		if !r.send(ctx, decoded) {
+			return
+		}
+	}
+}
+
+func (r *memoryReader) send(ctx context.Context, p rs.Packet) bool {
+	select {
+	case <-ctx.Done():
+		return false
+
+	case r.m.ch <- p:
+		return true
+	}
+}
+
+func (r *memoryReader) Packets() <-chan rs.Packet {
+	return r.m.ch
+}
+
+func (r *memoryReader) Ack(tag uint64) error {
+	_, ok := r.m.untrack(tag)
+	if !ok {
+		return fmt.Errorf("unknown tag: %d", tag)
+	}
+	return nil
+}
+
+func (r *memoryReader) Reject(tag uint64) error {
+	msg, ok := r.m.untrack(tag)
+	if !ok {
+		return fmt.Errorf("unknown tag: %d", tag)
+	}
+
+	msg.deaths++
+	if msg.deaths > r.m.MaxRetries {
+		reactor.ParkedMessageTotal.WithLabelValues(msg.typ).Inc()
+		slog.Warn("Discarded message after exceeding retry limit", slog.String("packet-type", msg.typ), slog.Int64("max-retries", r.m.MaxRetries))
+		return nil
+	}
+
+	time.AfterFunc(r.m.RetryInterval, func() {
+		timer := time.NewTimer(RedeliveryTimeout)
+		defer timer.Stop()
+
+		select {
+		case r.m.lane(msg.priority) <- msg:
+		case <-timer.C:
+			reactor.ParkedMessageTotal.WithLabelValues(msg.typ).Inc()
+			slog.Error("Discarded message as the queue is full", slog.String("packet-type", msg.typ), slog.Duration("timeout", RedeliveryTimeout))
+		}
+	})
+	return nil
+}
+
+func (r *memoryReader) Close() error {
+	return nil
+}
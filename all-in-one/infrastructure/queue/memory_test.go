@@ -0,0 +1,166 @@
+package queue_test
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/all-in-one/infrastructure/queue"
+	rs "github.com/chitoku-k/ejaculation-counter/reactor/service"
+	ss "github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+		now    time.Time
+		writer ss.QueueWriter
+		reader rs.QueueReader
+	)
+
+	deletion := ss.Deletion{
+		ID:        "100",
+		DeletedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		now = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		writer, reader = queue.NewMemory(10*time.Millisecond, 1, func() time.Time {
+			return now
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			reader.Consume(ctx)
+		}()
+
+		DeferCleanup(func() {
+			cancel()
+			<-done
+			Expect(reader.Close()).To(Succeed())
+		})
+	})
+
+	Context("context is done", func() {
+		It("closes the channel of packets", func() {
+			cancel()
+			Eventually(reader.Packets()).Should(BeClosed())
+		})
+	})
+
+	Context("packet is published", func() {
+		It("delivers the packet", func() {
+			err := writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			var actual rs.Packet
+			Eventually(reader.Packets()).Should(Receive(&actual))
+			Expect(actual).To(BeAssignableToTypeOf(rs.Deletion{}))
+			Expect(actual.(rs.Deletion).ID).To(Equal("100"))
+			Expect(actual.Timestamp()).To(Equal(deletion.DeletedAt))
+
+			err = reader.Ack(actual.Tag())
+			Expect(err).NotTo(HaveOccurred())
+
+			err = reader.Ack(actual.Tag())
+			Expect(err).To(MatchError("unknown tag: 1"))
+		})
+	})
+
+	Context("same packet is published twice", func() {
+		It("drops the duplicate within the cache TTL", func() {
+			err := writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(reader.Packets()).Should(Receive())
+			Consistently(reader.Packets(), 50*time.Millisecond).ShouldNot(Receive())
+
+			now = now.Add(queue.CacheTTL)
+			err = writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(reader.Packets()).Should(Receive())
+		})
+	})
+
+	Context("packet is rejected", func() {
+		It("redelivers the packet until the retry limit", func() {
+			err := writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			var actual rs.Packet
+			Eventually(reader.Packets()).Should(Receive(&actual))
+			Expect(reader.Reject(actual.Tag())).To(Succeed())
+
+			Eventually(reader.Packets()).Should(Receive(&actual))
+			Expect(actual.Tag()).To(Equal(uint64(2)))
+			Expect(reader.Reject(actual.Tag())).To(Succeed())
+
+			Consistently(reader.Packets(), 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("redelivers the packet after the queue has room", func() {
+			err := writer.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			var actual rs.Packet
+			Eventually(reader.Packets()).Should(Receive(&actual))
+
+			// Fill both the queue and the channel of packets, with one more held by the consumer.
+			for i := range 2*queue.QueueSize + 1 {
+				err := writer.Publish(ctx, ss.Deletion{ID: strconv.Itoa(1000 + i), DeletedAt: deletion.DeletedAt}, ss.PriorityNormal)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(reader.Reject(actual.Tag())).To(Succeed())
+			time.Sleep(50 * time.Millisecond)
+
+			var ids []string
+			for range 2*queue.QueueSize + 2 {
+				Eventually(reader.Packets()).Should(Receive(&actual))
+				ids = append(ids, actual.(rs.Deletion).ID)
+			}
+			Expect(ids).To(ContainElement("100"))
+		})
+	})
+
+	Context("packets with different priorities are waiting", func() {
+		It("delivers the packet with high priority first", func() {
+			w, r := queue.NewMemory(10*time.Millisecond, 1, func() time.Time {
+				return now
+			})
+
+			err := w.Publish(ctx, deletion, ss.PriorityNormal)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = w.Publish(ctx, ss.Tick{Year: 2024, Month: 1, Day: 2}, ss.PriorityHigh)
+			Expect(err).NotTo(HaveOccurred())
+
+			c, stop := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				r.Consume(c)
+			}()
+			DeferCleanup(func() {
+				stop()
+				<-done
+				Expect(r.Close()).To(Succeed())
+			})
+
+			var actual rs.Packet
+			Eventually(r.Packets()).Should(Receive(&actual))
+			Expect(actual).To(BeAssignableToTypeOf(rs.Tick{}))
+
+			Eventually(r.Packets()).Should(Receive(&actual))
+			Expect(actual).To(BeAssignableToTypeOf(rs.Deletion{}))
+		})
+	})
+})
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/all-in-one/infrastructure/config"
+	"github.com/chitoku-k/ejaculation-counter/all-in-one/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/reactor/application/server"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/action"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/hardcoding"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	reactor "github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	rs "github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/scheduler"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	ss "github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/pflag"
+)
+
+var (
+	signals = []os.Signal{os.Interrupt}
+	name    = "ejaculation-counter all-in-one"
+	version = "v0.0.0-dev"
+
+	flagversion = pflag.BoolP("version", "V", false, "show version")
+)
+
+func init() {
+	prometheus.DefaultRegisterer.Unregister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	prometheus.DefaultRegisterer.Unregister(collectors.NewGoCollector())
+}
+
+func main() {
+	pflag.Parse()
+	if *flagversion {
+		fmt.Println(name, version)
+		return
+	}
+
+	var wg sync.WaitGroup
+	ctx, stop := signal.NotifyContext(context.Background(), signals...)
+	defer stop()
+
+	env, err := config.Get()
+	if err != nil {
+		slog.Error("Failed to initialize config", slog.Any("err", err))
+		os.Exit(1)
+	}
+	slog.SetLogLoggerLevel(env.LogLevel)
+
+	db, err := client.NewSQLiteDB(env.DB.Path)
+	if err != nil {
+		slog.Error("Failed to initialize DB", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	s, err := scheduler.New()
+	if err != nil {
+		slog.Error("Failed to initialize scheduler", slog.Any("err", err))
+		os.Exit(1)
+	}
+	tick := s.Start()
+
+	wg.Go(func() {
+		<-ctx.Done()
+		s.Stop()
+	})
+
+	maxRetries := env.Queue.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = reactor.DefaultMaxRetries
+	}
+	retryInterval := env.Queue.RetryInterval
+	if retryInterval <= 0 {
+		retryInterval = reactor.DeadLetterTTL
+	}
+	writer, reader := queue.NewMemory(retryInterval, maxRetries, time.Now)
+
+	var mastodon ss.Streaming
+	var poster rs.Poster
+	c, err := client.NewHttpClient()
+	if err != nil {
+		slog.Error("Failed to initialize Cookie Jar", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	dialer := wrapper.NewDialer(websocket.DefaultDialer)
+	switch env.Platform {
+	case "", "mastodon":
+		streams, err := streaming.ParseStreams(env.Mastodon.Streams)
+		if err != nil {
+			slog.Error("Failed to parse streams", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		state := streaming.NewPollingState(env.Mastodon.PollingStateFile)
+		mastodon = streaming.NewMastodon(
+			dialer,
+			wrapper.NewTimer(),
+			env.Mastodon.ServerURL,
+			env.Mastodon.AccessToken,
+			streams,
+			state,
+		)
+		if env.Mastodon.FallbackThreshold > 0 {
+			interval := env.Mastodon.PollingInterval
+			if interval <= 0 {
+				interval = streaming.DefaultPollingInterval
+			}
+			polling := streaming.NewPolling(
+				wrapper.NewTimer(),
+				env.Mastodon.ServerURL,
+				env.Mastodon.AccessToken,
+				streams,
+				interval,
+				state,
+			)
+			mastodon = streaming.NewFailover(mastodon, polling, int(env.Mastodon.FallbackThreshold))
+		}
+		poster = client.NewMastodon(env.Mastodon.ServerURL, env.Mastodon.AccessToken)
+
+	case "misskey":
+		channels, err := streaming.ParseMisskeyChannels(env.Mastodon.Streams)
+		if err != nil {
+			slog.Error("Failed to parse channels", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		mastodon = streaming.NewMisskey(
+			dialer,
+			wrapper.NewTimer(),
+			c,
+			env.Mastodon.ServerURL,
+			env.Mastodon.AccessToken,
+			channels,
+		)
+		poster = client.NewMisskey(c, env.Mastodon.ServerURL, env.Mastodon.AccessToken)
+
+	default:
+		slog.Error("Unknown platform", slog.String("platform", env.Platform))
+		os.Exit(1)
+	}
+
+	wg.Go(func() {
+		err := mastodon.Run(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("Error in starting streaming", slog.Any("err", err))
+			os.Exit(1)
+		}
+	})
+
+	wg.Go(func() {
+		<-ctx.Done()
+		err := mastodon.Close(true)
+		if err != nil {
+			slog.Error("Failed to close streaming", slog.Any("err", err))
+		}
+	})
+
+	wg.Go(func() {
+		ps := ss.NewProcessor(writer, env.Mastodon.UserID)
+		ps.Execute(ctx, tick, mastodon.Statuses())
+
+		err := writer.Close()
+		if err != nil {
+			slog.Error("Failed to close writer", slog.Any("err", err))
+		}
+	})
+
+	wg.Go(func() {
+		reader.Consume(ctx)
+	})
+
+	wg.Go(func() {
+		shindan := client.NewShindanmaker(c)
+		if env.External.ShindanmakerDefinitionsDir != "" {
+			var err error
+			shindan, err = client.NewLocalShindanmaker(env.External.ShindanmakerDefinitionsDir, time.Now)
+			if err != nil {
+				slog.Error("Failed to initialize ShindanMaker", slog.Any("err", err))
+				os.Exit(1)
+			}
+		}
+		mpyw := client.NewMpyw(c)
+		if env.External.BreakerThreshold > 0 {
+			shindan = client.NewBreakerShindanmaker(shindan, client.NewBreaker("shindanmaker", int(env.External.BreakerThreshold), env.External.BreakerTimeout, time.Now))
+			mpyw = client.NewBreakerMpyw(mpyw, client.NewBreaker("mpyw", int(env.External.BreakerThreshold), env.External.BreakerTimeout, time.Now))
+		}
+		if env.External.ShindanmakerCacheSize > 0 {
+			var store client.ShindanmakerStore
+			if env.External.ShindanmakerCachePersisted {
+				store = db
+			}
+			shindan = client.NewCachedShindanmaker(shindan, store, int(env.External.ShindanmakerCacheSize), time.Now)
+		}
+		through := hardcoding.NewThroughRepository()
+		doublet := hardcoding.NewDoubletRepository()
+
+		var notificationActions []rs.NotificationAction
+		if env.Mastodon.WelcomeMessage != "" {
+			notificationActions = append(notificationActions, action.NewFollowWelcome(env.Mastodon.WelcomeMessage))
+		}
+
+		ps := rs.NewProcessor(
+			reader,
+			invoker.NewReply(poster, env.External.FallbackMessage, env.TTL.ApologyMessage),
+			invoker.NewIncrement(poster, db, env.UserID),
+			invoker.NewDecrement(poster, db, env.UserID, time.Now),
+			invoker.NewUpdate(poster, db, env.UserID),
+			invoker.NewAdministration(poster, db),
+			db,
+			[]rs.Action{
+				action.NewOfufutonChallenge(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), env.Mastodon.UserID),
+				action.NewDB(env.Mastodon.UserID),
+				action.NewPyuUpdate(env.Mastodon.UserID),
+				action.NewMpyw(mpyw, env.Mastodon.UserID, env.External.MpywAPIURL),
+				action.NewAVShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewBattleChimpoShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewBlueArchiveEcchiGameShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewChimpoChallengeShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewChimpoInsertionChallengeShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewChimpoMatchingShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewLawChallengeShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewOfutonManagerShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewPyuppyuManagerShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewSushiShindanmaker(shindan, env.Mastodon.UserID),
+				action.NewThrough(through, env.Mastodon.UserID),
+				action.NewDoublet(doublet, env.Mastodon.UserID),
+			},
+			notificationActions,
+			env.Mastodon.DeleteReplies,
+			rs.NewTTLPolicy(env.TTL.Packets, env.TTL.Actions, env.TTL.ApologyMessage != ""),
+			time.Now,
+		)
+		ps.Execute(ctx, reader.Packets())
+
+		// Close the reader after the packets in flight are acknowledged, and then the DB they use.
+		err := reader.Close()
+		if err != nil {
+			slog.Error("Failed to close reader", slog.Any("err", err))
+		}
+
+		err = db.Close()
+		if err != nil {
+			slog.Error("Failed to close connection to DB", slog.Any("err", err))
+		}
+	})
+
+	wg.Go(func() {
+		through := rs.NewThrough(hardcoding.NewThroughRepository())
+		doublet := rs.NewDoublet(hardcoding.NewDoubletRepository())
+		engine := server.NewEngine(through, doublet, nil, "", env.Port, env.TLSCert, env.TLSKey)
+		err := engine.Start(ctx)
+		if err != nil {
+			slog.Error("Failed to start web server", slog.Any("err", err))
+			os.Exit(1)
+		}
+	})
+
+	wg.Wait()
+}
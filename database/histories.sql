@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "histories" (
+    "id" SERIAL NOT NULL PRIMARY KEY,
+    "status_id" text NOT NULL,
+    "action" text NOT NULL,
+    "event" text NOT NULL,
+    "reply_id" text NOT NULL,
+    "date" date NOT NULL,
+    "created_at" timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS "histories_status_id" ON "histories" ("status_id");
+CREATE INDEX IF NOT EXISTS "histories_created_at" ON "histories" ("created_at");
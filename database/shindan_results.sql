@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "shindan_results" (
+    "url" text NOT NULL,
+    "name" text NOT NULL,
+    "date" date NOT NULL,
+    "result" text NOT NULL,
+    UNIQUE ("url", "name", "date")
+);
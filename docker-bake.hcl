@@ -4,11 +4,17 @@ group "default" {
 
 target "supplier" {
     context = "./supplier"
+    contexts = {
+        packet = "./packet"
+    }
     target = "production"
 }
 
 target "reactor" {
     context = "./reactor"
+    contexts = {
+        packet = "./packet"
+    }
     target = "production"
 }
 
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/chitoku-k/ejaculation-counter/e2e/fakemastodon"
+)
+
+var (
+	addr        = flag.String("addr", ":8080", "address to listen on")
+	token       = flag.String("token", "", "access token to accept (empty to accept any)")
+	id          = flag.String("id", "1", "ID of the authenticated account")
+	username    = flag.String("username", "ejaculation_counter", "username of the authenticated account")
+	displayName = flag.String("display-name", "ぴゅっぴゅカウンター（昨日: 0 / 今日: 0）", "display name of the authenticated account")
+)
+
+func main() {
+	flag.Parse()
+
+	server := fakemastodon.NewServer(fakemastodon.Account{
+		ID:          *id,
+		Username:    *username,
+		Acct:        *username,
+		DisplayName: *displayName,
+	}, *token)
+
+	slog.Info("Starting fake Mastodon server", slog.String("addr", *addr))
+	err := http.ListenAndServe(*addr, server)
+	if err != nil {
+		slog.Error("Failed to start fake Mastodon server", slog.Any("err", err))
+		os.Exit(1)
+	}
+}
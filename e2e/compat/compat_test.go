@@ -0,0 +1,167 @@
+package compat_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	reactor "github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	rs "github.com/chitoku-k/ejaculation-counter/reactor/service"
+	supplier "github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/queue"
+	ss "github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// roundTrip encodes the packet as the supplier publishes it and decodes it as the reactor consumes it.
+func roundTrip(p ss.Packet, timestamp time.Time) rs.Packet {
+	body, err := supplier.EncodePacket(p)
+	Expect(err).NotTo(HaveOccurred())
+
+	decoded, err := reactor.DecodePacket(p.Name(), packet.SchemaVersion, 1, timestamp, body)
+	Expect(err).NotTo(HaveOccurred())
+	return decoded
+}
+
+var _ = Describe("Supplier to Reactor", func() {
+	timestamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	It("round-trips a tick", func() {
+		actual := roundTrip(ss.Tick{
+			Year:  2024,
+			Month: 1,
+			Day:   2,
+		}, timestamp)
+
+		expected := rs.NewTick(1, timestamp)
+		expected.Year = 2024
+		expected.Month = 1
+		expected.Day = 2
+		Expect(actual).To(Equal(expected))
+	})
+
+	It("round-trips a message", func() {
+		actual := roundTrip(ss.Message{
+			ID: "100",
+			Account: ss.Account{
+				ID:          "1",
+				Acct:        "test",
+				DisplayName: "テスト",
+				Username:    "test",
+			},
+			CreatedAt:   timestamp,
+			EditedAt:    timestamp.Add(time.Minute),
+			Content:     "@ejaculation_counter ぴゅっ♡",
+			Emojis:      []ss.Emoji{{Shortcode: "ios"}},
+			InReplyToID: "99",
+			IsReblog:    true,
+			Mentions:    []ss.Mention{{ID: "2", Acct: "ejaculation_counter", Username: "ejaculation_counter"}},
+			Tags:        []ss.Tag{{Name: "ejaculation_counter"}},
+			Visibility:  "unlisted",
+		}, timestamp)
+
+		expected := rs.NewMessage(1, timestamp)
+		expected.ID = "100"
+		expected.Account = rs.Account{
+			ID:          "1",
+			Acct:        "test",
+			DisplayName: "テスト",
+			Username:    "test",
+		}
+		expected.CreatedAt = timestamp
+		expected.EditedAt = timestamp.Add(time.Minute)
+		expected.Content = "@ejaculation_counter ぴゅっ♡"
+		expected.Emojis = []rs.Emoji{{Shortcode: "ios"}}
+		expected.InReplyToID = "99"
+		expected.IsReblog = true
+		expected.Mentions = []rs.Mention{{ID: "2", Acct: "ejaculation_counter", Username: "ejaculation_counter"}}
+		expected.Tags = []rs.Tag{{Name: "ejaculation_counter"}}
+		expected.Visibility = "unlisted"
+		Expect(actual).To(Equal(expected))
+	})
+
+	It("round-trips a notification", func() {
+		actual := roundTrip(ss.Notification{
+			ID:   "200",
+			Type: "mention",
+			Account: ss.Account{
+				ID:          "1",
+				Acct:        "test",
+				DisplayName: "テスト",
+				Username:    "test",
+			},
+			CreatedAt: timestamp,
+			StatusID:  "100",
+		}, timestamp)
+
+		expected := rs.NewNotification(1, timestamp)
+		expected.ID = "200"
+		expected.Type = "mention"
+		expected.Account = rs.Account{
+			ID:          "1",
+			Acct:        "test",
+			DisplayName: "テスト",
+			Username:    "test",
+		}
+		expected.CreatedAt = timestamp
+		expected.StatusID = "100"
+		Expect(actual).To(Equal(expected))
+	})
+
+	It("round-trips a deletion", func() {
+		actual := roundTrip(ss.Deletion{
+			ID:        "100",
+			DeletedAt: timestamp,
+		}, timestamp)
+
+		expected := rs.NewDeletion(1, timestamp)
+		expected.ID = "100"
+		expected.DeletedAt = timestamp
+		Expect(actual).To(Equal(expected))
+	})
+
+	It("keeps the names of packet types in sync", func() {
+		Expect(ss.Tick{}.Name()).To(Equal(packet.TypeTick))
+		Expect(ss.Message{}.Name()).To(Equal(packet.TypeMessage))
+		Expect(ss.Notification{}.Name()).To(Equal(packet.TypeNotification))
+		Expect(ss.Deletion{}.Name()).To(Equal(packet.TypeDeletion))
+	})
+})
+
+var _ = Describe("Reactor", func() {
+	timestamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	It("decodes a message published by a supplier of version 1", func() {
+		body := []byte(`{"id":"100","account":{"id":"1","acct":"test","display_name":"テスト","user_name":"test"},"created_at":"2024-01-02T15:04:05Z","edited_at":"0001-01-01T00:00:00Z","content":"ぴゅっ♡","emojis":null,"in_reply_to_id":"","is_reblog":false,"mentions":null,"tags":null,"visibility":"public"}`)
+		actual, err := reactor.DecodePacket(packet.TypeMessage, packet.Version(nil), 1, timestamp, body)
+		Expect(err).NotTo(HaveOccurred())
+
+		expected := rs.NewMessage(1, timestamp)
+		expected.ID = "100"
+		expected.Account = rs.Account{
+			ID:          "1",
+			Acct:        "test",
+			DisplayName: "テスト",
+			Username:    "test",
+		}
+		expected.CreatedAt = timestamp
+		expected.Content = "ぴゅっ♡"
+		expected.Visibility = "public"
+		Expect(actual).To(Equal(expected))
+	})
+})
+
+var _ = Describe("Supplier", func() {
+	It("publishes the username for reactors of version 1", func() {
+		body, err := supplier.EncodePacket(ss.Notification{
+			ID:   "200",
+			Type: "follow",
+			Account: ss.Account{
+				ID:       "1",
+				Acct:     "test",
+				Username: "test",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(body).To(ContainSubstring(`"user_name":"test"`))
+	})
+})
@@ -0,0 +1,172 @@
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/e2e/fakemastodon"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	BotID   = "1"
+	BotName = "ejaculation_counter"
+	Token   = "token"
+)
+
+var (
+	fake   *fakemastodon.Server
+	server *httptest.Server
+	mpyw   *httptest.Server
+	mqHost string
+)
+
+func TestE2E(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "E2E Suite")
+}
+
+// requireEnv returns the value of the environment variable or skips the suite when it is not set,
+// as the suite needs a running RabbitMQ and PostgreSQL.
+func requireEnv(name string) string {
+	v := os.Getenv(name)
+	if v == "" {
+		Skip(fmt.Sprintf("%s is not set", name))
+	}
+	return v
+}
+
+func build(dir, name string) string {
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	output := filepath.Join(GinkgoT().TempDir(), name)
+
+	cmd := exec.Command("go", "build", "-o", output, ".")
+	cmd.Dir = dir
+	cmd.Stdout = GinkgoWriter
+	cmd.Stderr = GinkgoWriter
+	Expect(cmd.Run()).To(Succeed(), "failed to build %s", name)
+
+	return output
+}
+
+func freePort() string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	Expect(err).NotTo(HaveOccurred())
+	return port
+}
+
+func start(path string, env map[string]string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdout = GinkgoWriter
+	cmd.Stderr = GinkgoWriter
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = 10 * time.Second
+	Expect(cmd.Start()).To(Succeed())
+
+	DeferCleanup(func() {
+		cancel()
+		_ = cmd.Wait()
+	})
+}
+
+// ready reports whether the web server of the service is listening, which it starts after connecting to the queue.
+func ready(port string) func() error {
+	return func() error {
+		res, err := http.Get("http://127.0.0.1:" + port + "/metrics")
+		if err != nil {
+			return err
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status: %s", res.Status)
+		}
+		return nil
+	}
+}
+
+var _ = BeforeSuite(func() {
+	mqHost = requireEnv("E2E_MQ_HOST")
+	dbHost := requireEnv("E2E_DB_HOST")
+
+	fake = fakemastodon.NewServer(fakemastodon.Account{
+		ID:          BotID,
+		Username:    BotName,
+		Acct:        BotName,
+		DisplayName: "ぴゅっぴゅカウンター（昨日: 0 / 今日: 0）",
+	}, Token)
+	server = httptest.NewServer(fake)
+	DeferCleanup(server.Close)
+
+	mpyw = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"title":"実務経験ガチャ","result":["実務経験 10 年"]}`)
+	}))
+	DeferCleanup(mpyw.Close)
+
+	supplier := build("../supplier", "supplier")
+	reactor := build("../reactor", "reactor")
+
+	queue := map[string]string{
+		"MQ_HOST":     mqHost,
+		"MQ_USERNAME": os.Getenv("E2E_MQ_USERNAME"),
+		"MQ_PASSWORD": os.Getenv("E2E_MQ_PASSWORD"),
+	}
+
+	supplierPort := freePort()
+	supplierEnv := map[string]string{
+		"MASTODON_USER_ID":      BotID,
+		"MASTODON_SERVER_URL":   server.URL,
+		"MASTODON_ACCESS_TOKEN": Token,
+		"MASTODON_STREAM":       "user",
+		"PORT":                  supplierPort,
+		"LOG_LEVEL":             "debug",
+	}
+	for k, v := range queue {
+		supplierEnv[k] = v
+	}
+	start(supplier, supplierEnv)
+	Eventually(fake.Subscribers).WithTimeout(30 * time.Second).Should(BeNumerically(">=", 1))
+
+	reactorPort := freePort()
+	reactorEnv := map[string]string{
+		"USER_ID":               "1",
+		"DB_HOST":               dbHost,
+		"DB_DATABASE":           os.Getenv("E2E_DB_DATABASE"),
+		"DB_USERNAME":           os.Getenv("E2E_DB_USERNAME"),
+		"DB_PASSWORD":           os.Getenv("E2E_DB_PASSWORD"),
+		"DB_SSL_MODE":           "disable",
+		"MASTODON_USER_ID":      BotID,
+		"MASTODON_SERVER_URL":   server.URL,
+		"MASTODON_ACCESS_TOKEN": Token,
+		"EXT_MPYW_API_URL":      mpyw.URL,
+		"PORT":                  reactorPort,
+	}
+	for k, v := range queue {
+		reactorEnv[k] = v
+	}
+	start(reactor, reactorEnv)
+	Eventually(ready(reactorPort)).WithTimeout(30 * time.Second).Should(Succeed())
+})
@@ -0,0 +1,13 @@
+package fakemastodon_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFakemastodon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fakemastodon Suite")
+}
@@ -0,0 +1,484 @@
+// Package fakemastodon provides an in-memory Mastodon server that covers the API surface used by the supplier and the reactor.
+package fakemastodon
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	ServerName   = "fakemastodon"
+	ServerHeader = "X-Served-By"
+	DefaultLimit = 20
+)
+
+type Account struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+}
+
+type Mention struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Acct     string `json:"acct"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Status struct {
+	ID          string    `json:"id"`
+	Account     Account   `json:"account"`
+	CreatedAt   time.Time `json:"created_at"`
+	Content     string    `json:"content"`
+	InReplyToID *string   `json:"in_reply_to_id"`
+	Visibility  string    `json:"visibility"`
+	Mentions    []Mention `json:"mentions"`
+	Tags        []Tag     `json:"tags"`
+	Emojis      []any     `json:"emojis"`
+	MediaIDs    []string  `json:"-"`
+}
+
+type Attachment struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+type streamRequest struct {
+	Type   string `json:"type"`
+	Stream string `json:"stream"`
+	Tag    string `json:"tag"`
+}
+
+type streamEvent struct {
+	Stream  []string `json:"stream"`
+	Event   string   `json:"event"`
+	Payload string   `json:"payload"`
+}
+
+type subscriber struct {
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	streams map[string]streamRequest
+}
+
+// Server is an in-memory Mastodon server. Statuses posted through the API or Publish are
+// delivered to streaming subscribers as they would be by a real server.
+type Server struct {
+	mu          sync.Mutex
+	nextID      int64
+	account     Account
+	accounts    map[string]Account
+	statuses    []Status
+	deleted     map[string]bool
+	media       map[string][]byte
+	subscribers map[*subscriber]struct{}
+	upgrader    websocket.Upgrader
+	mux         *http.ServeMux
+	Token       string
+}
+
+// NewServer returns a server whose authenticated user is account. If token is not empty,
+// requests without the token are rejected.
+func NewServer(account Account, token string) *Server {
+	s := &Server{
+		account:     account,
+		accounts:    map[string]Account{account.ID: account},
+		deleted:     map[string]bool{},
+		media:       map[string][]byte{},
+		subscribers: map[*subscriber]struct{}{},
+		mux:         http.NewServeMux(),
+		Token:       token,
+	}
+
+	s.mux.HandleFunc("GET /api/v1/accounts/verify_credentials", s.handleVerifyCredentials)
+	s.mux.HandleFunc("PATCH /api/v1/accounts/update_credentials", s.handleUpdateCredentials)
+	s.mux.HandleFunc("POST /api/v1/statuses", s.handlePostStatus)
+	s.mux.HandleFunc("DELETE /api/v1/statuses/{id}", s.handleDeleteStatus)
+	s.mux.HandleFunc("POST /api/v2/media", s.handleUploadMedia)
+	s.mux.HandleFunc("GET /api/v1/timelines/home", s.handleTimeline)
+	s.mux.HandleFunc("GET /api/v1/timelines/public", s.handleTimeline)
+	s.mux.HandleFunc("GET /api/v1/timelines/tag/{tag}", s.handleTimeline)
+	s.mux.HandleFunc("GET /api/v1/notifications", s.handleEmpty)
+	s.mux.HandleFunc("GET /api/v1/conversations", s.handleEmpty)
+	s.mux.HandleFunc("GET /api/v1/streaming", s.handleStreaming)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(ServerHeader, ServerName)
+
+	if s.Token != "" && !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "The access token is invalid"})
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token == s.Token
+	}
+	return r.URL.Query().Get("access_token") == s.Token
+}
+
+// Account returns the current profile of the authenticated user.
+func (s *Server) Account() Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.account
+}
+
+// Statuses returns the statuses that have not been deleted, oldest first.
+func (s *Server) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Status
+	for _, status := range s.statuses {
+		if !s.deleted[status.ID] {
+			result = append(result, status)
+		}
+	}
+	return result
+}
+
+// Subscribers returns the number of streaming connections.
+func (s *Server) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// Publish posts a status from account and delivers it to streaming subscribers.
+// Mentions are taken from the words in content that start with @ and tags from those with #.
+func (s *Server) Publish(account Account, content, visibility, inReplyToID string) Status {
+	s.mu.Lock()
+	s.accounts[account.ID] = account
+	status := s.newStatus(account, content, visibility, inReplyToID)
+	s.mu.Unlock()
+
+	s.broadcast(status)
+	return status
+}
+
+func (s *Server) newStatus(account Account, content, visibility, inReplyToID string) Status {
+	s.nextID++
+
+	status := Status{
+		ID:         strconv.FormatInt(s.nextID, 10),
+		Account:    account,
+		CreatedAt:  time.Now().UTC(),
+		Content:    "<p>" + html.EscapeString(content) + "</p>",
+		Visibility: visibility,
+		Mentions:   []Mention{},
+		Tags:       []Tag{},
+		Emojis:     []any{},
+	}
+	if status.Visibility == "" {
+		status.Visibility = "public"
+	}
+	if inReplyToID != "" {
+		status.InReplyToID = &inReplyToID
+	}
+
+	for word := range strings.FieldsSeq(content) {
+		if acct, ok := strings.CutPrefix(word, "@"); ok {
+			mention := Mention{Acct: acct}
+			mention.Username, _, _ = strings.Cut(acct, "@")
+			for _, a := range s.accounts {
+				if a.Acct == acct {
+					mention.ID = a.ID
+				}
+			}
+			status.Mentions = append(status.Mentions, mention)
+		}
+		if tag, ok := strings.CutPrefix(word, "#"); ok {
+			status.Tags = append(status.Tags, Tag{Name: strings.ToLower(tag)})
+		}
+	}
+
+	s.statuses = append(s.statuses, status)
+	return status
+}
+
+func (s *Server) streamsFor(status Status) []streamRequest {
+	var streams []streamRequest
+	switch status.Visibility {
+	case "public":
+		streams = append(streams, streamRequest{Stream: "public"}, streamRequest{Stream: "public:local"})
+		for _, tag := range status.Tags {
+			streams = append(streams, streamRequest{Stream: "hashtag", Tag: tag.Name}, streamRequest{Stream: "hashtag:local", Tag: tag.Name})
+		}
+	case "direct":
+		streams = append(streams, streamRequest{Stream: "direct"})
+	}
+
+	// Everything is delivered to the home timeline of the authenticated user, who follows everyone here.
+	if status.Visibility != "direct" || status.Account.ID == s.account.ID || slices.ContainsFunc(status.Mentions, func(m Mention) bool {
+		return m.ID == s.account.ID
+	}) {
+		streams = append(streams, streamRequest{Stream: "user"})
+	}
+	return streams
+}
+
+func (s *Server) broadcast(status Status) {
+	payload, err := json.Marshal(status)
+	if err != nil {
+		slog.Error("Failed to encode status", slog.Any("err", err))
+		return
+	}
+
+	s.mu.Lock()
+	streams := s.streamsFor(status)
+	subscribers := make([]*subscriber, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.send(streams, "update", string(payload))
+	}
+}
+
+func (s *Server) broadcastDelete(id string) {
+	s.mu.Lock()
+	subscribers := make([]*subscriber, 0, len(s.subscribers))
+	for sub := range s.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.send([]streamRequest{{Stream: "user"}, {Stream: "public"}}, "delete", id)
+	}
+}
+
+func (sub *subscriber) send(streams []streamRequest, event, payload string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	for _, stream := range streams {
+		key := stream.Stream + ":" + stream.Tag
+		if _, ok := sub.streams[key]; !ok {
+			continue
+		}
+
+		name := []string{stream.Stream}
+		if stream.Tag != "" {
+			name = append(name, stream.Tag)
+		}
+		err := sub.conn.WriteJSON(streamEvent{
+			Stream:  name,
+			Event:   event,
+			Payload: payload,
+		})
+		if err != nil {
+			slog.Debug("Failed to write to streaming", slog.Any("err", err))
+		}
+
+		// Real servers deliver a status once per stream; once is enough for a single connection here.
+		return
+	}
+}
+
+func (s *Server) handleVerifyCredentials(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Account())
+}
+
+func (s *Server) handleUpdateCredentials(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	if r.Form.Has("display_name") {
+		s.account.DisplayName = r.Form.Get("display_name")
+		s.accounts[s.account.ID] = s.account
+	}
+	account := s.account
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+func (s *Server) handlePostStatus(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	content := r.Form.Get("status")
+	if content == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "Validation failed: Text can't be blank"})
+		return
+	}
+
+	s.mu.Lock()
+	status := s.newStatus(s.account, content, r.Form.Get("visibility"), r.Form.Get("in_reply_to_id"))
+	status.MediaIDs = r.Form["media_ids[]"]
+	s.statuses[len(s.statuses)-1] = status
+	s.mu.Unlock()
+
+	s.broadcast(status)
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleDeleteStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	idx := slices.IndexFunc(s.statuses, func(status Status) bool {
+		return status.ID == id
+	})
+	found := idx >= 0 && !s.deleted[id]
+	var status Status
+	if found {
+		s.deleted[id] = true
+		status = s.statuses[idx]
+	}
+	s.mu.Unlock()
+
+	if !found {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Record not found"})
+		return
+	}
+
+	s.broadcastDelete(id)
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.FormatInt(s.nextID, 10)
+	s.media[id] = b
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, Attachment{ID: id, Type: "image"})
+}
+
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, _ := strconv.ParseInt(q.Get("since_id"), 10, 64)
+	if minID, err := strconv.ParseInt(q.Get("min_id"), 10, 64); err == nil {
+		since = minID
+	}
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = DefaultLimit
+	}
+	tag := r.PathValue("tag")
+
+	var result []Status
+	for _, status := range slices.Backward(s.Statuses()) {
+		id, _ := strconv.ParseInt(status.ID, 10, 64)
+		if id <= since {
+			continue
+		}
+		if tag != "" && !slices.Contains(status.Tags, Tag{Name: strings.ToLower(tag)}) {
+			continue
+		}
+		result = append(result, status)
+		if len(result) == limit {
+			break
+		}
+	}
+	if result == nil {
+		result = []Status{}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleEmpty(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []any{})
+}
+
+func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, http.Header{ServerHeader: {ServerName}})
+	if err != nil {
+		return
+	}
+
+	sub := &subscriber{
+		conn:    conn,
+		streams: map[string]streamRequest{},
+	}
+	if stream := r.URL.Query().Get("stream"); stream != "" {
+		req := streamRequest{Type: "subscribe", Stream: stream, Tag: strings.ToLower(r.URL.Query().Get("tag"))}
+		sub.streams[req.Stream+":"+req.Tag] = req
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	for {
+		var req streamRequest
+		err := conn.ReadJSON(&req)
+		if err != nil {
+			return
+		}
+
+		sub.mu.Lock()
+		switch req.Type {
+		case "subscribe":
+			req.Tag = strings.ToLower(req.Tag)
+			sub.streams[req.Stream+":"+req.Tag] = req
+		case "unsubscribe":
+			delete(sub.streams, req.Stream+":"+strings.ToLower(req.Tag))
+		}
+		sub.mu.Unlock()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,150 @@
+package fakemastodon_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/e2e/fakemastodon"
+	"github.com/gorilla/websocket"
+	mast "github.com/mattn/go-mastodon"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		fake   *fakemastodon.Server
+		server *httptest.Server
+		client *mast.Client
+		user   fakemastodon.Account
+	)
+
+	BeforeEach(func() {
+		fake = fakemastodon.NewServer(fakemastodon.Account{
+			ID:          "1",
+			Username:    "ejaculation_counter",
+			Acct:        "ejaculation_counter",
+			DisplayName: "テスト（昨日: 0 / 今日: 0）",
+		}, "token")
+		server = httptest.NewServer(fake)
+		client = mast.NewClient(&mast.Config{
+			Server:      server.URL,
+			AccessToken: "token",
+		})
+		user = fakemastodon.Account{
+			ID:          "100",
+			Username:    "test",
+			Acct:        "test",
+			DisplayName: "テスト",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("verify_credentials", func() {
+		Context("token is invalid", func() {
+			It("returns an error", func() {
+				client.Config.AccessToken = "invalid"
+				_, err := client.GetAccountCurrentUser(context.Background())
+				Expect(err).To(MatchError(HavePrefix("bad request: 401 Unauthorized")))
+			})
+		})
+
+		Context("token is valid", func() {
+			It("returns the account", func() {
+				account, err := client.GetAccountCurrentUser(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(account.ID).To(Equal(mast.ID("1")))
+				Expect(account.DisplayName).To(Equal("テスト（昨日: 0 / 今日: 0）"))
+			})
+		})
+	})
+
+	Describe("update_credentials", func() {
+		It("updates the display name", func() {
+			displayName := "テスト（昨日: 0 / 今日: 1）"
+			account, err := client.AccountUpdate(context.Background(), &mast.Profile{
+				DisplayName: &displayName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(account.DisplayName).To(Equal(displayName))
+			Expect(fake.Account().DisplayName).To(Equal(displayName))
+		})
+	})
+
+	Describe("statuses", func() {
+		It("posts, lists and deletes statuses", func() {
+			toot := fake.Publish(user, "@ejaculation_counter 実務経験ガチャ", "public", "")
+			Expect(toot.Mentions).To(Equal([]fakemastodon.Mention{
+				{ID: "1", Username: "ejaculation_counter", Acct: "ejaculation_counter"},
+			}))
+
+			attachment, err := client.UploadMediaFromReader(context.Background(), strings.NewReader("image"))
+			Expect(err).NotTo(HaveOccurred())
+
+			status, err := client.PostStatus(context.Background(), &mast.Toot{
+				Status:      "@test 診断結果",
+				InReplyToID: mast.ID(toot.ID),
+				MediaIDs:    []mast.ID{attachment.ID},
+				Visibility:  "unlisted",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Content).To(Equal("<p>@test 診断結果</p>"))
+			Expect(status.InReplyToID).To(Equal(toot.ID))
+
+			statuses, err := client.GetTimelineHome(context.Background(), &mast.Pagination{SinceID: mast.ID(toot.ID)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].ID).To(Equal(status.ID))
+
+			Expect(fake.Statuses()).To(HaveLen(2))
+			Expect(fake.Statuses()[1].MediaIDs).To(Equal([]string{string(attachment.ID)}))
+
+			err = client.DeleteStatus(context.Background(), status.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.Statuses()).To(HaveLen(1))
+
+			err = client.DeleteStatus(context.Background(), status.ID)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("streaming", func() {
+		It("delivers statuses to subscribed streams", func() {
+			u := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/streaming?access_token=token&stream=hashtag&tag=Ejaculation_Counter"
+			conn, res, err := websocket.DefaultDialer.Dial(u, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Header.Get(fakemastodon.ServerHeader)).To(Equal(fakemastodon.ServerName))
+			defer conn.Close()
+
+			Eventually(fake.Subscribers).Should(Equal(1))
+			fake.Publish(user, "unrelated", "public", "")
+			fake.Publish(user, "#ejaculation_counter", "public", "")
+
+			var event struct {
+				Stream  []string `json:"stream"`
+				Event   string   `json:"event"`
+				Payload string   `json:"payload"`
+			}
+			err = conn.ReadJSON(&event)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Stream).To(Equal([]string{"hashtag", "ejaculation_counter"}))
+			Expect(event.Event).To(Equal("update"))
+			Expect(event.Payload).To(MatchJSON(`{
+				"id": "2",
+				"account": {"id": "100", "username": "test", "acct": "test", "display_name": "テスト"},
+				"created_at": "` + fake.Statuses()[1].CreatedAt.Format(time.RFC3339Nano) + `",
+				"content": "<p>#ejaculation_counter</p>",
+				"in_reply_to_id": null,
+				"visibility": "public",
+				"mentions": [],
+				"tags": [{"name": "ejaculation_counter"}],
+				"emojis": []
+			}`))
+		})
+	})
+})
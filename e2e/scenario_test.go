@@ -0,0 +1,139 @@
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/e2e/fakemastodon"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func publishTick(year int, month time.Month, day int) {
+	uri, err := amqp.ParseURI(mqHost)
+	Expect(err).NotTo(HaveOccurred())
+	uri.Username = os.Getenv("E2E_MQ_USERNAME")
+	uri.Password = os.Getenv("E2E_MQ_PASSWORD")
+
+	conn, err := amqp.Dial(uri.String())
+	Expect(err).NotTo(HaveOccurred())
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	Expect(err).NotTo(HaveOccurred())
+	defer ch.Close()
+
+	body, err := json.Marshal(map[string]int{
+		"year":  year,
+		"month": int(month),
+		"day":   day,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	// The reactor reads the date from the body, whereas the timestamp must be recent not to be expired.
+	err = ch.PublishWithContext(
+		context.Background(),
+		"ejaculation-counter.packets",
+		"packets",
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Timestamp:   time.Now(),
+			Type:        "packets.tick",
+			Headers: amqp.Table{
+				"x-deduplication-header": fmt.Sprintf("packets.tick-e2e-%d", time.Now().UnixNano()),
+			},
+			Body: body,
+		},
+	)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func replyTo(id string) func() []fakemastodon.Status {
+	return func() []fakemastodon.Status {
+		var result []fakemastodon.Status
+		for _, status := range fake.Statuses() {
+			if status.InReplyToID != nil && *status.InReplyToID == id {
+				result = append(result, status)
+			}
+		}
+		return result
+	}
+}
+
+func displayName() string {
+	return fake.Account().DisplayName
+}
+
+// counts returns the display name of the bot with the counts of yesterday and today.
+func counts(yesterday, today int) string {
+	return fmt.Sprintf("ぴゅっぴゅカウンター（昨日: %d / 今日: %d）", yesterday, today)
+}
+
+var _ = Describe("Ejaculation counter", Ordered, func() {
+	var (
+		bot       fakemastodon.Account
+		user      fakemastodon.Account
+		yesterday int
+		today     int
+	)
+
+	BeforeAll(func() {
+		bot = fake.Account()
+
+		// The counts go on from the display name of the bot, which the reactor reads them from.
+		matches := regexp.MustCompile(`（昨日: (\d+) / 今日: (\d+)）`).FindStringSubmatch(bot.DisplayName)
+		Expect(matches).To(HaveLen(3))
+		yesterday, _ = strconv.Atoi(matches[1])
+		today, _ = strconv.Atoi(matches[2])
+
+		user = fakemastodon.Account{
+			ID:          "100",
+			Username:    "test",
+			Acct:        "test",
+			DisplayName: "テスト",
+		}
+	})
+
+	It("increments today's count on ぴゅっ♡", func() {
+		fake.Publish(bot, "ぴゅっ♡", "public", "")
+		Eventually(displayName).WithTimeout(30 * time.Second).Should(Equal(counts(yesterday, today+1)))
+
+		fake.Publish(bot, "ぴゅっ♡♡", "public", "")
+		Eventually(displayName).WithTimeout(30 * time.Second).Should(Equal(counts(yesterday, today+2)))
+	})
+
+	It("rolls over the count on a new day", func() {
+		now := time.Now()
+		publishTick(now.Year(), now.Month(), now.Day())
+
+		Eventually(displayName).WithTimeout(30 * time.Second).Should(Equal(counts(today+2, 0)))
+		Eventually(func() []string {
+			var contents []string
+			for _, status := range fake.Statuses() {
+				if status.Account.ID == BotID {
+					contents = append(contents, status.Content)
+				}
+			}
+			return contents
+		}).WithTimeout(30 * time.Second).Should(ContainElement(MatchRegexp(`%s [はも] %d 回ぴゅっぴゅしました`, now.AddDate(0, 0, -1).Format(time.DateOnly), today+2)))
+	})
+
+	It("replies to 実務経験ガチャ", func() {
+		toot := fake.Publish(user, "実務経験ガチャ", "public", "")
+
+		Eventually(replyTo(toot.ID)).WithTimeout(30 * time.Second).Should(ConsistOf(
+			WithTransform(func(status fakemastodon.Status) string {
+				return strings.TrimSuffix(strings.TrimPrefix(status.Content, "<p>"), "</p>")
+			}, Equal("@test 実務経験 10 年")),
+		))
+	})
+})
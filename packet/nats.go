@@ -0,0 +1,27 @@
+package packet
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// NATSPrioritySuffix is appended to the subject of packets with high priority, as JetStream has no message priority.
+	NATSPrioritySuffix = ".priority"
+
+	// NATSDuplicates is the window in which packets with the same message ID are stored only once.
+	NATSDuplicates = 1 * time.Minute
+)
+
+// NATSStream returns the configuration of the stream that carries packets published to subject,
+// which is declared by both the supplier and the reactor so that either of them can start first.
+func NATSStream(name, subject string) jetstream.StreamConfig {
+	return jetstream.StreamConfig{
+		Name:       name,
+		Subjects:   []string{subject, subject + NATSPrioritySuffix},
+		Retention:  jetstream.WorkQueuePolicy,
+		Storage:    jetstream.FileStorage,
+		Duplicates: NATSDuplicates,
+	}
+}
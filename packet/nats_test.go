@@ -0,0 +1,22 @@
+package packet_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/nats-io/nats.go/jetstream"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NATSStream()", func() {
+	It("returns the stream for the subject and its priority subject", func() {
+		Expect(packet.NATSStream("ejaculation-counter", "packets")).To(Equal(jetstream.StreamConfig{
+			Name:       "ejaculation-counter",
+			Subjects:   []string{"packets", "packets.priority"},
+			Retention:  jetstream.WorkQueuePolicy,
+			Storage:    jetstream.FileStorage,
+			Duplicates: 1 * time.Minute,
+		}))
+	})
+})
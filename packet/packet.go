@@ -0,0 +1,141 @@
+// Package packet defines the schema of packets sent from the supplier to the reactor through the message queue.
+package packet
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	// SchemaVersion is the version of the schema written by this package.
+	//
+	//  1: Account.Username is encoded as user_name (no schema_version header).
+	//  2: Account.Username is encoded as username.
+	SchemaVersion = 2
+
+	// SchemaVersionHeader is the name of the header that carries the schema version of the body.
+	SchemaVersionHeader = "schema_version"
+
+	TypeTick         = "packets.tick"
+	TypeMessage      = "packets.message"
+	TypeNotification = "packets.notification"
+	TypeDeletion     = "packets.deletion"
+)
+
+// Version returns the schema version in the headers, defaulting to 1 for packets written before the header was introduced.
+func Version(headers map[string]any) int {
+	switch v := headers[SchemaVersionHeader].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return 1
+}
+
+// Convert converts each element of s, keeping nil as nil.
+func Convert[T, U any](s []T, f func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	result := make([]U, len(s))
+	for i, v := range s {
+		result[i] = f(v)
+	}
+	return result
+}
+
+type Tick struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+type Message struct {
+	ID          string    `json:"id"`
+	Account     Account   `json:"account"`
+	CreatedAt   time.Time `json:"created_at"`
+	EditedAt    time.Time `json:"edited_at"`
+	Content     string    `json:"content"`
+	Emojis      []Emoji   `json:"emojis"`
+	InReplyToID string    `json:"in_reply_to_id"`
+	IsReblog    bool      `json:"is_reblog"`
+	Mentions    []Mention `json:"mentions"`
+	Tags        []Tag     `json:"tags"`
+	Visibility  string    `json:"visibility"`
+}
+
+type Account struct {
+	ID          string `json:"id"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+	Username    string `json:"username"`
+}
+
+type account Account
+
+type accountV1 struct {
+	account
+	UserName string `json:"user_name"`
+}
+
+// MarshalJSON encodes the account with user_name as well so that readers of version 1 keep working during rolling upgrades.
+func (a Account) MarshalJSON() ([]byte, error) {
+	return json.Marshal(accountV1{
+		account:  account(a),
+		UserName: a.Username,
+	})
+}
+
+// UnmarshalJSON decodes the account of either version.
+func (a *Account) UnmarshalJSON(data []byte) error {
+	var v accountV1
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	*a = Account(v.account)
+	if a.Username == "" {
+		a.Username = v.UserName
+	}
+	return nil
+}
+
+type Mention struct {
+	ID       string `json:"id"`
+	Acct     string `json:"acct"`
+	Username string `json:"username"`
+}
+
+type Emoji struct {
+	Shortcode string `json:"shortcode"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Notification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Account   Account   `json:"account"`
+	CreatedAt time.Time `json:"created_at"`
+	StatusID  string    `json:"status_id"`
+}
+
+type Deletion struct {
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,144 @@
+package packet_test
+
+import (
+	"encoding/json"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version()", func() {
+	Context("header is missing", func() {
+		It("returns 1", func() {
+			Expect(packet.Version(nil)).To(Equal(1))
+			Expect(packet.Version(map[string]any{})).To(Equal(1))
+		})
+	})
+
+	Context("header is an integer", func() {
+		It("returns the version", func() {
+			Expect(packet.Version(map[string]any{"schema_version": int32(2)})).To(Equal(2))
+			Expect(packet.Version(map[string]any{"schema_version": int64(3)})).To(Equal(3))
+		})
+	})
+
+	Context("header is a string", func() {
+		It("returns the version", func() {
+			Expect(packet.Version(map[string]any{"schema_version": "2"})).To(Equal(2))
+		})
+	})
+
+	Context("header is invalid", func() {
+		It("returns 1", func() {
+			Expect(packet.Version(map[string]any{"schema_version": "v2"})).To(Equal(1))
+		})
+	})
+})
+
+var _ = Describe("Convert()", func() {
+	Context("slice is nil", func() {
+		It("returns nil", func() {
+			Expect(packet.Convert(nil, func(tag packet.Tag) string { return tag.Name })).To(BeNil())
+		})
+	})
+
+	Context("slice is empty", func() {
+		It("returns an empty slice", func() {
+			actual := packet.Convert([]packet.Tag{}, func(tag packet.Tag) string { return tag.Name })
+			Expect(actual).NotTo(BeNil())
+			Expect(actual).To(BeEmpty())
+		})
+	})
+
+	Context("slice is not empty", func() {
+		It("returns the converted elements", func() {
+			actual := packet.Convert([]packet.Tag{{Name: "a"}, {Name: "b"}}, func(tag packet.Tag) string { return tag.Name })
+			Expect(actual).To(Equal([]string{"a", "b"}))
+		})
+	})
+})
+
+var _ = Describe("Account", func() {
+	Describe("MarshalJSON()", func() {
+		It("encodes the username with both keys", func() {
+			actual, err := json.Marshal(packet.Account{
+				ID:          "1",
+				Acct:        "test",
+				DisplayName: "テスト",
+				Username:    "test",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(MatchJSON(`{"id":"1","acct":"test","display_name":"テスト","username":"test","user_name":"test"}`))
+		})
+	})
+
+	Describe("UnmarshalJSON()", func() {
+		var (
+			actual packet.Account
+		)
+
+		BeforeEach(func() {
+			actual = packet.Account{}
+		})
+
+		Context("version 1 is given", func() {
+			It("decodes user_name", func() {
+				err := json.Unmarshal([]byte(`{"id":"1","acct":"test","display_name":"テスト","user_name":"test"}`), &actual)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(Equal(packet.Account{
+					ID:          "1",
+					Acct:        "test",
+					DisplayName: "テスト",
+					Username:    "test",
+				}))
+			})
+		})
+
+		Context("version 2 is given", func() {
+			It("decodes username", func() {
+				err := json.Unmarshal([]byte(`{"id":"1","acct":"test","display_name":"テスト","username":"test"}`), &actual)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(Equal(packet.Account{
+					ID:          "1",
+					Acct:        "test",
+					DisplayName: "テスト",
+					Username:    "test",
+				}))
+			})
+		})
+
+		Context("invalid JSON is given", func() {
+			It("returns an error", func() {
+				err := json.Unmarshal([]byte(`{"id":1}`), &actual)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
+
+var _ = Describe("Message", func() {
+	It("round-trips", func() {
+		expected := packet.Message{
+			ID: "100",
+			Account: packet.Account{
+				ID:       "1",
+				Acct:     "test",
+				Username: "test",
+			},
+			Content:    "ぴゅっ♡",
+			Emojis:     []packet.Emoji{{Shortcode: "ios"}},
+			Mentions:   []packet.Mention{{ID: "2", Acct: "bot", Username: "bot"}},
+			Tags:       []packet.Tag{{Name: "ejaculation_counter"}},
+			Visibility: "public",
+		}
+
+		body, err := json.Marshal(expected)
+		Expect(err).NotTo(HaveOccurred())
+
+		var actual packet.Message
+		err = json.Unmarshal(body, &actual)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(actual).To(Equal(expected))
+	})
+})
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (e *engine) Authorize(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(e.AdminToken)) != 1 {
+		c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	c.Next()
+}
+
+func (e *engine) deadLetterError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrUnknownQueue) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	slog.Error("Failed to handle dead letters", slog.String("queue", c.Param("queue")), slog.Any("err", err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func (e *engine) HandleDeadLetters(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deadLetters, err := e.DeadLetters.List(c.Request.Context(), c.Param("queue"), limit)
+	if err != nil {
+		e.deadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+func (e *engine) HandleReplayDeadLetters(c *gin.Context) {
+	var req DeadLetterRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil || len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids are required"})
+		return
+	}
+
+	replayed, err := e.DeadLetters.Replay(c.Request.Context(), c.Param("queue"), req.IDs)
+	if err != nil {
+		e.deadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func (e *engine) HandlePurgeDeadLetters(c *gin.Context) {
+	var req DeadLetterRequest
+	if c.Request.ContentLength != 0 {
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	purged, err := e.DeadLetters.Purge(c.Request.Context(), c.Param("queue"), req.IDs)
+	if err != nil {
+		e.deadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
@@ -14,11 +14,13 @@ import (
 )
 
 type engine struct {
-	Through  service.Through
-	Doublet  service.Doublet
-	Port     string
-	CertFile string
-	KeyFile  string
+	Through     service.Through
+	Doublet     service.Doublet
+	DeadLetters service.DeadLetters
+	AdminToken  string
+	Port        string
+	CertFile    string
+	KeyFile     string
 }
 
 type Engine interface {
@@ -28,16 +30,20 @@ type Engine interface {
 func NewEngine(
 	through service.Through,
 	doublet service.Doublet,
+	deadLetters service.DeadLetters,
+	adminToken string,
 	port string,
 	certFile string,
 	keyFile string,
 ) Engine {
 	return &engine{
-		Through:  through,
-		Doublet:  doublet,
-		Port:     port,
-		CertFile: certFile,
-		KeyFile:  keyFile,
+		Through:     through,
+		Doublet:     doublet,
+		DeadLetters: deadLetters,
+		AdminToken:  adminToken,
+		Port:        port,
+		CertFile:    certFile,
+		KeyFile:     keyFile,
 	}
 }
 
@@ -56,6 +62,14 @@ func (e *engine) Start(ctx context.Context) error {
 	router.GET("/through", e.HandleThrough)
 	router.GET("/doublet", e.HandleDoublet)
 
+	// The admin API is disabled unless a token is configured.
+	if e.AdminToken != "" {
+		admin := router.Group("/admin", e.Authorize)
+		admin.GET("/queues/:queue/messages", e.HandleDeadLetters)
+		admin.POST("/queues/:queue/replay", e.HandleReplayDeadLetters)
+		admin.POST("/queues/:queue/purge", e.HandlePurgeDeadLetters)
+	}
+
 	server := http.Server{
 		Addr:    net.JoinHostPort("", e.Port),
 		Handler: router,
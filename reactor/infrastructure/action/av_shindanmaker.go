@@ -53,7 +53,7 @@ func (as *avShindanmaker) Event(ctx context.Context, message service.Message) (s
 		return nil, 0, service.ErrNoMatch
 	}
 
-	result, err := as.Client.Do(ctx, matches[1], "https://shindanmaker.com/a/794363")
+	result, err := as.Client.Do(ctx, []string{matches[1]}, "https://shindanmaker.com/a/794363")
 	if err != nil {
 		return nil, index[4], fmt.Errorf("failed to create event: %w", err)
 	}
@@ -134,7 +134,7 @@ var _ = Describe("AVShindanmaker", func() {
 	Describe("Event()", func() {
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/794363").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/794363").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/794363": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -152,7 +152,7 @@ var _ = Describe("AVShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/794363").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/794363").Return(
 					"診断結果",
 					nil,
 				)
@@ -39,7 +39,7 @@ func (bs *battleChimpoShindanmaker) Target(message service.Message) bool {
 
 func (bs *battleChimpoShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := BattleChimpoRegex.FindStringIndex(message.Content)
-	result, err := bs.Client.Do(ctx, bs.Client.Name(message.Account), "https://shindanmaker.com/a/584238")
+	result, err := bs.Client.Do(ctx, shindanNames(bs.Client, message, bs.MastodonUserID), "https://shindanmaker.com/a/584238")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
@@ -440,7 +440,7 @@ var _ = Describe("BattleChimpoShindanmaker", func() {
 
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/584238").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/584238").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/584238": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -462,7 +462,7 @@ var _ = Describe("BattleChimpoShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/584238").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/584238").Return(
 					"診断結果",
 					nil,
 				)
@@ -489,5 +489,45 @@ var _ = Describe("BattleChimpoShindanmaker", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+
+		Context("toot mentions other accounts", func() {
+			BeforeEach(func() {
+				c.EXPECT().Name(service.Account{
+					ID:       "3",
+					Acct:     "test2@example.com",
+					Username: "test2",
+				}).Return("test2")
+				c.EXPECT().Do(context.Background(), []string{"テスト", "test2"}, "https://shindanmaker.com/a/584238").Return(
+					"診断結果",
+					nil,
+				)
+			})
+
+			It("returns an event with the mentioned account", func() {
+				event, index, err := battleChimpoShindanmaker.Event(context.Background(), service.Message{
+					ID:       "1",
+					IsReblog: false,
+					Account: service.Account{
+						DisplayName: "テスト",
+						Acct:        "@test",
+					},
+					Content: "絶対おちんぽなんかに負けない！",
+					Mentions: []service.Mention{
+						{ID: "1", Acct: "ejaculation_counter", Username: "ejaculation_counter"},
+						{ID: "3", Acct: "test2@example.com", Username: "test2"},
+						{ID: "4", Acct: "test3@example.com", Username: "test3"},
+					},
+					Visibility: "private",
+				})
+				Expect(event).To(Equal(service.ReplyEvent{
+					InReplyToID: "1",
+					Acct:        "@test",
+					Body:        io.NopCloser(strings.NewReader("診断結果")),
+					Visibility:  "private",
+				}))
+				Expect(index).To(Equal(9))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
 	})
 })
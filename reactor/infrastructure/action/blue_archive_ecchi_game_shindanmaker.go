@@ -47,7 +47,7 @@ func (bs *blueArchiveEcchiGameShindanmaker) Target(message service.Message) bool
 
 func (bs *blueArchiveEcchiGameShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := BlueArchiveEcchiGameRegex.FindStringSubmatchIndex(message.Content)
-	result, err := bs.Client.Do(ctx, bs.Client.Name(message.Account), "https://shindanmaker.com/a/1111071")
+	result, err := bs.Client.Do(ctx, []string{bs.Client.Name(message.Account)}, "https://shindanmaker.com/a/1111071")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
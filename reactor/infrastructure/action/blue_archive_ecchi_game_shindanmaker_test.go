@@ -106,7 +106,7 @@ var _ = Describe("BlueArchiveEcchiGameShindanmaker", func() {
 
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/1111071").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/1111071").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/1111071": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -128,7 +128,7 @@ var _ = Describe("BlueArchiveEcchiGameShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/1111071").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/1111071").Return(
 					"診断結果",
 					nil,
 				)
@@ -47,7 +47,7 @@ func (cs *chimpoChallengeShindanmaker) Target(message service.Message) bool {
 
 func (cs *chimpoChallengeShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := ChimpoChallengeRegex.FindStringIndex(message.Content)
-	result, err := cs.Client.Do(ctx, cs.Client.Name(message.Account), "https://shindanmaker.com/a/656461")
+	result, err := cs.Client.Do(ctx, []string{cs.Client.Name(message.Account)}, "https://shindanmaker.com/a/656461")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
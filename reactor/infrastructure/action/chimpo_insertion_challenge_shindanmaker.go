@@ -47,7 +47,7 @@ func (cs *chimpoInsertionChallengeShindanmaker) Target(message service.Message)
 
 func (cs *chimpoInsertionChallengeShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := ChimpoInsertionChallengeRegex.FindStringIndex(message.Content)
-	result, err := cs.Client.Do(ctx, cs.Client.Name(message.Account), "https://shindanmaker.com/a/670773")
+	result, err := cs.Client.Do(ctx, []string{cs.Client.Name(message.Account)}, "https://shindanmaker.com/a/670773")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
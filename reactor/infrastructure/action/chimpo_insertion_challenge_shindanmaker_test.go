@@ -169,7 +169,7 @@ var _ = Describe("ChimpoInsertionChallengeShindanmaker", func() {
 
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/670773").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/670773").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/670773": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -191,7 +191,7 @@ var _ = Describe("ChimpoInsertionChallengeShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/670773").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/670773").Return(
 					"診断結果",
 					nil,
 				)
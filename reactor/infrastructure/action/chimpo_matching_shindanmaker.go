@@ -47,7 +47,7 @@ func (bs *chimpoMatchingShindanmaker) Target(message service.Message) bool {
 
 func (bs *chimpoMatchingShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := ChimpoMatchingRegex.FindStringIndex(message.Content)
-	result, err := bs.Client.Do(ctx, bs.Client.Name(message.Account), "https://shindanmaker.com/a/855159")
+	result, err := bs.Client.Do(ctx, shindanNames(bs.Client, message, bs.MastodonUserID), "https://shindanmaker.com/a/855159")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
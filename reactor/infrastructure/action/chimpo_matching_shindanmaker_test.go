@@ -211,7 +211,7 @@ var _ = Describe("ChimpoMatchingShindanmaker", func() {
 
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/855159").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/855159": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -233,7 +233,7 @@ var _ = Describe("ChimpoMatchingShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/855159").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return(
 					"診断結果",
 					nil,
 				)
@@ -285,5 +285,46 @@ var _ = Describe("ChimpoMatchingShindanmaker", func() {
 				})
 			})
 		})
+
+		Context("toot mentions other accounts", func() {
+			BeforeEach(func() {
+				c.EXPECT().Name(service.Account{
+					ID:       "3",
+					Acct:     "test2@example.com",
+					Username: "test2",
+				}).Return("test2")
+				c.EXPECT().Do(context.Background(), []string{"テスト", "test2"}, "https://shindanmaker.com/a/855159").Return(
+					"診断結果",
+					nil,
+				)
+			})
+
+			It("returns an event with the mentioned account whose ID is known", func() {
+				event, index, err := chimpoMatchingShindanmaker.Event(context.Background(), service.Message{
+					ID:       "1",
+					IsReblog: false,
+					Account: service.Account{
+						DisplayName: "テスト",
+						Acct:        "@test",
+					},
+					Content: "ちんぽ揃えゲーム",
+					Mentions: []service.Mention{
+						{ID: "1", Acct: "ejaculation_counter", Username: "ejaculation_counter"},
+						{Acct: "unknown", Username: "unknown"},
+						{ID: "3", Acct: "test2@example.com", Username: "test2"},
+						{ID: "4", Acct: "test3@example.com", Username: "test3"},
+					},
+					Visibility: "private",
+				})
+				Expect(event).To(Equal(service.ReplyEvent{
+					InReplyToID: "1",
+					Acct:        "@test",
+					Body:        io.NopCloser(strings.NewReader("診断結果")),
+					Visibility:  "private",
+				}))
+				Expect(index).To(Equal(0))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
 	})
 })
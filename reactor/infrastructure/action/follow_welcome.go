@@ -0,0 +1,37 @@
+package action
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+type followWelcome struct {
+	Message string
+}
+
+func NewFollowWelcome(message string) service.NotificationAction {
+	return &followWelcome{
+		Message: message,
+	}
+}
+
+func (fw *followWelcome) Name() string {
+	return "フォローありがとう"
+}
+
+func (fw *followWelcome) Target(notification service.Notification) bool {
+	return notification.Type == "follow"
+}
+
+func (fw *followWelcome) Event(ctx context.Context, notification service.Notification) (service.Event, error) {
+	event := service.ReplyEvent{
+		Acct:       notification.Account.Acct,
+		Body:       io.NopCloser(strings.NewReader(fw.Message)),
+		Visibility: "direct",
+	}
+
+	return event, nil
+}
@@ -0,0 +1,72 @@
+package action_test
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/action"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FollowWelcome", func() {
+	var (
+		followWelcome service.NotificationAction
+	)
+
+	BeforeEach(func() {
+		followWelcome = action.NewFollowWelcome("フォローありがとう！")
+	})
+
+	Describe("Target()", func() {
+		Context("notification is follow", func() {
+			It("returns true", func() {
+				actual := followWelcome.Target(service.Notification{
+					Type: "follow",
+				})
+				Expect(actual).To(BeTrue())
+			})
+		})
+
+		Context("notification is favourite", func() {
+			It("returns false", func() {
+				actual := followWelcome.Target(service.Notification{
+					Type: "favourite",
+				})
+				Expect(actual).To(BeFalse())
+			})
+		})
+
+		Context("notification is reblog", func() {
+			It("returns false", func() {
+				actual := followWelcome.Target(service.Notification{
+					Type: "reblog",
+				})
+				Expect(actual).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Event()", func() {
+		It("returns an event", func() {
+			event, err := followWelcome.Event(context.Background(), service.Notification{
+				ID:   "1",
+				Type: "follow",
+				Account: service.Account{
+					ID:          "2",
+					Acct:        "test",
+					DisplayName: "テスト",
+					Username:    "test",
+				},
+			})
+			Expect(event).To(ReplyEventEqual(service.ReplyEvent{
+				Acct:       "test",
+				Body:       io.NopCloser(strings.NewReader("フォローありがとう！")),
+				Visibility: "direct",
+			}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
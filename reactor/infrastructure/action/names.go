@@ -0,0 +1,27 @@
+package action
+
+import (
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+// shindanNames returns the author's name followed by the name of the first
+// account mentioned in the message other than the bot and the author, skipping ones whose IDs are unknown.
+func shindanNames(c client.Shindanmaker, message service.Message, mastodonUserID string) []string {
+	names := []string{c.Name(message.Account)}
+
+	for _, mention := range message.Mentions {
+		if mention.ID == "" || mention.ID == mastodonUserID || mention.ID == message.Account.ID {
+			continue
+		}
+
+		names = append(names, c.Name(service.Account{
+			ID:       mention.ID,
+			Acct:     mention.Acct,
+			Username: mention.Username,
+		}))
+		break
+	}
+
+	return names
+}
@@ -246,7 +246,7 @@ var _ = Describe("OfutonManagerShindanmaker", func() {
 
 		Context("fetching fails", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/503598").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/503598").Return(
 					"",
 					errors.New(`failed to fetch shindan result: Get "https://shindanmaker.com/a/503598": dial tcp [::1]:443: connect: connection refused`),
 				)
@@ -268,7 +268,7 @@ var _ = Describe("OfutonManagerShindanmaker", func() {
 
 		Context("fetching succeeds", func() {
 			BeforeEach(func() {
-				c.EXPECT().Do(context.Background(), "テスト", "https://shindanmaker.com/a/503598").Return(
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/503598").Return(
 					`むむ……このおちんちんしゅっしゅは…………よしよし、ちゃんと申請してありますね♥えらいえらい♥ もっとぴゅっぴゅってさせたげる♥ あは、おちんちんびくびくしちゃってる♥
 あっ、今日のぴゅっぴゅは…………ちょっと！おちんちんぴゅっぴゅの許可は下りてないじゃない！ぴゅっぴゅするの駄目っ！おちんちんやめなさいっ！！
 むむ……今日のおちんちんしこしこは…………こらっ！おちんちんぴゅっぴゅの許可は下りてないじゃない！こらーっ！おちんちんしこしこするなっ！ぴゅっぴゅしちゃ駄目でしょ！！
@@ -39,7 +39,7 @@ func (ps *pyuppyuManagerShindanmaker) Target(message service.Message) bool {
 
 func (ps *pyuppyuManagerShindanmaker) Event(ctx context.Context, message service.Message) (service.Event, int, error) {
 	index := PyuppyuManagerRegex.FindStringIndex(message.Content)
-	result, err := ps.Client.Do(ctx, ps.Client.Name(message.Account), "https://shindanmaker.com/a/503598")
+	result, err := ps.Client.Do(ctx, []string{ps.Client.Name(message.Account)}, "https://shindanmaker.com/a/503598")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
@@ -66,7 +66,7 @@ func (ss *sushiShindanmaker) Event(ctx context.Context, message service.Message)
 		index = make([]int, 2)
 	}
 
-	result, err := ss.Client.Do(ctx, ss.Client.Name(message.Account), "https://shindanmaker.com/a/577901")
+	result, err := ss.Client.Do(ctx, []string{ss.Client.Name(message.Account)}, "https://shindanmaker.com/a/577901")
 	if err != nil {
 		return nil, index[0], fmt.Errorf("failed to create event: %w", err)
 	}
@@ -0,0 +1,432 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+const (
+	BlueskyPostCollection    = "app.bsky.feed.post"
+	BlueskyProfileCollection = "app.bsky.actor.profile"
+	BlueskyMaxLength         = 300
+)
+
+var (
+	// BlueskyMentionRegexp matches a mention of a DID or a handle at the beginning of the text or after a space.
+	BlueskyMentionRegexp = regexp.MustCompile(`(?:^|\s)(@(did:[a-z]+:[a-zA-Z0-9._:%-]+|(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?\.)+[a-zA-Z](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?))`)
+
+	errBlueskyExpiredToken = errors.New("expired token")
+)
+
+type bluesky struct {
+	mu         sync.Mutex
+	session    *blueskySession
+	Client     *http.Client
+	ServerURL  string
+	Identifier string
+	Password   string
+	Clock      func() time.Time
+}
+
+type blueskySession struct {
+	AccessJWT string `json:"accessJwt"`
+	DID       string `json:"did"`
+}
+
+type blueskyError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+type blueskyRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+type blueskyRecord struct {
+	URI   string          `json:"uri"`
+	CID   string          `json:"cid"`
+	Value json.RawMessage `json:"value"`
+}
+
+type blueskyPost struct {
+	Reply *struct {
+		Root blueskyRef `json:"root"`
+	} `json:"reply"`
+}
+
+type blueskyProfile struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+}
+
+type blueskyIdentity struct {
+	DID string `json:"did"`
+}
+
+type blueskyBlob struct {
+	Blob json.RawMessage `json:"blob"`
+}
+
+// NewBluesky returns a poster for the account identified by the handle or DID on the PDS at server,
+// which signs in with the app password.
+func NewBluesky(client *http.Client, server, identifier, password string, clock func() time.Time) service.Poster {
+	return &bluesky{
+		Client:     client,
+		ServerURL:  server,
+		Identifier: identifier,
+		Password:   password,
+		Clock:      clock,
+	}
+}
+
+// parseBlueskyURI splits the AT URI into the repository, collection and record key.
+func parseBlueskyURI(uri string) (repo, collection, rkey string, err error) {
+	s, ok := strings.CutPrefix(uri, "at://")
+	if !ok {
+		return "", "", "", fmt.Errorf("invalid AT URI: %q", uri)
+	}
+
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("invalid AT URI: %q", uri)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func (p blueskyProfile) profile() service.Profile {
+	profile := service.Profile{
+		ID:          p.DID,
+		DisplayName: p.DisplayName,
+	}
+	if profile.DisplayName == "" {
+		profile.DisplayName = p.Handle
+	}
+	return profile
+}
+
+func (b *bluesky) do(ctx context.Context, method, nsid string, query url.Values, contentType string, body io.Reader, token string, result any) error {
+	u, err := url.Parse(b.ServerURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, "/xrpc", nsid)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := b.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", nsid, err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 399 {
+		var e blueskyError
+		_ = json.NewDecoder(res.Body).Decode(&e)
+		if e.Error == "ExpiredToken" {
+			return fmt.Errorf("failed response from %s (%v): %w", nsid, res.Status, errBlueskyExpiredToken)
+		}
+		return fmt.Errorf("failed response from %s (%v)", nsid, res.Status)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", nsid, err)
+	}
+
+	return nil
+}
+
+// login returns the current session, creating one if it has not been created or has expired.
+func (b *bluesky) login(ctx context.Context, renew bool) (blueskySession, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.session != nil && !renew {
+		return *b.session, nil
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"identifier": b.Identifier,
+		"password":   b.Password,
+	})
+	if err != nil {
+		return blueskySession{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var session blueskySession
+	err = b.do(ctx, http.MethodPost, "com.atproto.server.createSession", nil, "application/json", bytes.NewReader(body), "", &session)
+	if err != nil {
+		return blueskySession{}, err
+	}
+
+	b.session = &session
+	return session, nil
+}
+
+// call calls the XRPC method with the session, which is renewed once if the access token has expired.
+func (b *bluesky) call(ctx context.Context, method, nsid string, query url.Values, contentType string, body []byte, result any) error {
+	renew := false
+	for {
+		session, err := b.login(ctx, renew)
+		if err != nil {
+			return err
+		}
+
+		err = b.do(ctx, method, nsid, query, contentType, bytes.NewReader(body), session.AccessJWT, result)
+		if errors.Is(err, errBlueskyExpiredToken) && !renew {
+			renew = true
+			continue
+		}
+		return err
+	}
+}
+
+func (b *bluesky) query(ctx context.Context, nsid string, query url.Values, result any) error {
+	return b.call(ctx, http.MethodGet, nsid, query, "", nil, result)
+}
+
+func (b *bluesky) procedure(ctx context.Context, nsid string, params map[string]any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return b.call(ctx, http.MethodPost, nsid, nil, "application/json", body, result)
+}
+
+func (b *bluesky) getRecord(ctx context.Context, uri string) (blueskyRecord, error) {
+	repo, collection, rkey, err := parseBlueskyURI(uri)
+	if err != nil {
+		return blueskyRecord{}, err
+	}
+
+	var record blueskyRecord
+	err = b.query(ctx, "com.atproto.repo.getRecord", url.Values{
+		"repo":       {repo},
+		"collection": {collection},
+		"rkey":       {rkey},
+	}, &record)
+	if err != nil {
+		return blueskyRecord{}, err
+	}
+	return record, nil
+}
+
+// Post creates a post, replying in the same thread as the post of InReplyToID if given.
+// Visibility is ignored as every post on Bluesky is public.
+func (b *bluesky) Post(ctx context.Context, post service.Post) (string, error) {
+	session, err := b.login(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	record := map[string]any{
+		"$type":     BlueskyPostCollection,
+		"text":      post.Status,
+		"createdAt": b.Clock().UTC().Format(time.RFC3339Nano),
+	}
+
+	if post.InReplyToID != "" {
+		parent, err := b.getRecord(ctx, post.InReplyToID)
+		if err != nil {
+			return "", err
+		}
+
+		var value blueskyPost
+		err = json.Unmarshal(parent.Value, &value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode post: %w", err)
+		}
+
+		root := blueskyRef{URI: parent.URI, CID: parent.CID}
+		if value.Reply != nil {
+			root = value.Reply.Root
+		}
+		record["reply"] = map[string]any{
+			"root":   root,
+			"parent": blueskyRef{URI: parent.URI, CID: parent.CID},
+		}
+	}
+
+	if facets := b.facets(ctx, post.Status); len(facets) > 0 {
+		record["facets"] = facets
+	}
+
+	if len(post.MediaIDs) > 0 {
+		var images []map[string]any
+		for _, id := range post.MediaIDs {
+			images = append(images, map[string]any{
+				"alt":   "",
+				"image": json.RawMessage(id),
+			})
+		}
+		record["embed"] = map[string]any{
+			"$type":  "app.bsky.embed.images",
+			"images": images,
+		}
+	}
+
+	var created blueskyRef
+	err = b.procedure(ctx, "com.atproto.repo.createRecord", map[string]any{
+		"repo":       session.DID,
+		"collection": BlueskyPostCollection,
+		"record":     record,
+	}, &created)
+	if err != nil {
+		return "", err
+	}
+	return created.URI, nil
+}
+
+// facets returns the mentions in the text as facets, without which they neither link to nor notify the users.
+// Mentions of handles that cannot be resolved are left as plain text.
+func (b *bluesky) facets(ctx context.Context, text string) []map[string]any {
+	var facets []map[string]any
+	for _, m := range BlueskyMentionRegexp.FindAllStringSubmatchIndex(text, -1) {
+		did := text[m[4]:m[5]]
+		if !strings.HasPrefix(did, "did:") {
+			var identity blueskyIdentity
+			err := b.query(ctx, "com.atproto.identity.resolveHandle", url.Values{
+				"handle": {did},
+			}, &identity)
+			if err != nil {
+				slog.Warn("Failed to resolve handle", slog.String("handle", did), slog.Any("err", err))
+				continue
+			}
+			did = identity.DID
+		}
+
+		// The indices are in bytes of the text encoded in UTF-8.
+		facets = append(facets, map[string]any{
+			"index": map[string]any{
+				"byteStart": m[2],
+				"byteEnd":   m[3],
+			},
+			"features": []map[string]any{
+				{
+					"$type": "app.bsky.richtext.facet#mention",
+					"did":   did,
+				},
+			},
+		})
+	}
+	return facets
+}
+
+func (b *bluesky) Delete(ctx context.Context, id string) error {
+	repo, collection, rkey, err := parseBlueskyURI(id)
+	if err != nil {
+		return err
+	}
+
+	return b.procedure(ctx, "com.atproto.repo.deleteRecord", map[string]any{
+		"repo":       repo,
+		"collection": collection,
+		"rkey":       rkey,
+	}, nil)
+}
+
+func (b *bluesky) Profile(ctx context.Context) (service.Profile, error) {
+	session, err := b.login(ctx, false)
+	if err != nil {
+		return service.Profile{}, err
+	}
+
+	var profile blueskyProfile
+	err = b.query(ctx, "app.bsky.actor.getProfile", url.Values{
+		"actor": {session.DID},
+	}, &profile)
+	if err != nil {
+		return service.Profile{}, err
+	}
+	return profile.profile(), nil
+}
+
+// UpdateProfile replaces the display name in the profile record, keeping the other fields as they are.
+func (b *bluesky) UpdateProfile(ctx context.Context, displayName string) (service.Profile, error) {
+	session, err := b.login(ctx, false)
+	if err != nil {
+		return service.Profile{}, err
+	}
+
+	current, err := b.getRecord(ctx, "at://"+session.DID+"/"+BlueskyProfileCollection+"/self")
+	if err != nil {
+		return service.Profile{}, err
+	}
+
+	record := map[string]any{}
+	err = json.Unmarshal(current.Value, &record)
+	if err != nil {
+		return service.Profile{}, fmt.Errorf("failed to decode profile: %w", err)
+	}
+	record["$type"] = BlueskyProfileCollection
+	record["displayName"] = displayName
+
+	err = b.procedure(ctx, "com.atproto.repo.putRecord", map[string]any{
+		"repo":       session.DID,
+		"collection": BlueskyProfileCollection,
+		"rkey":       "self",
+		"record":     record,
+		"swapRecord": current.CID,
+	}, nil)
+	if err != nil {
+		return service.Profile{}, err
+	}
+
+	return service.Profile{
+		ID:          session.DID,
+		DisplayName: displayName,
+	}, nil
+}
+
+func (b *bluesky) MaxLength() int {
+	return BlueskyMaxLength
+}
+
+// UploadMedia uploads the image as a blob and returns its reference encoded in JSON,
+// which is embedded as is in the post as Bluesky has no IDs for media.
+func (b *bluesky) UploadMedia(ctx context.Context, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read media: %w", err)
+	}
+
+	var blob blueskyBlob
+	err = b.call(ctx, http.MethodPost, "com.atproto.repo.uploadBlob", nil, http.DetectContentType(data), data, &blob)
+	if err != nil {
+		return "", err
+	}
+	return string(blob.Blob), nil
+}
@@ -0,0 +1,282 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Bluesky", func() {
+	var (
+		server  *ghttp.Server
+		bluesky service.Poster
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewTLSServer()
+		bluesky = client.NewBluesky(server.HTTPTestServer.Client(), server.URL(), "did:plc:bot", "password", func() time.Time {
+			return time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		})
+
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.server.createSession"),
+				ghttp.VerifyJSON(`{"identifier":"did:plc:bot","password":"password"}`),
+				ghttp.RespondWith(http.StatusOK, `{"accessJwt":"access","refreshJwt":"refresh","did":"did:plc:bot","handle":"bot.bsky.social"}`),
+			),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Profile()", func() {
+		Context("access token has expired", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/app.bsky.actor.getProfile", "actor=did:plc:bot"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer access"),
+						ghttp.RespondWith(http.StatusBadRequest, `{"error":"ExpiredToken","message":"Token has expired"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.server.createSession"),
+						ghttp.RespondWith(http.StatusOK, `{"accessJwt":"renewed","refreshJwt":"refresh","did":"did:plc:bot","handle":"bot.bsky.social"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/app.bsky.actor.getProfile", "actor=did:plc:bot"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer renewed"),
+						ghttp.RespondWith(http.StatusOK, `{"did":"did:plc:bot","handle":"bot.bsky.social","displayName":"テスト（昨日: 1 / 今日: 2）"}`),
+					),
+				)
+			})
+
+			It("renews the session and returns the profile", func() {
+				actual, err := bluesky.Profile(context.Background())
+				Expect(actual).To(Equal(service.Profile{
+					ID:          "did:plc:bot",
+					DisplayName: "テスト（昨日: 1 / 今日: 2）",
+				}))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("fetching fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/app.bsky.actor.getProfile"),
+						ghttp.RespondWith(http.StatusUnauthorized, `{"error":"AuthenticationRequired"}`),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				_, err := bluesky.Profile(context.Background())
+				Expect(err).To(MatchError("failed response from app.bsky.actor.getProfile (401 Unauthorized)"))
+			})
+		})
+	})
+
+	Describe("UpdateProfile()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/xrpc/com.atproto.repo.getRecord", "collection=app.bsky.actor.profile&repo=did:plc:bot&rkey=self"),
+					ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:bot/app.bsky.actor.profile/self","cid":"bafyprofile","value":{"$type":"app.bsky.actor.profile","displayName":"テスト（昨日: 1 / 今日: 2）","description":"説明"}}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.putRecord"),
+					ghttp.VerifyJSON(`{
+						"repo": "did:plc:bot",
+						"collection": "app.bsky.actor.profile",
+						"rkey": "self",
+						"record": {"$type":"app.bsky.actor.profile","displayName":"テスト（昨日: 2 / 今日: 0）","description":"説明"},
+						"swapRecord": "bafyprofile"
+					}`),
+					ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:bot/app.bsky.actor.profile/self","cid":"bafyprofile2"}`),
+				),
+			)
+		})
+
+		It("updates the display name", func() {
+			actual, err := bluesky.UpdateProfile(context.Background(), "テスト（昨日: 2 / 今日: 0）")
+			Expect(actual).To(Equal(service.Profile{
+				ID:          "did:plc:bot",
+				DisplayName: "テスト（昨日: 2 / 今日: 0）",
+			}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Post()", func() {
+		Context("replying to a post in a thread", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/com.atproto.repo.getRecord", "collection=app.bsky.feed.post&repo=did:plc:test&rkey=3kparent"),
+						ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:test/app.bsky.feed.post/3kparent","cid":"bafyparent","value":{"text":"診断","reply":{"root":{"uri":"at://did:plc:root/app.bsky.feed.post/3kroot","cid":"bafyroot"},"parent":{"uri":"at://did:plc:root/app.bsky.feed.post/3kroot","cid":"bafyroot"}}}}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/com.atproto.identity.resolveHandle", "handle=test.bsky.social"),
+						ghttp.RespondWith(http.StatusOK, `{"did":"did:plc:test"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.createRecord"),
+						ghttp.VerifyHeaderKV("Authorization", "Bearer access"),
+						ghttp.VerifyJSON(`{
+							"repo": "did:plc:bot",
+							"collection": "app.bsky.feed.post",
+							"record": {
+								"$type": "app.bsky.feed.post",
+								"text": "@test.bsky.social 診断結果",
+								"createdAt": "2024-01-02T15:04:05Z",
+								"reply": {
+									"root": {"uri":"at://did:plc:root/app.bsky.feed.post/3kroot","cid":"bafyroot"},
+									"parent": {"uri":"at://did:plc:test/app.bsky.feed.post/3kparent","cid":"bafyparent"}
+								},
+								"facets": [
+									{
+										"index": {"byteStart":0,"byteEnd":17},
+										"features": [{"$type":"app.bsky.richtext.facet#mention","did":"did:plc:test"}]
+									}
+								]
+							}
+						}`),
+						ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:bot/app.bsky.feed.post/3kreply","cid":"bafyreply"}`),
+					),
+				)
+			})
+
+			It("creates a reply in the thread with the mention", func() {
+				actual, err := bluesky.Post(context.Background(), service.Post{
+					InReplyToID: "at://did:plc:test/app.bsky.feed.post/3kparent",
+					Status:      "@test.bsky.social 診断結果",
+					Visibility:  "public",
+				})
+				Expect(actual).To(Equal("at://did:plc:bot/app.bsky.feed.post/3kreply"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("mentioning handles that cannot be resolved and DIDs", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/com.atproto.identity.resolveHandle", "handle=unknown.bsky.social"),
+						ghttp.RespondWith(http.StatusBadRequest, `{"error":"InvalidRequest","message":"Unable to resolve handle"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.createRecord"),
+						ghttp.VerifyJSON(`{
+							"repo": "did:plc:bot",
+							"collection": "app.bsky.feed.post",
+							"record": {
+								"$type": "app.bsky.feed.post",
+								"text": "ちんぽ 👍 @unknown.bsky.social vs @did:plc:test",
+								"createdAt": "2024-01-02T15:04:05Z",
+								"facets": [
+									{
+										"index": {"byteStart":39,"byteEnd":52},
+										"features": [{"$type":"app.bsky.richtext.facet#mention","did":"did:plc:test"}]
+									}
+								]
+							}
+						}`),
+						ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:bot/app.bsky.feed.post/3kmention","cid":"bafymention"}`),
+					),
+				)
+			})
+
+			It("links only the mentions of the users found", func() {
+				actual, err := bluesky.Post(context.Background(), service.Post{
+					Status: "ちんぽ 👍 @unknown.bsky.social vs @did:plc:test",
+				})
+				Expect(actual).To(Equal("at://did:plc:bot/app.bsky.feed.post/3kmention"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("posting with media", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.createRecord"),
+						ghttp.VerifyJSON(`{
+							"repo": "did:plc:bot",
+							"collection": "app.bsky.feed.post",
+							"record": {
+								"$type": "app.bsky.feed.post",
+								"text": "画像",
+								"createdAt": "2024-01-02T15:04:05Z",
+								"embed": {
+									"$type": "app.bsky.embed.images",
+									"images": [{"alt":"","image":{"$type":"blob","ref":{"$link":"bafyimage"},"mimeType":"image/png","size":5}}]
+								}
+							}
+						}`),
+						ghttp.RespondWith(http.StatusOK, `{"uri":"at://did:plc:bot/app.bsky.feed.post/3kimage","cid":"bafypost"}`),
+					),
+				)
+			})
+
+			It("embeds the images", func() {
+				actual, err := bluesky.Post(context.Background(), service.Post{
+					Status:   "画像",
+					MediaIDs: []string{`{"$type":"blob","ref":{"$link":"bafyimage"},"mimeType":"image/png","size":5}`},
+				})
+				Expect(actual).To(Equal("at://did:plc:bot/app.bsky.feed.post/3kimage"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("UploadMedia()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.uploadBlob"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer access"),
+					func(w http.ResponseWriter, r *http.Request) {
+						b, err := io.ReadAll(r.Body)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(b).To(Equal([]byte("image")))
+					},
+					ghttp.RespondWith(http.StatusOK, `{"blob":{"$type":"blob","ref":{"$link":"bafyimage"},"mimeType":"text/plain","size":5}}`),
+				),
+			)
+		})
+
+		It("returns the blob reference", func() {
+			actual, err := bluesky.UploadMedia(context.Background(), strings.NewReader("image"))
+			Expect(actual).To(MatchJSON(`{"$type":"blob","ref":{"$link":"bafyimage"},"mimeType":"text/plain","size":5}`))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Delete()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/xrpc/com.atproto.repo.deleteRecord"),
+					ghttp.VerifyJSON(`{"repo":"did:plc:bot","collection":"app.bsky.feed.post","rkey":"3kreply"}`),
+					ghttp.RespondWith(http.StatusOK, `{}`),
+				),
+			)
+		})
+
+		It("deletes the post", func() {
+			err := bluesky.Delete(context.Background(), "at://did:plc:bot/app.bsky.feed.post/3kreply")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+const (
+	DefaultBreakerTimeout = 30 * time.Second
+)
+
+var (
+	CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "circuit_breaker_state",
+		Help:      "State of circuit breakers for external services (0: closed, 1: half-open, 2: open).",
+	}, []string{"name"})
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("unknown (%d)", int(s))
+	}
+}
+
+type Breaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	Name      string
+	Threshold int
+	Timeout   time.Duration
+	Clock     func() time.Time
+}
+
+// NewBreaker returns a breaker that opens after threshold consecutive failures and half-opens after timeout,
+// or DefaultBreakerTimeout if timeout is not positive.
+func NewBreaker(name string, threshold int, timeout time.Duration, clock func() time.Time) *Breaker {
+	if timeout <= 0 {
+		timeout = DefaultBreakerTimeout
+	}
+	CircuitBreakerState.WithLabelValues(name).Set(float64(BreakerClosed))
+	return &Breaker{
+		state:     BreakerClosed,
+		Name:      name,
+		Threshold: threshold,
+		Timeout:   timeout,
+		Clock:     clock,
+	}
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) transition(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	slog.Info("Circuit breaker state changed", slog.String("name", b.Name), slog.String("from", b.state.String()), slog.String("to", state.String()))
+	b.state = state
+	CircuitBreakerState.WithLabelValues(b.Name).Set(float64(state))
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.Clock().Sub(b.openedAt) < b.Timeout {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return true
+
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) report(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if err == nil {
+		b.failures = 0
+		b.transition(BreakerClosed)
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		b.openedAt = b.Clock()
+		b.transition(BreakerOpen)
+	}
+}
+
+func (b *Breaker) Do(fn func() error) error {
+	if !b.allow() {
+		return fmt.Errorf("circuit breaker for %s is open: %w", b.Name, service.ErrUnavailable)
+	}
+
+	err := fn()
+	b.report(err)
+	return err
+}
+
+type breakerShindanmaker struct {
+	Client  Shindanmaker
+	Breaker *Breaker
+}
+
+func NewBreakerShindanmaker(c Shindanmaker, breaker *Breaker) Shindanmaker {
+	return &breakerShindanmaker{
+		Client:  c,
+		Breaker: breaker,
+	}
+}
+
+func (s *breakerShindanmaker) Name(account service.Account) string {
+	return s.Client.Name(account)
+}
+
+func (s *breakerShindanmaker) Do(ctx context.Context, names []string, targetURL string) (result string, err error) {
+	err = s.Breaker.Do(func() error {
+		result, err = s.Client.Do(ctx, names, targetURL)
+		return err
+	})
+	return result, err
+}
+
+type breakerMpyw struct {
+	Client  Mpyw
+	Breaker *Breaker
+}
+
+func NewBreakerMpyw(c Mpyw, breaker *Breaker) Mpyw {
+	return &breakerMpyw{
+		Client:  c,
+		Breaker: breaker,
+	}
+}
+
+func (m *breakerMpyw) Do(ctx context.Context, targetURL string, count int) (body io.ReadCloser, err error) {
+	err = m.Breaker.Do(func() error {
+		body, err = m.Client.Do(ctx, targetURL, count)
+		return err
+	})
+	return body, err
+}
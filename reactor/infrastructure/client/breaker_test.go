@@ -0,0 +1,216 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Breaker", func() {
+	var (
+		now     time.Time
+		breaker *client.Breaker
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2006, 1, 2, 15, 4, 5, 0, time.Local)
+		breaker = client.NewBreaker("test", 2, time.Minute, func() time.Time {
+			return now
+		})
+	})
+
+	Describe("NewBreaker()", func() {
+		Context("timeout is zero", func() {
+			It("uses the default timeout", func() {
+				breaker := client.NewBreaker("test", 2, 0, func() time.Time {
+					return now
+				})
+				Expect(breaker.Timeout).To(Equal(client.DefaultBreakerTimeout))
+			})
+		})
+	})
+
+	Describe("Do()", func() {
+		Context("calls succeed", func() {
+			It("stays closed", func() {
+				Expect(breaker.Do(func() error { return nil })).To(Succeed())
+				Expect(breaker.State()).To(Equal(client.BreakerClosed))
+			})
+		})
+
+		Context("calls fail less than threshold", func() {
+			It("stays closed", func() {
+				Expect(breaker.Do(func() error { return errors.New("connection refused") })).To(MatchError("connection refused"))
+				Expect(breaker.State()).To(Equal(client.BreakerClosed))
+			})
+		})
+
+		Context("calls are canceled", func() {
+			It("stays closed", func() {
+				Expect(breaker.Do(func() error { return context.Canceled })).To(MatchError(context.Canceled))
+				Expect(breaker.Do(func() error { return context.Canceled })).To(MatchError(context.Canceled))
+				Expect(breaker.State()).To(Equal(client.BreakerClosed))
+			})
+		})
+
+		Context("calls fail as many as threshold", func() {
+			var (
+				called bool
+			)
+
+			BeforeEach(func() {
+				called = false
+				Expect(breaker.Do(func() error { return errors.New("connection refused") })).To(MatchError("connection refused"))
+				Expect(breaker.Do(func() error { return errors.New("connection refused") })).To(MatchError("connection refused"))
+			})
+
+			It("opens and rejects calls", func() {
+				err := breaker.Do(func() error {
+					called = true
+					return nil
+				})
+				Expect(err).To(MatchError(service.ErrUnavailable))
+				Expect(err).To(MatchError("circuit breaker for test is open: service unavailable"))
+				Expect(called).To(BeFalse())
+				Expect(breaker.State()).To(Equal(client.BreakerOpen))
+			})
+
+			Context("timeout has passed", func() {
+				BeforeEach(func() {
+					now = now.Add(time.Minute)
+				})
+
+				Context("probe succeeds", func() {
+					It("closes", func() {
+						Expect(breaker.Do(func() error {
+							Expect(breaker.State()).To(Equal(client.BreakerHalfOpen))
+							Expect(breaker.Do(func() error { return nil })).To(MatchError(service.ErrUnavailable))
+							return nil
+						})).To(Succeed())
+						Expect(breaker.State()).To(Equal(client.BreakerClosed))
+					})
+				})
+
+				Context("probe fails", func() {
+					It("opens again", func() {
+						Expect(breaker.Do(func() error { return errors.New("connection refused") })).To(MatchError("connection refused"))
+						Expect(breaker.State()).To(Equal(client.BreakerOpen))
+						Expect(breaker.Do(func() error { return nil })).To(MatchError(service.ErrUnavailable))
+					})
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("BreakerShindanmaker", func() {
+	var (
+		ctrl         *gomock.Controller
+		c            *client.MockShindanmaker
+		shindanmaker client.Shindanmaker
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		c = client.NewMockShindanmaker(ctrl)
+		shindanmaker = client.NewBreakerShindanmaker(c, client.NewBreaker("shindanmaker", 1, time.Minute, time.Now))
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("fetching fails", func() {
+			BeforeEach(func() {
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return(
+					"",
+					errors.New("failed to fetch shindan result"),
+				)
+			})
+
+			It("stops calling the client", func() {
+				_, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(err).To(MatchError("failed to fetch shindan result"))
+
+				_, err = shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(err).To(MatchError(service.ErrUnavailable))
+			})
+		})
+
+		Context("fetching succeeds", func() {
+			BeforeEach(func() {
+				c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果", nil)
+			})
+
+			It("returns the result", func() {
+				actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(actual).To(Equal("診断結果"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+})
+
+var _ = Describe("BreakerMpyw", func() {
+	var (
+		ctrl *gomock.Controller
+		c    *client.MockMpyw
+		mpyw client.Mpyw
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		c = client.NewMockMpyw(ctrl)
+		mpyw = client.NewBreakerMpyw(c, client.NewBreaker("mpyw", 1, time.Minute, time.Now))
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("fetching fails", func() {
+			BeforeEach(func() {
+				c.EXPECT().Do(context.Background(), "https://mpyw.hinanawi.net/api", 1).Return(
+					nil,
+					errors.New("failed to fetch challenge result"),
+				)
+			})
+
+			It("stops calling the client", func() {
+				_, err := mpyw.Do(context.Background(), "https://mpyw.hinanawi.net/api", 1)
+				Expect(err).To(MatchError("failed to fetch challenge result"))
+
+				_, err = mpyw.Do(context.Background(), "https://mpyw.hinanawi.net/api", 1)
+				Expect(err).To(MatchError(service.ErrUnavailable))
+			})
+		})
+
+		Context("fetching succeeds", func() {
+			BeforeEach(func() {
+				c.EXPECT().Do(context.Background(), "https://mpyw.hinanawi.net/api", 1).Return(
+					io.NopCloser(strings.NewReader(`{}`)),
+					nil,
+				)
+			})
+
+			It("returns the result", func() {
+				body, err := mpyw.Do(context.Background(), "https://mpyw.hinanawi.net/api", 1)
+				Expect(err).NotTo(HaveOccurred())
+
+				actual, err := io.ReadAll(body)
+				Expect(actual).To(MatchJSON(`{}`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,153 @@
+//go:generate go tool mockgen -source=cached_shindanmaker.go -destination=cached_shindanmaker_mock.go -package=client -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client
+
+package client
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ShindanmakerCacheTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "shindanmaker_cache_total",
+		Help:      "Total number of lookups in cache for ShindanMaker results.",
+	}, []string{"result"})
+)
+
+type ShindanmakerStore interface {
+	GetShindanResult(ctx context.Context, targetURL string, name string, date time.Time) (string, bool, error)
+	SaveShindanResult(ctx context.Context, targetURL string, name string, date time.Time, result string) error
+}
+
+type shindanCacheKey struct {
+	TargetURL string
+	Name      string
+	Date      string
+}
+
+type shindanCacheEntry struct {
+	Key    shindanCacheKey
+	Result string
+}
+
+type cachedShindanmaker struct {
+	mu      sync.Mutex
+	entries map[shindanCacheKey]*list.Element
+	order   *list.List
+	Client  Shindanmaker
+	Store   ShindanmakerStore
+	Size    int
+	Clock   func() time.Time
+}
+
+func NewCachedShindanmaker(
+	c Shindanmaker,
+	store ShindanmakerStore,
+	size int,
+	clock func() time.Time,
+) Shindanmaker {
+	return &cachedShindanmaker{
+		entries: make(map[shindanCacheKey]*list.Element, size),
+		order:   list.New(),
+		Client:  c,
+		Store:   store,
+		Size:    size,
+		Clock:   clock,
+	}
+}
+
+func (s *cachedShindanmaker) Name(account service.Account) string {
+	return s.Client.Name(account)
+}
+
+func (s *cachedShindanmaker) get(key shindanCacheKey) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	s.order.MoveToFront(e)
+	return e.Value.(*shindanCacheEntry).Result, true
+}
+
+func (s *cachedShindanmaker) put(key shindanCacheKey, result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*shindanCacheEntry).Result = result
+		s.order.MoveToFront(e)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&shindanCacheEntry{
+		Key:    key,
+		Result: result,
+	})
+
+	for s.order.Len() > s.Size {
+		e := s.order.Back()
+		s.order.Remove(e)
+		delete(s.entries, e.Value.(*shindanCacheEntry).Key)
+	}
+}
+
+func (s *cachedShindanmaker) Do(ctx context.Context, names []string, targetURL string) (string, error) {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, normalizeName(name))
+	}
+
+	date := s.Clock()
+	key := shindanCacheKey{
+		TargetURL: targetURL,
+		Name:      strings.Join(normalized, "\n"),
+		Date:      date.Format(time.DateOnly),
+	}
+
+	if result, ok := s.get(key); ok {
+		ShindanmakerCacheTotal.WithLabelValues("hit").Inc()
+		return result, nil
+	}
+
+	if s.Store != nil {
+		result, ok, err := s.Store.GetShindanResult(ctx, key.TargetURL, key.Name, date)
+		if err != nil {
+			slog.Warn("Failed to get ShindanMaker result from store", slog.Any("err", err))
+		} else if ok {
+			ShindanmakerCacheTotal.WithLabelValues("hit").Inc()
+			s.put(key, result)
+			return result, nil
+		}
+	}
+
+	ShindanmakerCacheTotal.WithLabelValues("miss").Inc()
+
+	result, err := s.Client.Do(ctx, names, targetURL)
+	if err != nil {
+		return "", err
+	}
+
+	s.put(key, result)
+
+	if s.Store != nil {
+		err := s.Store.SaveShindanResult(ctx, key.TargetURL, key.Name, date, result)
+		if err != nil {
+			slog.Warn("Failed to save ShindanMaker result to store", slog.Any("err", err))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cached_shindanmaker.go
+//
+// Generated by this command:
+//
+//	mockgen -source=cached_shindanmaker.go -destination=cached_shindanmaker_mock.go -package=client -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client
+//
+
+// Package client is a generated GoMock package.
+package client
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockShindanmakerStore is a mock of ShindanmakerStore interface.
+type MockShindanmakerStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockShindanmakerStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockShindanmakerStoreMockRecorder is the mock recorder for MockShindanmakerStore.
+type MockShindanmakerStoreMockRecorder struct {
+	mock *MockShindanmakerStore
+}
+
+// NewMockShindanmakerStore creates a new mock instance.
+func NewMockShindanmakerStore(ctrl *gomock.Controller) *MockShindanmakerStore {
+	mock := &MockShindanmakerStore{ctrl: ctrl}
+	mock.recorder = &MockShindanmakerStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShindanmakerStore) EXPECT() *MockShindanmakerStoreMockRecorder {
+	return m.recorder
+}
+
+// GetShindanResult mocks base method.
+func (m *MockShindanmakerStore) GetShindanResult(ctx context.Context, targetURL, name string, date time.Time) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShindanResult", ctx, targetURL, name, date)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetShindanResult indicates an expected call of GetShindanResult.
+func (mr *MockShindanmakerStoreMockRecorder) GetShindanResult(ctx, targetURL, name, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShindanResult", reflect.TypeOf((*MockShindanmakerStore)(nil).GetShindanResult), ctx, targetURL, name, date)
+}
+
+// SaveShindanResult mocks base method.
+func (m *MockShindanmakerStore) SaveShindanResult(ctx context.Context, targetURL, name string, date time.Time, result string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveShindanResult", ctx, targetURL, name, date, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveShindanResult indicates an expected call of SaveShindanResult.
+func (mr *MockShindanmakerStoreMockRecorder) SaveShindanResult(ctx, targetURL, name, date, result any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveShindanResult", reflect.TypeOf((*MockShindanmakerStore)(nil).SaveShindanResult), ctx, targetURL, name, date, result)
+}
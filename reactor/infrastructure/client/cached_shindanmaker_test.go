@@ -0,0 +1,202 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("CachedShindanmaker", func() {
+	var (
+		ctrl  *gomock.Controller
+		c     *client.MockShindanmaker
+		store *client.MockShindanmakerStore
+		now   time.Time
+		clock func() time.Time
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		c = client.NewMockShindanmaker(ctrl)
+		store = client.NewMockShindanmakerStore(ctrl)
+		now = time.Date(2006, 1, 2, 15, 4, 5, 0, time.Local)
+		clock = func() time.Time {
+			return now
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Name()", func() {
+		BeforeEach(func() {
+			c.EXPECT().Name(service.Account{
+				DisplayName: "テスト",
+			}).Return("テスト")
+		})
+
+		It("delegates to the client", func() {
+			shindanmaker := client.NewCachedShindanmaker(c, nil, 1, clock)
+			actual := shindanmaker.Name(service.Account{
+				DisplayName: "テスト",
+			})
+			Expect(actual).To(Equal("テスト"))
+		})
+	})
+
+	Describe("Do()", func() {
+		Context("without store", func() {
+			var (
+				shindanmaker client.Shindanmaker
+			)
+
+			BeforeEach(func() {
+				shindanmaker = client.NewCachedShindanmaker(c, nil, 1, clock)
+			})
+
+			Context("fetching fails", func() {
+				BeforeEach(func() {
+					c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return(
+						"",
+						errors.New("failed to fetch shindan result"),
+					).Times(2)
+				})
+
+				It("does not cache the error", func() {
+					_, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(err).To(MatchError("failed to fetch shindan result"))
+
+					_, err = shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(err).To(MatchError("failed to fetch shindan result"))
+				})
+			})
+
+			Context("fetching succeeds", func() {
+				Context("on the same day", func() {
+					BeforeEach(func() {
+						c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果", nil)
+					})
+
+					It("returns the cached result", func() {
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果"))
+						Expect(err).NotTo(HaveOccurred())
+
+						actual, err = shindanmaker.Do(context.Background(), []string{"テスト（がんばらない）"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果"))
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+
+				Context("on the next day", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果1", nil),
+							c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果2", nil),
+						)
+					})
+
+					It("returns a new result", func() {
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果1"))
+						Expect(err).NotTo(HaveOccurred())
+
+						now = now.AddDate(0, 0, 1)
+						actual, err = shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果2"))
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+
+				Context("the cache is full", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().Do(context.Background(), []string{"テスト1"}, "https://shindanmaker.com/a/855159").Return("診断結果1", nil),
+							c.EXPECT().Do(context.Background(), []string{"テスト2"}, "https://shindanmaker.com/a/855159").Return("診断結果2", nil),
+							c.EXPECT().Do(context.Background(), []string{"テスト1"}, "https://shindanmaker.com/a/855159").Return("診断結果1", nil),
+						)
+					})
+
+					It("evicts the least recently used result", func() {
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト1"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果1"))
+						Expect(err).NotTo(HaveOccurred())
+
+						actual, err = shindanmaker.Do(context.Background(), []string{"テスト2"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果2"))
+						Expect(err).NotTo(HaveOccurred())
+
+						actual, err = shindanmaker.Do(context.Background(), []string{"テスト1"}, "https://shindanmaker.com/a/855159")
+						Expect(actual).To(Equal("診断結果1"))
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+			})
+		})
+
+		Context("with store", func() {
+			var (
+				shindanmaker client.Shindanmaker
+			)
+
+			BeforeEach(func() {
+				shindanmaker = client.NewCachedShindanmaker(c, store, 1, clock)
+			})
+
+			Context("store has the result", func() {
+				BeforeEach(func() {
+					store.EXPECT().GetShindanResult(context.Background(), "https://shindanmaker.com/a/855159", "テスト", now).Return("診断結果", true, nil)
+				})
+
+				It("returns the stored result", func() {
+					actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(actual).To(Equal("診断結果"))
+					Expect(err).NotTo(HaveOccurred())
+
+					actual, err = shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(actual).To(Equal("診断結果"))
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("store does not have the result", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						store.EXPECT().GetShindanResult(context.Background(), "https://shindanmaker.com/a/855159", "テスト", now).Return("", false, nil),
+						c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果", nil),
+						store.EXPECT().SaveShindanResult(context.Background(), "https://shindanmaker.com/a/855159", "テスト", now, "診断結果").Return(nil),
+					)
+				})
+
+				It("saves the result", func() {
+					actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(actual).To(Equal("診断結果"))
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("store fails", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						store.EXPECT().GetShindanResult(context.Background(), "https://shindanmaker.com/a/855159", "テスト", now).Return("", false, errors.New("connection refused")),
+						c.EXPECT().Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159").Return("診断結果", nil),
+						store.EXPECT().SaveShindanResult(context.Background(), "https://shindanmaker.com/a/855159", "テスト", now, "診断結果").Return(errors.New("connection refused")),
+					)
+				})
+
+				It("falls back to the client", func() {
+					actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+					Expect(actual).To(Equal("診断結果"))
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+	})
+})
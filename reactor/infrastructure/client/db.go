@@ -1,3 +1,5 @@
+//go:generate go tool mockgen -source=db.go -destination=db_mock.go -package=client -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client
+
 package client
 
 import (
@@ -6,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -17,6 +20,22 @@ type Count struct {
 	Count  int       `db:"count"`
 }
 
+type ShindanResult struct {
+	URL    string    `db:"url"`
+	Name   string    `db:"name"`
+	Date   time.Time `db:"date"`
+	Result string    `db:"result"`
+}
+
+type History struct {
+	ID       int64     `db:"id"`
+	StatusID string    `db:"status_id"`
+	Action   string    `db:"action"`
+	Event    string    `db:"event"`
+	ReplyID  string    `db:"reply_id"`
+	Date     time.Time `db:"date"`
+}
+
 type db struct {
 	Connection *sqlx.DB
 }
@@ -24,6 +43,9 @@ type db struct {
 type DB interface {
 	Query(ctx context.Context, q string) ([]string, int64, error)
 	UpdateCount(ctx context.Context, userID int64, date time.Time, count int) error
+	DecrementCount(ctx context.Context, userID int64, date time.Time) error
+	ShindanmakerStore
+	service.History
 	Close() error
 }
 
@@ -123,3 +145,110 @@ func (d *db) UpdateCount(ctx context.Context, userID int64, date time.Time, coun
 
 	return nil
 }
+
+func (d *db) DecrementCount(ctx context.Context, userID int64, date time.Time) error {
+	_, err := d.Connection.NamedExecContext(
+		ctx,
+		`UPDATE "counts" SET "count" = GREATEST("count" - 1, 0) WHERE "user_id" = :user_id AND "date" = :date`,
+		Count{
+			UserID: userID,
+			Date:   date,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to decrement count on DB: %w", err)
+	}
+
+	return nil
+}
+
+func (d *db) GetShindanResult(ctx context.Context, targetURL string, name string, date time.Time) (string, bool, error) {
+	var results []string
+	err := d.Connection.SelectContext(
+		ctx,
+		&results,
+		`SELECT "result" FROM "shindan_results" WHERE "url" = $1 AND "name" = $2 AND "date" = $3`,
+		targetURL,
+		name,
+		date,
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get shindan result from DB: %w", err)
+	}
+	if len(results) == 0 {
+		return "", false, nil
+	}
+	return results[0], true, nil
+}
+
+func (d *db) SaveShindanResult(ctx context.Context, targetURL string, name string, date time.Time, result string) error {
+	_, err := d.Connection.NamedExecContext(
+		ctx,
+		`INSERT INTO "shindan_results" ("url", "name", "date", "result") VALUES (:url, :name, :date, :result) ON CONFLICT ("url", "name", "date") DO UPDATE SET "result" = :result`,
+		ShindanResult{
+			URL:    targetURL,
+			Name:   name,
+			Date:   date,
+			Result: result,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save shindan result on DB: %w", err)
+	}
+	return nil
+}
+
+func (d *db) FindHistory(ctx context.Context, statusID string) ([]service.HistoryRecord, error) {
+	var histories []History
+	err := d.Connection.SelectContext(
+		ctx,
+		&histories,
+		`SELECT "id", "status_id", "action", "event", "reply_id", "date" FROM "histories" WHERE "status_id" = $1`,
+		statusID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find history from DB: %w", err)
+	}
+
+	records := make([]service.HistoryRecord, 0, len(histories))
+	for _, h := range histories {
+		records = append(records, service.HistoryRecord(h))
+	}
+	return records, nil
+}
+
+func (d *db) SaveHistory(ctx context.Context, record service.HistoryRecord) error {
+	_, err := d.Connection.NamedExecContext(
+		ctx,
+		`INSERT INTO "histories" ("status_id", "action", "event", "reply_id", "date") VALUES (:status_id, :action, :event, :reply_id, :date)`,
+		History(record),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save history on DB: %w", err)
+	}
+	return nil
+}
+
+func (d *db) DeleteHistory(ctx context.Context, id int64) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`DELETE FROM "histories" WHERE "id" = $1`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete history from DB: %w", err)
+	}
+	return nil
+}
+
+func (d *db) PruneHistory(ctx context.Context, before time.Time) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`DELETE FROM "histories" WHERE "created_at" < $1`,
+		before,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune history from DB: %w", err)
+	}
+	return nil
+}
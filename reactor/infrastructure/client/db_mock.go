@@ -0,0 +1,188 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: db.go
+//
+// Generated by this command:
+//
+//	mockgen -source=db.go -destination=db_mock.go -package=client -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client
+//
+
+// Package client is a generated GoMock package.
+package client
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	service "github.com/chitoku-k/ejaculation-counter/reactor/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDB is a mock of DB interface.
+type MockDB struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBMockRecorder
+	isgomock struct{}
+}
+
+// MockDBMockRecorder is the mock recorder for MockDB.
+type MockDBMockRecorder struct {
+	mock *MockDB
+}
+
+// NewMockDB creates a new mock instance.
+func NewMockDB(ctrl *gomock.Controller) *MockDB {
+	mock := &MockDB{ctrl: ctrl}
+	mock.recorder = &MockDBMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDB) EXPECT() *MockDBMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockDB) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockDBMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDB)(nil).Close))
+}
+
+// DecrementCount mocks base method.
+func (m *MockDB) DecrementCount(ctx context.Context, userID int64, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrementCount", ctx, userID, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrementCount indicates an expected call of DecrementCount.
+func (mr *MockDBMockRecorder) DecrementCount(ctx, userID, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrementCount", reflect.TypeOf((*MockDB)(nil).DecrementCount), ctx, userID, date)
+}
+
+// DeleteHistory mocks base method.
+func (m *MockDB) DeleteHistory(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHistory", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHistory indicates an expected call of DeleteHistory.
+func (mr *MockDBMockRecorder) DeleteHistory(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHistory", reflect.TypeOf((*MockDB)(nil).DeleteHistory), ctx, id)
+}
+
+// FindHistory mocks base method.
+func (m *MockDB) FindHistory(ctx context.Context, statusID string) ([]service.HistoryRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindHistory", ctx, statusID)
+	ret0, _ := ret[0].([]service.HistoryRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindHistory indicates an expected call of FindHistory.
+func (mr *MockDBMockRecorder) FindHistory(ctx, statusID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindHistory", reflect.TypeOf((*MockDB)(nil).FindHistory), ctx, statusID)
+}
+
+// GetShindanResult mocks base method.
+func (m *MockDB) GetShindanResult(ctx context.Context, targetURL, name string, date time.Time) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShindanResult", ctx, targetURL, name, date)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetShindanResult indicates an expected call of GetShindanResult.
+func (mr *MockDBMockRecorder) GetShindanResult(ctx, targetURL, name, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShindanResult", reflect.TypeOf((*MockDB)(nil).GetShindanResult), ctx, targetURL, name, date)
+}
+
+// PruneHistory mocks base method.
+func (m *MockDB) PruneHistory(ctx context.Context, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneHistory", ctx, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PruneHistory indicates an expected call of PruneHistory.
+func (mr *MockDBMockRecorder) PruneHistory(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneHistory", reflect.TypeOf((*MockDB)(nil).PruneHistory), ctx, before)
+}
+
+// Query mocks base method.
+func (m *MockDB) Query(ctx context.Context, q string) ([]string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, q)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockDBMockRecorder) Query(ctx, q any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockDB)(nil).Query), ctx, q)
+}
+
+// SaveHistory mocks base method.
+func (m *MockDB) SaveHistory(ctx context.Context, record service.HistoryRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHistory", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHistory indicates an expected call of SaveHistory.
+func (mr *MockDBMockRecorder) SaveHistory(ctx, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHistory", reflect.TypeOf((*MockDB)(nil).SaveHistory), ctx, record)
+}
+
+// SaveShindanResult mocks base method.
+func (m *MockDB) SaveShindanResult(ctx context.Context, targetURL, name string, date time.Time, result string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveShindanResult", ctx, targetURL, name, date, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveShindanResult indicates an expected call of SaveShindanResult.
+func (mr *MockDBMockRecorder) SaveShindanResult(ctx, targetURL, name, date, result any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveShindanResult", reflect.TypeOf((*MockDB)(nil).SaveShindanResult), ctx, targetURL, name, date, result)
+}
+
+// UpdateCount mocks base method.
+func (m *MockDB) UpdateCount(ctx context.Context, userID int64, date time.Time, count int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCount", ctx, userID, date, count)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCount indicates an expected call of UpdateCount.
+func (mr *MockDBMockRecorder) UpdateCount(ctx, userID, date, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCount", reflect.TypeOf((*MockDB)(nil).UpdateCount), ctx, userID, date, count)
+}
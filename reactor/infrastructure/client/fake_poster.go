@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+// FakePoster is an in-memory service.Poster that records what was posted.
+type FakePoster struct {
+	mu      sync.Mutex
+	nextID  int
+	posts   map[string]service.Post
+	media   map[string][]byte
+	profile service.Profile
+
+	// Err is returned from every method when set.
+	Err error
+
+	// Length is the maximum length of a post, or MastodonMaxLength if zero.
+	Length int
+}
+
+func NewFakePoster(profile service.Profile) *FakePoster {
+	return &FakePoster{
+		posts:   map[string]service.Post{},
+		media:   map[string][]byte{},
+		profile: profile,
+	}
+}
+
+func (f *FakePoster) id() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
+
+// Posts returns the posts that have not been deleted, ordered by ID.
+func (f *FakePoster) Posts() []service.Post {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]int, 0, len(f.posts))
+	for id := range f.posts {
+		n, _ := strconv.Atoi(id)
+		ids = append(ids, n)
+	}
+	slices.Sort(ids)
+
+	posts := make([]service.Post, 0, len(ids))
+	for _, id := range ids {
+		posts = append(posts, f.posts[strconv.Itoa(id)])
+	}
+	return posts
+}
+
+// Media returns the content of the uploaded media.
+func (f *FakePoster) Media(id string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b, ok := f.media[id]
+	return b, ok
+}
+
+func (f *FakePoster) Post(ctx context.Context, post service.Post) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	id := f.id()
+	f.posts[id] = post
+	return id, nil
+}
+
+func (f *FakePoster) Delete(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+
+	delete(f.posts, id)
+	return nil
+}
+
+func (f *FakePoster) Profile(ctx context.Context) (service.Profile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return service.Profile{}, f.Err
+	}
+	return f.profile, nil
+}
+
+func (f *FakePoster) UpdateProfile(ctx context.Context, displayName string) (service.Profile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return service.Profile{}, f.Err
+	}
+
+	f.profile.DisplayName = displayName
+	return f.profile, nil
+}
+
+func (f *FakePoster) MaxLength() int {
+	if f.Length == 0 {
+		return MastodonMaxLength
+	}
+	return f.Length
+}
+
+func (f *FakePoster) UploadMedia(ctx context.Context, r io.Reader) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	id := f.id()
+	f.media[id] = b
+	return id, nil
+}
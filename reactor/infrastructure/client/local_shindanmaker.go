@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+const (
+	LocalShindanUserPlaceholder = "USER"
+)
+
+var (
+	LocalShindanPlaceholderRegex = regexp.MustCompile(`\[([^\[\]]+)\]`)
+)
+
+type LocalShindanDefinition struct {
+	ID      string              `json:"id"`
+	Title   string              `json:"title"`
+	Results []string            `json:"results"`
+	Lists   map[string][]string `json:"lists"`
+}
+
+type localShindanmaker struct {
+	Definitions map[string]LocalShindanDefinition
+	Clock       func() time.Time
+}
+
+func NewLocalShindanmaker(dir string, clock func() time.Time) (Shindanmaker, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shindan definitions: %w", err)
+	}
+
+	definitions := make(map[string]LocalShindanDefinition, len(files))
+	for _, file := range files {
+		definition, err := loadLocalShindanDefinition(file)
+		if err != nil {
+			return nil, err
+		}
+		definitions[definition.ID] = definition
+	}
+
+	return &localShindanmaker{
+		Definitions: definitions,
+		Clock:       clock,
+	}, nil
+}
+
+func loadLocalShindanDefinition(file string) (LocalShindanDefinition, error) {
+	var definition LocalShindanDefinition
+
+	f, err := os.Open(file)
+	if err != nil {
+		return definition, fmt.Errorf("failed to open shindan definition: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	err = json.NewDecoder(f).Decode(&definition)
+	if err != nil {
+		return definition, fmt.Errorf("failed to decode shindan definition (%s): %w", filepath.Base(file), err)
+	}
+
+	if definition.ID == "" {
+		definition.ID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if len(definition.Results) == 0 {
+		return definition, fmt.Errorf("failed to load shindan definition (%s): no results", filepath.Base(file))
+	}
+
+	return definition, nil
+}
+
+func (s *localShindanmaker) Name(account service.Account) string {
+	return displayName(account)
+}
+
+func (s *localShindanmaker) seed(id string, names []string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(id))
+	h.Write([]byte{0})
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(s.Clock().Format(time.DateOnly)))
+
+	sum := h.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:])
+}
+
+func (s *localShindanmaker) Do(ctx context.Context, names []string, targetURL string) (string, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse given targetURL: %w", err)
+	}
+
+	id := path.Base(u.Path)
+	definition, ok := s.Definitions[id]
+	if !ok {
+		return "", fmt.Errorf("failed to find shindan definition: %s", id)
+	}
+
+	if len(names) == 0 {
+		return "", fmt.Errorf("failed to run shindan: no names given")
+	}
+
+	normalized := make([]string, 0, len(names))
+	inputs := make(map[string]string, len(names)+1)
+	for i, name := range names {
+		name = trimName(name)
+		normalized = append(normalized, name)
+
+		if i == 0 {
+			inputs[LocalShindanUserPlaceholder] = name
+		}
+		inputs[fmt.Sprintf("%s%d", LocalShindanUserPlaceholder, i+1)] = name
+	}
+
+	r := rand.New(rand.NewPCG(s.seed(id, normalized)))
+
+	result := definition.Results[r.IntN(len(definition.Results))]
+	result = LocalShindanPlaceholderRegex.ReplaceAllStringFunc(result, func(placeholder string) string {
+		key := placeholder[1 : len(placeholder)-1]
+		if input, ok := inputs[key]; ok {
+			return input
+		}
+
+		list, ok := definition.Lists[key]
+		if !ok || len(list) == 0 {
+			return placeholder
+		}
+		return list[r.IntN(len(list))]
+	})
+
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package client_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocalShindanmaker", func() {
+	var (
+		dir   string
+		now   time.Time
+		clock func() time.Time
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		now = time.Date(2006, 1, 2, 15, 4, 5, 0, time.Local)
+		clock = func() time.Time {
+			return now
+		}
+	})
+
+	Describe("NewLocalShindanmaker()", func() {
+		Context("definition is invalid", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(filepath.Join(dir, "855159.json"), []byte(`{`), 0o644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				_, err := client.NewLocalShindanmaker(dir, clock)
+				Expect(err).To(MatchError("failed to decode shindan definition (855159.json): unexpected EOF"))
+			})
+		})
+
+		Context("definition has no results", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(filepath.Join(dir, "855159.json"), []byte(`{"title": "ちんぽ揃えゲーム"}`), 0o644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				_, err := client.NewLocalShindanmaker(dir, clock)
+				Expect(err).To(MatchError("failed to load shindan definition (855159.json): no results"))
+			})
+		})
+	})
+
+	Describe("Name()", func() {
+		var (
+			shindanmaker client.Shindanmaker
+		)
+
+		BeforeEach(func() {
+			var err error
+			shindanmaker, err = client.NewLocalShindanmaker(dir, clock)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("DisplayName is not empty", func() {
+			It("returns DisplayName", func() {
+				actual := shindanmaker.Name(service.Account{
+					DisplayName: "テスト",
+					Username:    "test",
+				})
+				Expect(actual).To(Equal("テスト"))
+			})
+		})
+
+		Context("DisplayName is empty", func() {
+			It("returns Username", func() {
+				actual := shindanmaker.Name(service.Account{
+					DisplayName: "",
+					Username:    "test",
+				})
+				Expect(actual).To(Equal("test"))
+			})
+		})
+	})
+
+	Describe("Do()", func() {
+		var (
+			shindanmaker client.Shindanmaker
+		)
+
+		BeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(dir, "855159.json"), []byte(`
+				{
+					"title": "ちんぽ揃えゲーム",
+					"results": [
+						"[USER]のちんぽは[SIZE]cm、[UNKNOWN][USER2]"
+					],
+					"lists": {
+						"SIZE": ["1", "2", "3", "4", "5", "6", "7", "8", "9", "10"]
+					}
+				}
+			`), 0o644)).To(Succeed())
+
+			var err error
+			shindanmaker, err = client.NewLocalShindanmaker(dir, clock)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("targetURL is incorrect", func() {
+			It("returns an error", func() {
+				_, err := shindanmaker.Do(context.Background(), []string{"テスト"}, ":/")
+				Expect(err).To(MatchError(`failed to parse given targetURL: parse ":/": missing protocol scheme`))
+			})
+		})
+
+		Context("definition does not exist", func() {
+			It("returns an error", func() {
+				_, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/584238")
+				Expect(err).To(MatchError("failed to find shindan definition: 584238"))
+			})
+		})
+
+		Context("definition exists", func() {
+			It("fills placeholders", func() {
+				actual, err := shindanmaker.Do(context.Background(), []string{"テスト@がんばらない"}, "https://shindanmaker.com/a/855159")
+				Expect(actual).To(MatchRegexp(`^テストのちんぽは\d+cm、\[UNKNOWN\]\[USER2\]$`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fills placeholders with multiple inputs", func() {
+				actual, err := shindanmaker.Do(context.Background(), []string{"テスト", "test2"}, "https://shindanmaker.com/a/855159")
+				Expect(actual).To(MatchRegexp(`^テストのちんぽは\d+cm、\[UNKNOWN\]test2$`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fills placeholders without escaping", func() {
+				actual, err := shindanmaker.Do(context.Background(), []string{"$1テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(actual).To(MatchRegexp(`^\$1テストのちんぽは\d+cm、\[UNKNOWN\]\[USER2\]$`))
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("no names are given", func() {
+				It("returns an error", func() {
+					_, err := shindanmaker.Do(context.Background(), nil, "https://shindanmaker.com/a/855159")
+					Expect(err).To(MatchError("failed to run shindan: no names given"))
+				})
+			})
+
+			It("returns the same result within a day", func() {
+				expected, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(err).NotTo(HaveOccurred())
+
+				now = now.Add(8 * time.Hour)
+				actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, "https://shindanmaker.com/a/855159")
+				Expect(actual).To(Equal(expected))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+})
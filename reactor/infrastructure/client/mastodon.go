@@ -1,12 +1,83 @@
 package client
 
 import (
+	"context"
+	"io"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
 	"github.com/mattn/go-mastodon"
 )
 
-func NewMastodon(server, accessToken string) *mastodon.Client {
-	return mastodon.NewClient(&mastodon.Config{
-		Server:      server,
-		AccessToken: accessToken,
+const (
+	MastodonMaxLength = 500
+)
+
+type mastodonPoster struct {
+	Client *mastodon.Client
+}
+
+func NewMastodon(server, accessToken string) service.Poster {
+	return &mastodonPoster{
+		Client: mastodon.NewClient(&mastodon.Config{
+			Server:      server,
+			AccessToken: accessToken,
+		}),
+	}
+}
+
+func (m *mastodonPoster) Post(ctx context.Context, post service.Post) (string, error) {
+	toot := &mastodon.Toot{
+		InReplyToID: mastodon.ID(post.InReplyToID),
+		Status:      post.Status,
+		Visibility:  post.Visibility,
+	}
+	for _, id := range post.MediaIDs {
+		toot.MediaIDs = append(toot.MediaIDs, mastodon.ID(id))
+	}
+
+	status, err := m.Client.PostStatus(ctx, toot)
+	if err != nil {
+		return "", err
+	}
+	return string(status.ID), nil
+}
+
+func (m *mastodonPoster) Delete(ctx context.Context, id string) error {
+	return m.Client.DeleteStatus(ctx, mastodon.ID(id))
+}
+
+func (m *mastodonPoster) Profile(ctx context.Context) (service.Profile, error) {
+	account, err := m.Client.GetAccountCurrentUser(ctx)
+	if err != nil {
+		return service.Profile{}, err
+	}
+	return service.Profile{
+		ID:          string(account.ID),
+		DisplayName: account.DisplayName,
+	}, nil
+}
+
+func (m *mastodonPoster) UpdateProfile(ctx context.Context, displayName string) (service.Profile, error) {
+	account, err := m.Client.AccountUpdate(ctx, &mastodon.Profile{
+		DisplayName: &displayName,
 	})
+	if err != nil {
+		return service.Profile{}, err
+	}
+	return service.Profile{
+		ID:          string(account.ID),
+		DisplayName: account.DisplayName,
+	}, nil
+}
+
+func (m *mastodonPoster) MaxLength() int {
+	return MastodonMaxLength
+}
+
+func (m *mastodonPoster) UploadMedia(ctx context.Context, r io.Reader) (string, error) {
+	attachment, err := m.Client.UploadMediaFromReader(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	return string(attachment.ID), nil
 }
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+const (
+	MisskeyMaxLength = 3000
+)
+
+type misskey struct {
+	Client      *http.Client
+	ServerURL   string
+	AccessToken string
+}
+
+type misskeyUser struct {
+	ID       string  `json:"id"`
+	Name     *string `json:"name"`
+	Username string  `json:"username"`
+}
+
+type misskeyNote struct {
+	ID string `json:"id"`
+}
+
+type misskeyCreatedNote struct {
+	CreatedNote misskeyNote `json:"createdNote"`
+}
+
+type misskeyFile struct {
+	ID string `json:"id"`
+}
+
+func NewMisskey(client *http.Client, server, accessToken string) service.Poster {
+	return &misskey{
+		Client:      client,
+		ServerURL:   server,
+		AccessToken: accessToken,
+	}
+}
+
+// MisskeyVisibility converts the visibility of Mastodon into that of Misskey.
+func MisskeyVisibility(visibility string) string {
+	switch visibility {
+	case "unlisted":
+		return "home"
+	case "private":
+		return "followers"
+	case "direct":
+		return "specified"
+	default:
+		return "public"
+	}
+}
+
+func (u misskeyUser) profile() service.Profile {
+	profile := service.Profile{
+		ID:          u.ID,
+		DisplayName: u.Username,
+	}
+	if u.Name != nil {
+		profile.DisplayName = *u.Name
+	}
+	return profile
+}
+
+func (m *misskey) do(ctx context.Context, endpoint, contentType string, body io.Reader, result any) error {
+	u, err := url.Parse(m.ServerURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, "/api", endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", endpoint, err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 399 {
+		return fmt.Errorf("failed response from %s (%v)", endpoint, res.Status)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+
+	return nil
+}
+
+func (m *misskey) call(ctx context.Context, endpoint string, params map[string]any, result any) error {
+	params["i"] = m.AccessToken
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return m.do(ctx, endpoint, "application/json", bytes.NewReader(body), result)
+}
+
+func (m *misskey) Post(ctx context.Context, post service.Post) (string, error) {
+	params := map[string]any{
+		"text":       post.Status,
+		"visibility": MisskeyVisibility(post.Visibility),
+	}
+	if post.InReplyToID != "" {
+		params["replyId"] = post.InReplyToID
+	}
+	if len(post.MediaIDs) > 0 {
+		params["fileIds"] = post.MediaIDs
+	}
+
+	var created misskeyCreatedNote
+	err := m.call(ctx, "notes/create", params, &created)
+	if err != nil {
+		return "", err
+	}
+	return created.CreatedNote.ID, nil
+}
+
+func (m *misskey) Delete(ctx context.Context, id string) error {
+	return m.call(ctx, "notes/delete", map[string]any{
+		"noteId": id,
+	}, nil)
+}
+
+func (m *misskey) Profile(ctx context.Context) (service.Profile, error) {
+	var user misskeyUser
+	err := m.call(ctx, "i", map[string]any{}, &user)
+	if err != nil {
+		return service.Profile{}, err
+	}
+	return user.profile(), nil
+}
+
+func (m *misskey) UpdateProfile(ctx context.Context, displayName string) (service.Profile, error) {
+	var user misskeyUser
+	err := m.call(ctx, "i/update", map[string]any{
+		"name": displayName,
+	}, &user)
+	if err != nil {
+		return service.Profile{}, err
+	}
+	return user.profile(), nil
+}
+
+func (m *misskey) MaxLength() int {
+	return MisskeyMaxLength
+}
+
+func (m *misskey) UploadMedia(ctx context.Context, r io.Reader) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	err := w.WriteField("i", m.AccessToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	part, err := w.CreateFormFile("file", "file")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	_, err = io.Copy(part, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	var file misskeyFile
+	err = m.do(ctx, "drive/files/create", w.FormDataContentType(), &body, &file)
+	if err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
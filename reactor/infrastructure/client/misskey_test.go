@@ -0,0 +1,153 @@
+package client_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Misskey", func() {
+	var (
+		server  *ghttp.Server
+		misskey service.Poster
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewTLSServer()
+		misskey = client.NewMisskey(server.HTTPTestServer.Client(), server.URL(), "token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Profile()", func() {
+		Context("fetching fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/api/i"),
+						ghttp.RespondWith(http.StatusUnauthorized, `{"error":{}}`),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				_, err := misskey.Profile(context.Background())
+				Expect(err).To(MatchError("failed response from i (401 Unauthorized)"))
+			})
+		})
+
+		Context("fetching succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/api/i"),
+						ghttp.VerifyJSON(`{"i":"token"}`),
+						ghttp.RespondWith(http.StatusOK, `{"id":"9abc","name":"テスト（昨日: 1 / 今日: 2）","username":"test"}`),
+					),
+				)
+			})
+
+			It("returns the profile", func() {
+				actual, err := misskey.Profile(context.Background())
+				Expect(actual).To(Equal(service.Profile{
+					ID:          "9abc",
+					DisplayName: "テスト（昨日: 1 / 今日: 2）",
+				}))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("UpdateProfile()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/api/i/update"),
+					ghttp.VerifyJSON(`{"i":"token","name":"テスト（昨日: 2 / 今日: 0）"}`),
+					ghttp.RespondWith(http.StatusOK, `{"id":"9abc","name":"テスト（昨日: 2 / 今日: 0）","username":"test"}`),
+				),
+			)
+		})
+
+		It("updates the name", func() {
+			actual, err := misskey.UpdateProfile(context.Background(), "テスト（昨日: 2 / 今日: 0）")
+			Expect(actual.DisplayName).To(Equal("テスト（昨日: 2 / 今日: 0）"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Post()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/api/notes/create"),
+					ghttp.VerifyJSON(`{"i":"token","text":"@test 診断結果","visibility":"followers","replyId":"9abd"}`),
+					ghttp.RespondWith(http.StatusOK, `{"createdNote":{"id":"9abe"}}`),
+				),
+			)
+		})
+
+		It("creates a note", func() {
+			actual, err := misskey.Post(context.Background(), service.Post{
+				InReplyToID: "9abd",
+				Status:      "@test 診断結果",
+				Visibility:  "private",
+			})
+			Expect(actual).To(Equal("9abe"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("UploadMedia()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/api/drive/files/create"),
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.FormValue("i")).To(Equal("token"))
+
+						f, _, err := r.FormFile("file")
+						Expect(err).NotTo(HaveOccurred())
+
+						b, err := io.ReadAll(f)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(b).To(Equal([]byte("image")))
+					},
+					ghttp.RespondWith(http.StatusOK, `{"id":"9abf"}`),
+				),
+			)
+		})
+
+		It("uploads the file", func() {
+			actual, err := misskey.UploadMedia(context.Background(), strings.NewReader("image"))
+			Expect(actual).To(Equal("9abf"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Delete()", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/api/notes/delete"),
+					ghttp.VerifyJSON(`{"i":"token","noteId":"9abe"}`),
+					ghttp.RespondWith(http.StatusNoContent, nil),
+				),
+			)
+		})
+
+		It("deletes the note", func() {
+			err := misskey.Delete(context.Background(), "9abe")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
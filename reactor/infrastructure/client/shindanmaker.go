@@ -3,11 +3,8 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"html"
-	"io"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -21,10 +18,8 @@ const (
 )
 
 var (
-	NameRegex          = regexp.MustCompile(`[$\\]{?\d+`)
-	ShindanNameRegex   = regexp.MustCompile(`[@＠].+|[\(（].+[\)）]`)
-	ShindanTokenRegex  = regexp.MustCompile(`<meta[^>]*name="csrf-token"[^>]*content="([\s\S]*?)"[^>]*>`)
-	ShindanResultRegex = regexp.MustCompile(`<textarea[^>]*id="copy-textarea-140"[^>]*>([\s\S]*?)<\/textarea>`)
+	NameRegex        = regexp.MustCompile(`[$\\]{?\d+`)
+	ShindanNameRegex = regexp.MustCompile(`[@＠].+|[\(（].+[\)）]`)
 )
 
 type shindanmaker struct {
@@ -32,7 +27,7 @@ type shindanmaker struct {
 }
 
 type Shindanmaker interface {
-	Do(ctx context.Context, name string, targerURL string) (string, error)
+	Do(ctx context.Context, names []string, targerURL string) (string, error)
 	Name(account service.Account) string
 }
 
@@ -42,7 +37,7 @@ func NewShindanmaker(client *http.Client) Shindanmaker {
 	}
 }
 
-func (s *shindanmaker) Name(account service.Account) string {
+func displayName(account service.Account) string {
 	if account.DisplayName != "" {
 		return account.DisplayName
 	} else {
@@ -50,6 +45,26 @@ func (s *shindanmaker) Name(account service.Account) string {
 	}
 }
 
+func normalizeName(name string) string {
+	name = NameRegex.ReplaceAllString(name, "\\$0")
+	return trimName(name)
+}
+
+func trimName(name string) string {
+	if name != strings.Join(ShindanNameRegex.FindStringSubmatch(name), "") {
+		name = ShindanNameRegex.ReplaceAllString(name, "")
+	}
+	return name
+}
+
+func (s *shindanmaker) Name(account service.Account) string {
+	return displayName(account)
+}
+
+func isBlockedStatus(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
 func (s *shindanmaker) token(ctx context.Context, targetURL string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
 	if err != nil {
@@ -67,39 +82,33 @@ func (s *shindanmaker) token(ctx context.Context, targetURL string) (string, err
 		_ = res.Body.Close()
 	}()
 
+	if isBlockedStatus(res.StatusCode) {
+		return "", fmt.Errorf("failed response from shindan page (%v): %w", res.Status, ErrShindanBlocked)
+	}
 	if res.StatusCode < 200 || res.StatusCode > 399 {
 		return "", fmt.Errorf("failed response from shindan page (%v)", res.Status)
 	}
 
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, res.Body)
+	token, err := ParseShindanToken(res.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read shindan page: %w", err)
-	}
-
-	matches := ShindanTokenRegex.FindSubmatch(buf.Bytes())
-	if matches == nil {
-		return "", fmt.Errorf("failed to parse shindan page")
+		return "", fmt.Errorf("failed to parse shindan page: %w", err)
 	}
 
-	return string(matches[1]), nil
+	return token, nil
 }
 
-func (s *shindanmaker) Do(ctx context.Context, name string, targetURL string) (string, error) {
+func (s *shindanmaker) Do(ctx context.Context, names []string, targetURL string) (string, error) {
 	token, err := s.token(ctx, targetURL)
 	if err != nil {
 		return "", err
 	}
 
-	name = NameRegex.ReplaceAllString(name, "\\$0")
-	if name != strings.Join(ShindanNameRegex.FindStringSubmatch(name), "") {
-		name = ShindanNameRegex.ReplaceAllString(name, "")
-	}
-
 	values := url.Values{
-		"type":               []string{"name"},
-		"user_input_value_1": []string{name},
-		"_token":             []string{token},
+		"type":   []string{"name"},
+		"_token": []string{token},
+	}
+	for i, name := range names {
+		values.Set(fmt.Sprintf("user_input_value_%d", i+1), normalizeName(name))
 	}
 	body := strings.NewReader(values.Encode())
 
@@ -119,24 +128,17 @@ func (s *shindanmaker) Do(ctx context.Context, name string, targetURL string) (s
 		_ = res.Body.Close()
 	}()
 
+	if isBlockedStatus(res.StatusCode) {
+		return "", fmt.Errorf("failed response from shindan result (%v): %w", res.Status, ErrShindanBlocked)
+	}
 	if res.StatusCode < 200 || res.StatusCode > 399 {
 		return "", fmt.Errorf("failed response from shindan result (%v)", res.Status)
 	}
 
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, res.Body)
+	result, err := ParseShindanResult(res.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read shindan result: %w", err)
-	}
-
-	matches := ShindanResultRegex.FindSubmatch(buf.Bytes())
-	if matches == nil {
-		return "", fmt.Errorf("failed to parse shindan result")
+		return "", fmt.Errorf("failed to parse shindan result: %w", err)
 	}
 
-	return strings.ReplaceAll(
-		html.UnescapeString(string(matches[1])),
-		"\u2002",
-		" ",
-	), nil
+	return result, nil
 }
@@ -42,18 +42,18 @@ func (m *MockShindanmaker) EXPECT() *MockShindanmakerMockRecorder {
 }
 
 // Do mocks base method.
-func (m *MockShindanmaker) Do(ctx context.Context, name, targerURL string) (string, error) {
+func (m *MockShindanmaker) Do(ctx context.Context, names []string, targerURL string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Do", ctx, name, targerURL)
+	ret := m.ctrl.Call(m, "Do", ctx, names, targerURL)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Do indicates an expected call of Do.
-func (mr *MockShindanmakerMockRecorder) Do(ctx, name, targerURL any) *gomock.Call {
+func (mr *MockShindanmakerMockRecorder) Do(ctx, names, targerURL any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockShindanmaker)(nil).Do), ctx, name, targerURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockShindanmaker)(nil).Do), ctx, names, targerURL)
 }
 
 // Name mocks base method.
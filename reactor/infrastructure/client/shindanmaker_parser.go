@@ -0,0 +1,215 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	ErrShindanMarkupChanged = errors.New("markup changed")
+	ErrShindanBlocked       = errors.New("blocked")
+
+	ShindanWhitespaceRegex = regexp.MustCompile(`[ \t\r\n\f]+`)
+	ShindanBlockedTitles   = []string{
+		"Just a moment...",
+		"Attention Required! | Cloudflare",
+	}
+)
+
+const (
+	shindanResultTextarea140 = iota
+	shindanResultTextarea
+	shindanResultDescription
+	shindanResultBlock
+	shindanResultSelectors
+)
+
+func attr(t html.Token, key string) (string, bool) {
+	for _, a := range t.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func isShindanBlocked(title string) bool {
+	return slices.Contains(ShindanBlockedTitles, strings.TrimSpace(title))
+}
+
+// ParseShindanToken extracts the CSRF token from a diagnosis page, falling back to the hidden form field.
+func ParseShindanToken(r io.Reader) (string, error) {
+	var (
+		title   strings.Builder
+		inTitle bool
+		input   string
+	)
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return "", fmt.Errorf("failed to read shindan page: %w", z.Err())
+			}
+			if input != "" {
+				return input, nil
+			}
+			if isShindanBlocked(title.String()) {
+				return "", ErrShindanBlocked
+			}
+			return "", ErrShindanMarkupChanged
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			switch t.DataAtom {
+			case atom.Title:
+				inTitle = true
+
+			case atom.Meta:
+				if name, _ := attr(t, "name"); name != "csrf-token" {
+					continue
+				}
+				if content, ok := attr(t, "content"); ok && content != "" {
+					return content, nil
+				}
+
+			case atom.Input:
+				if name, _ := attr(t, "name"); name != "_token" {
+					continue
+				}
+				if value, ok := attr(t, "value"); ok && value != "" && input == "" {
+					input = value
+				}
+			}
+
+		case html.EndTagToken:
+			if z.Token().DataAtom == atom.Title {
+				inTitle = false
+			}
+
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		}
+	}
+}
+
+// ParseShindanResult extracts the result from a result page, trying the 140-character textarea,
+// any other copy textarea, og:description, and the result block in this order.
+func ParseShindanResult(r io.Reader) (string, error) {
+	var (
+		candidates [shindanResultSelectors]strings.Builder
+		title      strings.Builder
+		inTitle    bool
+		textarea   = -1
+		blockDepth int
+	)
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return "", fmt.Errorf("failed to read shindan result: %w", z.Err())
+			}
+			for i, candidate := range candidates {
+				result := candidate.String()
+				if i == shindanResultBlock {
+					result = trimLines(result)
+				}
+				result = strings.TrimSpace(result)
+				if result != "" {
+					return strings.ReplaceAll(result, "\u2002", " "), nil
+				}
+			}
+			if isShindanBlocked(title.String()) {
+				return "", ErrShindanBlocked
+			}
+			return "", ErrShindanMarkupChanged
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			if blockDepth > 0 {
+				if t.DataAtom == atom.Br {
+					candidates[shindanResultBlock].WriteString("\n")
+				} else if tt == html.StartTagToken && !isVoidElement(t.DataAtom) {
+					blockDepth++
+				}
+			}
+
+			id, _ := attr(t, "id")
+			switch t.DataAtom {
+			case atom.Title:
+				inTitle = true
+
+			case atom.Textarea:
+				switch {
+				case id == "copy-textarea-140":
+					textarea = shindanResultTextarea140
+				case strings.HasPrefix(id, "copy-textarea") && candidates[shindanResultTextarea].Len() == 0:
+					textarea = shindanResultTextarea
+				}
+
+			case atom.Meta:
+				if property, _ := attr(t, "property"); property != "og:description" {
+					continue
+				}
+				if content, ok := attr(t, "content"); ok && candidates[shindanResultDescription].Len() == 0 {
+					candidates[shindanResultDescription].WriteString(content)
+				}
+
+			default:
+				if id == "shindanResult" && blockDepth == 0 && tt == html.StartTagToken {
+					blockDepth = 1
+				}
+			}
+
+		case html.EndTagToken:
+			switch z.Token().DataAtom {
+			case atom.Title:
+				inTitle = false
+			case atom.Textarea:
+				textarea = -1
+			}
+			if blockDepth > 0 {
+				blockDepth--
+			}
+
+		case html.TextToken:
+			switch {
+			case inTitle:
+				title.Write(z.Text())
+			case textarea >= 0:
+				candidates[textarea].Write(z.Text())
+			case blockDepth > 0:
+				candidates[shindanResultBlock].WriteString(ShindanWhitespaceRegex.ReplaceAllString(string(z.Text()), " "))
+			}
+		}
+	}
+}
+
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isVoidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Area, atom.Base, atom.Br, atom.Col, atom.Embed, atom.Hr, atom.Img, atom.Input, atom.Link, atom.Meta, atom.Source, atom.Track, atom.Wbr:
+		return true
+	}
+	return false
+}
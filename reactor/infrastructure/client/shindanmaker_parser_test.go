@@ -0,0 +1,69 @@
+package client_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func openPage(name string) *os.File {
+	f, err := os.Open(filepath.Join("testdata", "shindanmaker", name+".html"))
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(f.Close)
+	return f
+}
+
+func golden(name string) string {
+	b, err := os.ReadFile(filepath.Join("testdata", "shindanmaker", name+".golden"))
+	Expect(err).NotTo(HaveOccurred())
+	return string(b)
+}
+
+var _ = Describe("ParseShindanToken()", func() {
+	DescribeTable("page contains token",
+		func(name string) {
+			actual, err := client.ParseShindanToken(openPage(name))
+			Expect(actual).To(Equal(golden(name)))
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("in meta element", "token_meta"),
+		Entry("in input element", "token_input"),
+	)
+
+	DescribeTable("page does not contain token",
+		func(name string, expected error) {
+			actual, err := client.ParseShindanToken(openPage(name))
+			Expect(actual).To(BeEmpty())
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("when blocked", "token_blocked", client.ErrShindanBlocked),
+		Entry("when markup changed", "token_changed", client.ErrShindanMarkupChanged),
+	)
+})
+
+var _ = Describe("ParseShindanResult()", func() {
+	DescribeTable("page contains result",
+		func(name string) {
+			actual, err := client.ParseShindanResult(openPage(name))
+			Expect(actual).To(Equal(golden(name)))
+			Expect(err).NotTo(HaveOccurred())
+		},
+		Entry("in textarea for 140 characters", "result_textarea_140"),
+		Entry("in textarea", "result_textarea"),
+		Entry("in og:description", "result_og_description"),
+		Entry("in result block", "result_block"),
+	)
+
+	DescribeTable("page does not contain result",
+		func(name string, expected error) {
+			actual, err := client.ParseShindanResult(openPage(name))
+			Expect(actual).To(BeEmpty())
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("when blocked", "result_blocked", client.ErrShindanBlocked),
+		Entry("when markup changed", "result_changed", client.ErrShindanMarkupChanged),
+	)
+})
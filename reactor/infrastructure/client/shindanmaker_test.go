@@ -76,7 +76,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(BeEmpty())
 						Expect(err).To(MatchError(HavePrefix("failed to fetch shindan page:")))
 					})
@@ -108,9 +108,10 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(BeEmpty())
-						Expect(err).To(MatchError("failed response from shindan page (403 Forbidden)"))
+						Expect(err).To(MatchError("failed response from shindan page (403 Forbidden): blocked"))
+						Expect(err).To(MatchError(client.ErrShindanBlocked))
 					})
 				})
 			})
@@ -129,9 +130,10 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(BeEmpty())
-						Expect(err).To(MatchError("failed to parse shindan page"))
+						Expect(err).To(MatchError("failed to parse shindan page: markup changed"))
+						Expect(err).To(MatchError(client.ErrShindanMarkupChanged))
 					})
 				})
 
@@ -168,10 +170,44 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes token", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 					})
 				})
 			})
+
+			Describe("multiple inputs", func() {
+				BeforeEach(func() {
+					server.AppendHandlers(
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest(http.MethodGet, "/a/855159"),
+							ghttp.RespondWith(http.StatusOK, `
+							<!doctype html>
+							<html lang="ja">
+							<head>
+								<meta name="csrf-token" content="theQuickBrownFoxJumpsOverTheLazyDog">
+								<title>ちんぽ揃えゲーム</title>
+							</head>
+							<body>
+							</body>
+							</html>
+						`),
+						),
+						ghttp.CombineHandlers(
+							ghttp.VerifyRequest(http.MethodPost, "/855159"),
+							ghttp.VerifyForm(url.Values{
+								"type":               []string{"name"},
+								"user_input_value_1": []string{"テスト"},
+								"user_input_value_2": []string{"test2"},
+								"_token":             []string{"theQuickBrownFoxJumpsOverTheLazyDog"},
+							}),
+						),
+					)
+				})
+
+				It("passes all inputs", func() {
+					_, _ = shindanmaker.Do(context.Background(), []string{"テスト", "test2@example.com"}, serverURL+"/a/855159")
+				})
+			})
 		})
 
 		Describe("escape name", func() {
@@ -209,7 +245,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -246,7 +282,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "@test", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"@test"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -283,7 +319,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "＠テスト", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"＠テスト"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -320,7 +356,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "(テスト)", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"(テスト)"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -357,7 +393,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "（テスト）", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"（テスト）"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -394,7 +430,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before half-width at-sign", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト@がんばらない", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト@がんばらない"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -431,7 +467,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before full-width at-sign", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト＠がんばらない", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト＠がんばらない"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -468,7 +504,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before half-width parentheses", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト(昨日: 1 / 今日: 1)", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト(昨日: 1 / 今日: 1)"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -505,7 +541,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before full-width parentheses", func() {
-						_, _ = shindanmaker.Do(context.Background(), "テスト（昨日: 1 / 今日: 1）", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"テスト（昨日: 1 / 今日: 1）"}, serverURL+"/a/855159")
 					})
 				})
 			})
@@ -544,7 +580,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name", func() {
-						_, _ = shindanmaker.Do(context.Background(), "$1\\1${10}\\{10}", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"$1\\1${10}\\{10}"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -581,7 +617,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before half-width at-sign", func() {
-						_, _ = shindanmaker.Do(context.Background(), "$1\\1${10}\\{10}@がんばらない", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"$1\\1${10}\\{10}@がんばらない"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -618,7 +654,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before full-width at-sign", func() {
-						_, _ = shindanmaker.Do(context.Background(), "$1\\1${10}\\{10}＠がんばらない", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"$1\\1${10}\\{10}＠がんばらない"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -655,7 +691,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before half-width parentheses", func() {
-						_, _ = shindanmaker.Do(context.Background(), "$1\\1${10}\\{10}(昨日: 1 / 今日: 1)", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"$1\\1${10}\\{10}(昨日: 1 / 今日: 1)"}, serverURL+"/a/855159")
 					})
 				})
 
@@ -692,7 +728,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("passes name before full-width parentheses", func() {
-						_, _ = shindanmaker.Do(context.Background(), "$1\\1${10}\\{10}（昨日: 1 / 今日: 1）", serverURL+"/a/855159")
+						_, _ = shindanmaker.Do(context.Background(), []string{"$1\\1${10}\\{10}（昨日: 1 / 今日: 1）"}, serverURL+"/a/855159")
 					})
 				})
 			})
@@ -728,7 +764,7 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(Equal(""))
 						Expect(err).To(MatchError(HavePrefix("failed to fetch shindan result:")))
 					})
@@ -781,9 +817,10 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(Equal(""))
-						Expect(err).To(MatchError(HavePrefix("failed response from shindan result (403 Forbidden)")))
+						Expect(err).To(MatchError("failed response from shindan result (403 Forbidden): blocked"))
+						Expect(err).To(MatchError(client.ErrShindanBlocked))
 					})
 				})
 			})
@@ -823,9 +860,10 @@ var _ = Describe("Shindanmaker", func() {
 					})
 
 					It("returns an error", func() {
-						actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+						actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 						Expect(actual).To(BeEmpty())
-						Expect(err).To(MatchError("failed to parse shindan result"))
+						Expect(err).To(MatchError("failed to parse shindan result: markup changed"))
+						Expect(err).To(MatchError(client.ErrShindanMarkupChanged))
 					})
 				})
 
@@ -895,7 +933,7 @@ var _ = Describe("Shindanmaker", func() {
 							})
 
 							It("returns the result", func() {
-								actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+								actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 								Expect(actual).To(Equal(`ちんんんんぽんんぽちぽちちぽぽぽちんぽ(ﾎﾞﾛﾝ
 
 テストさんは19文字目でちんぽを出せました！
@@ -975,7 +1013,7 @@ https://shindanmaker.com/855159`))
 							})
 
 							It("returns the result", func() {
-								actual, err := shindanmaker.Do(context.Background(), "テスト", serverURL+"/a/855159")
+								actual, err := shindanmaker.Do(context.Background(), []string{"テスト"}, serverURL+"/a/855159")
 								Expect(actual).To(Equal(`んちちんんぽんちちちぽんちちんんんぽぽちちぽちぽぽぽぽんぽぽちんんぽんんんんちちぽぽちんちちんんぽんぽちちぽちぽんんちぽぽんんちんんちんちちぽんんんちちぽちちちちぽちぽんんぽんぽちちぽんちんちちぽんんちんんんぽちんんぽぽ…
 #ちんぽ揃えゲーム #shindanmaker
 https://shindanmaker.com/855159`))
@@ -1048,7 +1086,7 @@ https://shindanmaker.com/855159`))
 						})
 
 						It("returns the result", func() {
-							actual, err := shindanmaker.Do(context.Background(), `<>"'&`, serverURL+"/a/855159")
+							actual, err := shindanmaker.Do(context.Background(), []string{`<>"'&`}, serverURL+"/a/855159")
 							Expect(actual).To(Equal(`ちんんんんぽんんぽちぽちちぽぽぽちんぽ(ﾎﾞﾛﾝ
 
 <>"'&さんは19文字目でちんぽを出せました！
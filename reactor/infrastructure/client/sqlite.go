@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema is the equivalent of database/ for SQLite, where dates are stored as YYYY-MM-DD.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS "counts" (
+    "user_id" integer NOT NULL,
+    "date" date NOT NULL,
+    "count" integer NOT NULL,
+    UNIQUE ("user_id", "date")
+);
+CREATE TABLE IF NOT EXISTS "histories" (
+    "id" integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+    "status_id" text NOT NULL,
+    "action" text NOT NULL,
+    "event" text NOT NULL,
+    "reply_id" text NOT NULL,
+    "date" date NOT NULL,
+    "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS "histories_status_id" ON "histories" ("status_id");
+CREATE INDEX IF NOT EXISTS "histories_created_at" ON "histories" ("created_at");
+CREATE TABLE IF NOT EXISTS "shindan_results" (
+    "url" text NOT NULL,
+    "name" text NOT NULL,
+    "date" date NOT NULL,
+    "result" text NOT NULL,
+    UNIQUE ("url", "name", "date")
+);
+`
+
+type sqliteDB struct {
+	Connection *sqlx.DB
+}
+
+// NewSQLiteDB returns a DB backed by the SQLite file at path, creating the tables if they do not exist.
+func NewSQLiteDB(path string) (DB, error) {
+	conn, err := sqlx.Connect("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %w", err)
+	}
+
+	_, err = conn.Exec(sqliteSchema)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to create tables on DB: %w", err)
+	}
+
+	return &sqliteDB{
+		Connection: conn,
+	}, nil
+}
+
+func sqliteDate(date time.Time) string {
+	return date.Format(time.DateOnly)
+}
+
+func (d *sqliteDB) Close() error {
+	return d.Connection.Close()
+}
+
+func (d *sqliteDB) Query(ctx context.Context, q string) (result []string, affected int64, err error) {
+	conn, err := d.Connection.Connx(ctx)
+	if err != nil {
+		return nil, affected, fmt.Errorf("failed to open: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	rows, err := conn.QueryxContext(ctx, q)
+	if err != nil {
+		return result, affected, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return result, affected, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return result, affected, fmt.Errorf("failed to get values: %w", err)
+		}
+
+		var sb strings.Builder
+		for i, v := range values {
+			if i > 0 {
+				fmt.Fprintln(&sb)
+			}
+			fmt.Fprint(&sb, columns[i], ": ", v)
+		}
+
+		result = append(result, sb.String())
+		affected++
+	}
+
+	err = rows.Err()
+	if err != nil || len(columns) > 0 {
+		return result, affected, err
+	}
+
+	// Statements that return no rows report the number of rows they changed.
+	err = conn.GetContext(ctx, &affected, `SELECT changes()`)
+	return result, affected, err
+}
+
+func (d *sqliteDB) UpdateCount(ctx context.Context, userID int64, date time.Time, count int) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`INSERT INTO "counts" ("user_id", "date", "count") VALUES (?, ?, ?) ON CONFLICT ("user_id", "date") DO UPDATE SET "count" = excluded."count"`,
+		userID,
+		sqliteDate(date),
+		count,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update count on DB: %w", err)
+	}
+
+	return nil
+}
+
+func (d *sqliteDB) DecrementCount(ctx context.Context, userID int64, date time.Time) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`UPDATE "counts" SET "count" = MAX("count" - 1, 0) WHERE "user_id" = ? AND "date" = ?`,
+		userID,
+		sqliteDate(date),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to decrement count on DB: %w", err)
+	}
+
+	return nil
+}
+
+func (d *sqliteDB) GetShindanResult(ctx context.Context, targetURL string, name string, date time.Time) (string, bool, error) {
+	var results []string
+	err := d.Connection.SelectContext(
+		ctx,
+		&results,
+		`SELECT "result" FROM "shindan_results" WHERE "url" = ? AND "name" = ? AND "date" = ?`,
+		targetURL,
+		name,
+		sqliteDate(date),
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get shindan result from DB: %w", err)
+	}
+	if len(results) == 0 {
+		return "", false, nil
+	}
+	return results[0], true, nil
+}
+
+func (d *sqliteDB) SaveShindanResult(ctx context.Context, targetURL string, name string, date time.Time, result string) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`INSERT INTO "shindan_results" ("url", "name", "date", "result") VALUES (?, ?, ?, ?) ON CONFLICT ("url", "name", "date") DO UPDATE SET "result" = excluded."result"`,
+		targetURL,
+		name,
+		sqliteDate(date),
+		result,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save shindan result on DB: %w", err)
+	}
+	return nil
+}
+
+func (d *sqliteDB) FindHistory(ctx context.Context, statusID string) ([]service.HistoryRecord, error) {
+	var histories []History
+	err := d.Connection.SelectContext(
+		ctx,
+		&histories,
+		`SELECT "id", "status_id", "action", "event", "reply_id", "date" FROM "histories" WHERE "status_id" = ?`,
+		statusID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find history from DB: %w", err)
+	}
+
+	records := make([]service.HistoryRecord, 0, len(histories))
+	for _, h := range histories {
+		records = append(records, service.HistoryRecord(h))
+	}
+	return records, nil
+}
+
+func (d *sqliteDB) SaveHistory(ctx context.Context, record service.HistoryRecord) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`INSERT INTO "histories" ("status_id", "action", "event", "reply_id", "date") VALUES (?, ?, ?, ?, ?)`,
+		record.StatusID,
+		record.Action,
+		record.Event,
+		record.ReplyID,
+		sqliteDate(record.Date),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save history on DB: %w", err)
+	}
+	return nil
+}
+
+func (d *sqliteDB) DeleteHistory(ctx context.Context, id int64) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`DELETE FROM "histories" WHERE "id" = ?`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete history from DB: %w", err)
+	}
+	return nil
+}
+
+func (d *sqliteDB) PruneHistory(ctx context.Context, before time.Time) error {
+	_, err := d.Connection.ExecContext(
+		ctx,
+		`DELETE FROM "histories" WHERE "created_at" < ?`,
+		before.UTC().Format(time.DateTime),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune history from DB: %w", err)
+	}
+	return nil
+}
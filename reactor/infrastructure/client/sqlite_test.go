@@ -0,0 +1,160 @@
+package client_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLiteDB", func() {
+	var (
+		ctx  context.Context
+		db   client.DB
+		date time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		date = time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+
+		var err error
+		db, err = client.NewSQLiteDB(filepath.Join(GinkgoT().TempDir(), "ejaculation.db"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(db.Close)
+	})
+
+	Describe("UpdateCount()", func() {
+		It("inserts or updates the count", func() {
+			err := db.UpdateCount(ctx, 1, date, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = db.UpdateCount(ctx, 1, date, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, affected, err := db.Query(ctx, `SELECT "user_id", "date", "count" FROM "counts"`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(affected).To(Equal(int64(1)))
+			Expect(actual).To(HaveLen(1))
+			Expect(actual[0]).To(HaveSuffix("count: 2"))
+		})
+	})
+
+	Describe("DecrementCount()", func() {
+		It("decrements the count down to zero", func() {
+			err := db.UpdateCount(ctx, 1, date, 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = db.DecrementCount(ctx, 1, date)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = db.DecrementCount(ctx, 1, date)
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, _, err := db.Query(ctx, `SELECT "count" FROM "counts"`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal([]string{"count: 0"}))
+		})
+	})
+
+	Describe("Query()", func() {
+		Context("statement returns no rows", func() {
+			It("returns the number of changed rows", func() {
+				err := db.UpdateCount(ctx, 1, date, 1)
+				Expect(err).NotTo(HaveOccurred())
+
+				actual, affected, err := db.Query(ctx, `UPDATE "counts" SET "count" = 3`)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(BeEmpty())
+				Expect(affected).To(Equal(int64(1)))
+			})
+		})
+
+		Context("statement is invalid", func() {
+			It("returns an error", func() {
+				_, _, err := db.Query(ctx, `SELECT FROM`)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("GetShindanResult()", func() {
+		Context("result is saved", func() {
+			It("returns the result", func() {
+				err := db.SaveShindanResult(ctx, "https://shindanmaker.com/a/1", "test", date, "result")
+				Expect(err).NotTo(HaveOccurred())
+
+				actual, ok, err := db.GetShindanResult(ctx, "https://shindanmaker.com/a/1", "test", date)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(actual).To(Equal("result"))
+			})
+		})
+
+		Context("result is not saved", func() {
+			It("returns false", func() {
+				_, ok, err := db.GetShindanResult(ctx, "https://shindanmaker.com/a/1", "test", date)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("FindHistory()", func() {
+		It("returns saved histories until deleted", func() {
+			record := service.HistoryRecord{
+				StatusID: "100",
+				Action:   "reply",
+				Event:    "increment",
+				ReplyID:  "101",
+				Date:     date,
+			}
+			err := db.SaveHistory(ctx, record)
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, err := db.FindHistory(ctx, "100")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveLen(1))
+			Expect(actual[0].StatusID).To(Equal("100"))
+			Expect(actual[0].ReplyID).To(Equal("101"))
+			Expect(actual[0].Date.Format(time.DateOnly)).To(Equal("2024-01-02"))
+
+			err = db.DeleteHistory(ctx, actual[0].ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, err = db.FindHistory(ctx, "100")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(BeEmpty())
+		})
+	})
+
+	Describe("PruneHistory()", func() {
+		It("deletes histories saved before the given time", func() {
+			err := db.SaveHistory(ctx, service.HistoryRecord{
+				StatusID: "100",
+				Action:   "reply",
+				Event:    "reply",
+				ReplyID:  "101",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = db.PruneHistory(ctx, time.Now().Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, err := db.FindHistory(ctx, "100")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(HaveLen(1))
+
+			err = db.PruneHistory(ctx, time.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			actual, err = db.FindHistory(ctx, "100")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(BeEmpty())
+		})
+	})
+})
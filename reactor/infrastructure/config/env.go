@@ -15,12 +15,15 @@ type Environment struct {
 	Mastodon Mastodon
 	Queue    Queue
 	External External
+	TTL      TTL
 
-	LogLevel slog.Level
-	Port     string
-	TLSCert  string
-	TLSKey   string
-	UserID   int64
+	AdminToken string
+	LogLevel   slog.Level
+	Platform   string
+	Port       string
+	TLSCert    string
+	TLSKey     string
+	UserID     int64
 }
 
 type DB struct {
@@ -36,22 +39,38 @@ type DB struct {
 }
 
 type Mastodon struct {
-	UserID      string
-	ServerURL   string
-	AccessToken string
+	UserID         string
+	ServerURL      string
+	AccessToken    string
+	WelcomeMessage string
+	DeleteReplies  bool
 }
 
 type Queue struct {
+	Backend     string
 	Host        string
 	Username    string
 	Password    string
 	SSLCert     string
 	SSLKey      string
 	SSLRootCert string
+	MaxRetries  int64
 }
 
 type External struct {
-	MpywAPIURL string
+	MpywAPIURL                 string
+	ShindanmakerDefinitionsDir string
+	ShindanmakerCacheSize      int64
+	ShindanmakerCachePersisted bool
+	BreakerThreshold           int64
+	BreakerTimeout             time.Duration
+	FallbackMessage            string
+}
+
+type TTL struct {
+	Packets        map[string]time.Duration
+	Actions        map[string]time.Duration
+	ApologyMessage string
 }
 
 func Get() (env Environment, errs error) {
@@ -72,14 +91,29 @@ func Get() (env Environment, errs error) {
 		{name: "MASTODON_USER_ID", field: &env.Mastodon.UserID},
 		{name: "MASTODON_SERVER_URL", field: &env.Mastodon.ServerURL},
 		{name: "MASTODON_ACCESS_TOKEN", field: &env.Mastodon.AccessToken},
+		{name: "MASTODON_WELCOME_MESSAGE", field: &env.Mastodon.WelcomeMessage, optional: true},
+		{name: "MASTODON_DELETE_REPLIES", field: &env.Mastodon.DeleteReplies, optional: true},
+		{name: "MQ_BACKEND", field: &env.Queue.Backend, optional: true},
 		{name: "MQ_HOST", field: &env.Queue.Host},
 		{name: "MQ_USERNAME", field: &env.Queue.Username, optional: true},
 		{name: "MQ_PASSWORD", field: &env.Queue.Password, optional: true},
 		{name: "MQ_SSL_CERT", field: &env.Queue.SSLCert, optional: true},
 		{name: "MQ_SSL_KEY", field: &env.Queue.SSLKey, optional: true},
 		{name: "MQ_SSL_ROOT_CERT", field: &env.Queue.SSLRootCert, optional: true},
+		{name: "MQ_MAX_RETRIES", field: &env.Queue.MaxRetries, optional: true},
 		{name: "EXT_MPYW_API_URL", field: &env.External.MpywAPIURL},
+		{name: "EXT_SHINDANMAKER_DEFINITIONS_DIR", field: &env.External.ShindanmakerDefinitionsDir, optional: true},
+		{name: "EXT_SHINDANMAKER_CACHE_SIZE", field: &env.External.ShindanmakerCacheSize, optional: true},
+		{name: "EXT_SHINDANMAKER_CACHE_PERSISTED", field: &env.External.ShindanmakerCachePersisted, optional: true},
+		{name: "EXT_BREAKER_THRESHOLD", field: &env.External.BreakerThreshold, optional: true},
+		{name: "EXT_BREAKER_TIMEOUT_SEC", field: &env.External.BreakerTimeout, optional: true},
+		{name: "EXT_FALLBACK_MESSAGE", field: &env.External.FallbackMessage, optional: true},
+		{name: "TTL_PACKETS_SEC", field: &env.TTL.Packets, optional: true},
+		{name: "TTL_ACTIONS_SEC", field: &env.TTL.Actions, optional: true},
+		{name: "TTL_APOLOGY_MESSAGE", field: &env.TTL.ApologyMessage, optional: true},
+		{name: "ADMIN_TOKEN", field: &env.AdminToken, optional: true},
 		{name: "LOG_LEVEL", field: &env.External, optional: true},
+		{name: "PLATFORM", field: &env.Platform, optional: true},
 		{name: "PORT", field: &env.Port},
 		{name: "TLS_CERT", field: &env.TLSCert, optional: true},
 		{name: "TLS_KEY", field: &env.TLSKey, optional: true},
@@ -97,6 +131,14 @@ func Get() (env Environment, errs error) {
 		case *string:
 			*field = v
 
+		case *bool:
+			v, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
 		case *int64:
 			v, err := strconv.ParseInt(v, 10, 64)
 			if err != nil {
@@ -113,6 +155,14 @@ func Get() (env Environment, errs error) {
 			}
 			*field = time.Duration(v) * time.Second
 
+		case *map[string]time.Duration:
+			v, err := parseDurations(v)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
 		case *slog.Level:
 			v, err := parseLogLevel(v)
 			if err != nil {
@@ -126,6 +176,24 @@ func Get() (env Environment, errs error) {
 	return
 }
 
+// parseDurations parses comma-separated pairs of a name and seconds, such as "packets.tick=0,寿司職人=300".
+func parseDurations(s string) (map[string]time.Duration, error) {
+	durations := map[string]time.Duration{}
+	for pair := range strings.SplitSeq(s, ",") {
+		name, sec, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("not a valid pair: %q", pair)
+		}
+
+		v, err := strconv.ParseInt(sec, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		durations[name] = time.Duration(v) * time.Second
+	}
+	return durations, nil
+}
+
 func parseLogLevel(lvl string) (slog.Level, error) {
 	switch {
 	case strings.EqualFold(lvl, "error"):
@@ -0,0 +1,108 @@
+package config_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Environment", func() {
+	Describe("Get()", func() {
+		BeforeEach(func() {
+			os.Clearenv()
+
+			for name, value := range map[string]string{
+				"DB_HOST":               "db",
+				"DB_DATABASE":           "ejaculation_counter",
+				"DB_USERNAME":           "shiko",
+				"DB_SSL_MODE":           "disable",
+				"MASTODON_USER_ID":      "1",
+				"MASTODON_SERVER_URL":   "mastodon",
+				"MASTODON_ACCESS_TOKEN": "token",
+				"MQ_HOST":               "mq",
+				"EXT_MPYW_API_URL":      "mpyw",
+				"PORT":                  "8080",
+				"USER_ID":               "1",
+			} {
+				err := os.Setenv(name, value)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		Context("TTL vars missing", func() {
+			It("returns config without TTL", func() {
+				env, err := config.Get()
+				Expect(env.TTL).To(Equal(config.TTL{}))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("TTL vars set", func() {
+			BeforeEach(func() {
+				err := os.Setenv("TTL_PACKETS_SEC", "packets.tick=0, packets.message=600")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = os.Setenv("TTL_ACTIONS_SEC", "寿司職人=300,ぴゅっ♡=0")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = os.Setenv("TTL_APOLOGY_MESSAGE", "遅くなってごめんね")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns config with TTL", func() {
+				env, err := config.Get()
+				Expect(env.TTL).To(Equal(config.TTL{
+					Packets: map[string]time.Duration{
+						"packets.tick":    0,
+						"packets.message": 10 * time.Minute,
+					},
+					Actions: map[string]time.Duration{
+						"寿司職人": 5 * time.Minute,
+						"ぴゅっ♡": 0,
+					},
+					ApologyMessage: "遅くなってごめんね",
+				}))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("TTL without a name is given", func() {
+			BeforeEach(func() {
+				err := os.Setenv("TTL_PACKETS_SEC", "=300")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error", func() {
+				_, err := config.Get()
+				Expect(err).To(MatchError(`TTL_PACKETS_SEC is invalid: not a valid pair: "=300"`))
+			})
+		})
+
+		Context("TTL without seconds is given", func() {
+			BeforeEach(func() {
+				err := os.Setenv("TTL_ACTIONS_SEC", "寿司職人")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error", func() {
+				_, err := config.Get()
+				Expect(err).To(MatchError(`TTL_ACTIONS_SEC is invalid: not a valid pair: "寿司職人"`))
+			})
+		})
+
+		Context("TTL with invalid seconds is given", func() {
+			BeforeEach(func() {
+				err := os.Setenv("TTL_ACTIONS_SEC", "寿司職人=five")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error", func() {
+				_, err := config.Get()
+				Expect(err).To(MatchError(HavePrefix("TTL_ACTIONS_SEC is invalid:")))
+			})
+		})
+	})
+})
@@ -8,7 +8,6 @@ import (
 
 	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
 	"github.com/chitoku-k/ejaculation-counter/reactor/service"
-	"github.com/mattn/go-mastodon"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -31,16 +30,16 @@ var (
 )
 
 type administration struct {
-	Client *mastodon.Client
+	Poster service.Poster
 	DB     client.DB
 }
 
 func NewAdministration(
-	client *mastodon.Client,
+	poster service.Poster,
 	db client.DB,
 ) service.Administration {
 	return &administration{
-		Client: client,
+		Poster: poster,
 		DB:     db,
 	}
 }
@@ -89,14 +88,14 @@ func (a *administration) Do(ctx context.Context, event service.AdministrationEve
 		return fmt.Errorf("failed to run query: %w", err)
 	}
 
-	status, n, err := pack(strings.NewReader(a.format(event.Acct, result, affected)))
+	status, n, err := pack(strings.NewReader(a.format(event.Acct, result, affected)), a.Poster.MaxLength())
 	if err != nil {
 		ExecutedAdministrationEventsErrorsTotal.Inc()
 		return fmt.Errorf("failed to prepare reply (%v bytes): %w", n, err)
 	}
 
-	_, err = a.Client.PostStatus(ctx, &mastodon.Toot{
-		InReplyToID: mastodon.ID(event.InReplyToID),
+	_, err = a.Poster.Post(ctx, service.Post{
+		InReplyToID: event.InReplyToID,
 		Status:      status,
 		Visibility:  event.Visibility,
 	})
@@ -0,0 +1,78 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Administration", func() {
+	var (
+		ctrl           *gomock.Controller
+		db             *client.MockDB
+		poster         *client.FakePoster
+		administration service.Administration
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = client.NewMockDB(ctrl)
+		poster = client.NewFakePoster(service.Profile{ID: "1"})
+		administration = invoker.NewAdministration(poster, db)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("event type is unknown", func() {
+			It("returns an error", func() {
+				err := administration.Do(context.Background(), service.AdministrationEvent{Type: "SHELL"})
+				Expect(err).To(MatchError("failed to handle event type: SHELL"))
+			})
+		})
+
+		Context("query fails", func() {
+			BeforeEach(func() {
+				db.EXPECT().Query(gomock.Any(), "SELECT 1").Return(nil, int64(0), errors.New("syntax error"))
+			})
+
+			It("returns an error", func() {
+				err := administration.Do(context.Background(), service.AdministrationEvent{Type: "DB", Command: "SELECT 1"})
+				Expect(err).To(MatchError("failed to run query: syntax error"))
+				Expect(poster.Posts()).To(BeEmpty())
+			})
+		})
+
+		Context("query succeeds", func() {
+			BeforeEach(func() {
+				db.EXPECT().Query(gomock.Any(), "SELECT 1").Return([]string{"?column? | 1"}, int64(1), nil)
+			})
+
+			It("posts the result", func() {
+				err := administration.Do(context.Background(), service.AdministrationEvent{
+					InReplyToID: "2",
+					Acct:        "admin",
+					Type:        "DB",
+					Command:     "SELECT 1",
+					Visibility:  "direct",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						InReplyToID: "2",
+						Status:      "@admin\n?column? | 1\n--------\n(1 row)",
+						Visibility:  "direct",
+					},
+				}))
+			})
+		})
+	})
+})
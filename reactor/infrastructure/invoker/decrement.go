@@ -0,0 +1,99 @@
+package invoker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	DecrementTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "decrement_total",
+		Help:      "Total number of decrement through API.",
+	})
+	DecrementErrorTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "decrement_error_total",
+		Help:      "Total number of errors triggered when decrementing through API.",
+	}, []string{"type"})
+)
+
+type decrement struct {
+	Poster service.Poster
+	DB     client.DB
+	UserID int64
+	Clock  func() time.Time
+}
+
+func NewDecrement(
+	poster service.Poster,
+	db client.DB,
+	userID int64,
+	clock func() time.Time,
+) service.Decrement {
+	return &decrement{
+		Poster: poster,
+		DB:     db,
+		UserID: userID,
+		Clock:  clock,
+	}
+}
+
+func (d *decrement) Do(ctx context.Context, event service.DecrementEvent) error {
+	date := time.Date(event.Year, time.Month(event.Month), event.Day, 0, 0, 0, 0, time.Local)
+	now := d.Clock()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	// Only today and yesterday are shown in the display name; older counts live in DB only.
+	if date.Before(today.AddDate(0, 0, -1)) || date.After(today) {
+		err := d.DB.DecrementCount(ctx, d.UserID, date)
+		if err != nil {
+			DecrementErrorTotal.WithLabelValues("db").Inc()
+			return fmt.Errorf("failed to update DB: %w", err)
+		}
+
+		DecrementTotal.Inc()
+		return nil
+	}
+
+	profile, err := d.Poster.Profile(ctx)
+	if err != nil {
+		DecrementErrorTotal.WithLabelValues("get").Inc()
+		return fmt.Errorf("failed to get current user for updating: %w", err)
+	}
+
+	summary := parse(profile)
+	count := &summary.Today
+	if date.Before(today) {
+		count = &summary.Yesterday
+	}
+	*count = max(*count-1, 0)
+
+	name := fmt.Sprintf(
+		"%s（昨日: %d / 今日: %d）",
+		summary.Name,
+		summary.Yesterday,
+		summary.Today,
+	)
+
+	_, err = d.Poster.UpdateProfile(ctx, name)
+	if err != nil {
+		DecrementErrorTotal.WithLabelValues("update").Inc()
+		return fmt.Errorf("failed to update current user: %w", err)
+	}
+
+	err = d.DB.UpdateCount(ctx, d.UserID, date, *count)
+	if err != nil {
+		DecrementErrorTotal.WithLabelValues("db").Inc()
+		return fmt.Errorf("failed to update DB: %w", err)
+	}
+
+	DecrementTotal.Inc()
+	return nil
+}
@@ -0,0 +1,82 @@
+package invoker_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Decrement", func() {
+	var (
+		ctrl      *gomock.Controller
+		db        *client.MockDB
+		poster    *client.FakePoster
+		decrement service.Decrement
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = client.NewMockDB(ctrl)
+		poster = client.NewFakePoster(service.Profile{ID: "1", DisplayName: "テスト（昨日: 3 / 今日: 1）"})
+		decrement = invoker.NewDecrement(poster, db, 1, func() time.Time {
+			return time.Date(2006, 1, 2, 15, 4, 5, 0, time.Local)
+		})
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("date is today", func() {
+			BeforeEach(func() {
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), time.Date(2006, 1, 2, 0, 0, 0, 0, time.Local), 0).Return(nil)
+			})
+
+			It("decrements today in the profile", func() {
+				err := decrement.Do(context.Background(), service.DecrementEvent{Year: 2006, Month: 1, Day: 2})
+				Expect(err).NotTo(HaveOccurred())
+
+				profile, err := poster.Profile(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.DisplayName).To(Equal("テスト（昨日: 3 / 今日: 0）"))
+			})
+		})
+
+		Context("date is yesterday", func() {
+			BeforeEach(func() {
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), time.Date(2006, 1, 1, 0, 0, 0, 0, time.Local), 2).Return(nil)
+			})
+
+			It("decrements yesterday in the profile", func() {
+				err := decrement.Do(context.Background(), service.DecrementEvent{Year: 2006, Month: 1, Day: 1})
+				Expect(err).NotTo(HaveOccurred())
+
+				profile, err := poster.Profile(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.DisplayName).To(Equal("テスト（昨日: 2 / 今日: 1）"))
+			})
+		})
+
+		Context("date is older", func() {
+			BeforeEach(func() {
+				db.EXPECT().DecrementCount(gomock.Any(), int64(1), time.Date(2005, 12, 31, 0, 0, 0, 0, time.Local)).Return(nil)
+			})
+
+			It("decrements in DB only", func() {
+				err := decrement.Do(context.Background(), service.DecrementEvent{Year: 2005, Month: 12, Day: 31})
+				Expect(err).NotTo(HaveOccurred())
+
+				profile, err := poster.Profile(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.DisplayName).To(Equal("テスト（昨日: 3 / 今日: 1）"))
+			})
+		})
+	})
+})
@@ -7,7 +7,6 @@ import (
 
 	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
 	"github.com/chitoku-k/ejaculation-counter/reactor/service"
-	"github.com/mattn/go-mastodon"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -26,31 +25,31 @@ var (
 )
 
 type increment struct {
-	Client *mastodon.Client
+	Poster service.Poster
 	DB     client.DB
 	UserID int64
 }
 
 func NewIncrement(
-	client *mastodon.Client,
+	poster service.Poster,
 	db client.DB,
 	userID int64,
 ) service.Increment {
 	return &increment{
-		Client: client,
+		Poster: poster,
 		DB:     db,
 		UserID: userID,
 	}
 }
 
 func (i *increment) Do(ctx context.Context, event service.IncrementEvent) error {
-	user, err := i.Client.GetAccountCurrentUser(ctx)
+	profile, err := i.Poster.Profile(ctx)
 	if err != nil {
 		IncrementErrorTotal.WithLabelValues("get").Inc()
 		return fmt.Errorf("failed to get current user for updating: %w", err)
 	}
 
-	summary := parse(*user)
+	summary := parse(profile)
 	name := fmt.Sprintf(
 		"%s（昨日: %d / 今日: %d）",
 		summary.Name,
@@ -58,9 +57,7 @@ func (i *increment) Do(ctx context.Context, event service.IncrementEvent) error
 		summary.Today+1,
 	)
 
-	_, err = i.Client.AccountUpdate(ctx, &mastodon.Profile{
-		DisplayName: &name,
-	})
+	_, err = i.Poster.UpdateProfile(ctx, name)
 	if err != nil {
 		IncrementErrorTotal.WithLabelValues("update").Inc()
 		return fmt.Errorf("failed to update current user: %w", err)
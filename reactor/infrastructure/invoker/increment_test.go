@@ -0,0 +1,61 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Increment", func() {
+	var (
+		ctrl      *gomock.Controller
+		db        *client.MockDB
+		poster    *client.FakePoster
+		increment service.Increment
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = client.NewMockDB(ctrl)
+		poster = client.NewFakePoster(service.Profile{ID: "1", DisplayName: "テスト（昨日: 3 / 今日: 1）"})
+		increment = invoker.NewIncrement(poster, db, 1)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("updating DB fails", func() {
+			BeforeEach(func() {
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), gomock.Any(), 2).Return(errors.New("connection refused"))
+			})
+
+			It("returns an error", func() {
+				err := increment.Do(context.Background(), service.IncrementEvent{})
+				Expect(err).To(MatchError("failed to update DB: connection refused"))
+			})
+		})
+
+		Context("updating DB succeeds", func() {
+			BeforeEach(func() {
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), gomock.Any(), 2).Return(nil)
+			})
+
+			It("increments the count in the profile", func() {
+				err := increment.Do(context.Background(), service.IncrementEvent{})
+				Expect(err).NotTo(HaveOccurred())
+
+				profile, err := poster.Profile(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.DisplayName).To(Equal("テスト（昨日: 3 / 今日: 2）"))
+			})
+		})
+	})
+})
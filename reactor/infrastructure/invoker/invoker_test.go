@@ -0,0 +1,13 @@
+package invoker_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInvoker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Invoker Suite")
+}
@@ -8,14 +8,13 @@ import (
 	"strings"
 
 	"github.com/chitoku-k/ejaculation-counter/reactor/service"
-	"github.com/mattn/go-mastodon"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/rivo/uniseg"
 )
 
 const (
-	MaxTootLength = 500
+	FallbackMessage = "今は診断できないみたい…また後で試してね"
 )
 
 var (
@@ -33,15 +32,32 @@ var (
 		Name:      "replied_events_error_total",
 		Help:      "Total number of errors triggered when replying through API.",
 	})
+	DeletedRepliesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "deleted_replies_total",
+		Help:      "Total number of replies deleted through API.",
+	})
+	DeletedRepliesErrorTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "deleted_replies_error_total",
+		Help:      "Total number of errors triggered when deleting replies through API.",
+	})
 )
 
 type reply struct {
-	Client *mastodon.Client
+	Poster          service.Poster
+	FallbackMessage string
+	ApologyMessage  string
 }
 
-func NewReply(client *mastodon.Client) service.Reply {
+func NewReply(poster service.Poster, fallbackMessage, apologyMessage string) service.Reply {
+	if fallbackMessage == "" {
+		fallbackMessage = FallbackMessage
+	}
 	return &reply{
-		Client: client,
+		Poster:          poster,
+		FallbackMessage: fallbackMessage,
+		ApologyMessage:  apologyMessage,
 	}
 }
 
@@ -50,7 +66,8 @@ func getTootLength(s string) int {
 	return uniseg.GraphemeClusterCount(s)
 }
 
-func pack(r io.Reader) (string, int, error) {
+// pack reads r into a post up to maxLength graphemes, which is the limit of the platform.
+func pack(r io.Reader, maxLength int) (string, int, error) {
 	buf := make([]byte, 512)
 
 	var builder strings.Builder
@@ -64,7 +81,7 @@ func pack(r io.Reader) (string, int, error) {
 		if err != nil {
 			return builder.String(), builder.Len(), err
 		}
-		if getTootLength(builder.String()+string(buf[:n])) > MaxTootLength {
+		if getTootLength(builder.String()+string(buf[:n])) > maxLength {
 			break
 		}
 		builder.Write(buf[:n])
@@ -73,35 +90,43 @@ func pack(r io.Reader) (string, int, error) {
 	return builder.String(), builder.Len(), nil
 }
 
-func (r *reply) Send(ctx context.Context, event service.ReplyEvent) error {
+func (r *reply) Send(ctx context.Context, event service.ReplyEvent) (string, error) {
 	defer func() {
 		_ = event.Body.Close()
 	}()
 
-	status, n, err := pack(io.MultiReader(strings.NewReader(fmt.Sprintf("@%s ", event.Acct)), event.Body))
+	status, n, err := pack(io.MultiReader(strings.NewReader(fmt.Sprintf("@%s ", event.Acct)), event.Body), r.Poster.MaxLength())
 	if err != nil {
 		RepliedEventsErrorTotal.Inc()
-		return fmt.Errorf("failed to prepare reply (%v bytes): %w", n, err)
+		return "", fmt.Errorf("failed to prepare reply (%v bytes): %w", n, err)
 	}
 
-	_, err = r.Client.PostStatus(ctx, &mastodon.Toot{
-		InReplyToID: mastodon.ID(event.InReplyToID),
+	id, err := r.Poster.Post(ctx, service.Post{
+		InReplyToID: event.InReplyToID,
 		Status:      status,
 		Visibility:  event.Visibility,
 	})
 	if err != nil {
 		RepliedEventsErrorTotal.Inc()
-		return fmt.Errorf("failed to send reply: %w", err)
+		return "", fmt.Errorf("failed to send reply: %w", err)
 	}
 
 	RepliedEventsTotal.Inc()
-	return nil
+	return id, nil
 }
 
 func (r *reply) SendError(ctx context.Context, event service.ReplyErrorEvent) error {
-	_, err := r.Client.PostStatus(ctx, &mastodon.Toot{
-		InReplyToID: mastodon.ID(event.InReplyToID),
-		Status:      fmt.Sprintf("@%s 何かがおかしいよ（%s）", event.Acct, event.ActionName),
+	status := fmt.Sprintf("@%s 何かがおかしいよ（%s）", event.Acct, event.ActionName)
+	if event.Unavailable {
+		status = fmt.Sprintf("@%s %s（%s）", event.Acct, r.FallbackMessage, event.ActionName)
+	}
+	if event.Expired {
+		status = fmt.Sprintf("@%s %s（%s）", event.Acct, r.ApologyMessage, event.ActionName)
+	}
+
+	_, err := r.Poster.Post(ctx, service.Post{
+		InReplyToID: event.InReplyToID,
+		Status:      status,
 		Visibility:  event.Visibility,
 	})
 	if err != nil {
@@ -112,3 +137,14 @@ func (r *reply) SendError(ctx context.Context, event service.ReplyErrorEvent) er
 	RepliedEventsTotal.Inc()
 	return nil
 }
+
+func (r *reply) Delete(ctx context.Context, event service.DeleteReplyEvent) error {
+	err := r.Poster.Delete(ctx, event.ID)
+	if err != nil {
+		DeletedRepliesErrorTotal.Inc()
+		return fmt.Errorf("failed to delete reply: %w", err)
+	}
+
+	DeletedRepliesTotal.Inc()
+	return nil
+}
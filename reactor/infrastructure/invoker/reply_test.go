@@ -0,0 +1,170 @@
+package invoker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reply", func() {
+	var (
+		poster *client.FakePoster
+		reply  service.Reply
+	)
+
+	BeforeEach(func() {
+		poster = client.NewFakePoster(service.Profile{ID: "1"})
+		reply = invoker.NewReply(poster, "", "古い投稿には反応できなかったよ、ごめんね")
+	})
+
+	Describe("Send()", func() {
+		Context("posting fails", func() {
+			BeforeEach(func() {
+				poster.Err = errors.New("500 Internal Server Error")
+			})
+
+			It("returns an error", func() {
+				_, err := reply.Send(context.Background(), service.ReplyEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Body:        io.NopCloser(strings.NewReader("診断結果")),
+					Visibility:  "private",
+				})
+				Expect(err).To(MatchError("failed to send reply: 500 Internal Server Error"))
+			})
+		})
+
+		Context("posting succeeds", func() {
+			It("posts a reply with the mention", func() {
+				id, err := reply.Send(context.Background(), service.ReplyEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Body:        io.NopCloser(strings.NewReader("診断結果")),
+					Visibility:  "private",
+				})
+				Expect(id).To(Equal("1"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						InReplyToID: "2",
+						Status:      "@test 診断結果",
+						Visibility:  "private",
+					},
+				}))
+			})
+		})
+
+		Context("body is too long", func() {
+			It("truncates the reply", func() {
+				_, err := reply.Send(context.Background(), service.ReplyEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Body:        io.NopCloser(strings.NewReader(strings.Repeat("ぴゅっ", 1000))),
+					Visibility:  "private",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(HaveLen(1))
+				Expect(len([]rune(poster.Posts()[0].Status))).To(BeNumerically("<=", client.MastodonMaxLength))
+			})
+
+			It("truncates the reply to the length of the platform", func() {
+				poster.Length = client.BlueskyMaxLength
+
+				_, err := reply.Send(context.Background(), service.ReplyEvent{
+					InReplyToID: "2",
+					Acct:        "test.bsky.social",
+					Body:        io.NopCloser(strings.NewReader(strings.Repeat("ぴゅっ", 1000))),
+					Visibility:  "public",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(HaveLen(1))
+				Expect(len([]rune(poster.Posts()[0].Status))).To(BeNumerically("<=", client.BlueskyMaxLength))
+				Expect(poster.Posts()[0].Status).To(HavePrefix("@test.bsky.social ぴゅっ"))
+			})
+		})
+	})
+
+	Describe("SendError()", func() {
+		Context("action is unavailable", func() {
+			It("posts the fallback message", func() {
+				err := reply.SendError(context.Background(), service.ReplyErrorEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Visibility:  "public",
+					ActionName:  "ちんぽ揃えゲーム",
+					Unavailable: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						InReplyToID: "2",
+						Status:      "@test 今は診断できないみたい…また後で試してね（ちんぽ揃えゲーム）",
+						Visibility:  "public",
+					},
+				}))
+			})
+		})
+
+		Context("action is expired", func() {
+			It("posts the apology message", func() {
+				err := reply.SendError(context.Background(), service.ReplyErrorEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Visibility:  "public",
+					ActionName:  "寿司職人",
+					Expired:     true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						InReplyToID: "2",
+						Status:      "@test 古い投稿には反応できなかったよ、ごめんね（寿司職人）",
+						Visibility:  "public",
+					},
+				}))
+			})
+		})
+
+		Context("action fails", func() {
+			It("posts the error message", func() {
+				err := reply.SendError(context.Background(), service.ReplyErrorEvent{
+					InReplyToID: "2",
+					Acct:        "test",
+					Visibility:  "public",
+					ActionName:  "ちんぽ揃えゲーム",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						InReplyToID: "2",
+						Status:      "@test 何かがおかしいよ（ちんぽ揃えゲーム）",
+						Visibility:  "public",
+					},
+				}))
+			})
+		})
+	})
+
+	Describe("Delete()", func() {
+		It("deletes the reply", func() {
+			id, err := reply.Send(context.Background(), service.ReplyEvent{
+				InReplyToID: "2",
+				Acct:        "test",
+				Body:        io.NopCloser(strings.NewReader("診断結果")),
+				Visibility:  "private",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = reply.Delete(context.Background(), service.DeleteReplyEvent{ID: id})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(poster.Posts()).To(BeEmpty())
+		})
+	})
+})
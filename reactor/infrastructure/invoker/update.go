@@ -9,7 +9,6 @@ import (
 
 	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
 	"github.com/chitoku-k/ejaculation-counter/reactor/service"
-	"github.com/mattn/go-mastodon"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -29,7 +28,7 @@ var (
 )
 
 type update struct {
-	Client *mastodon.Client
+	Poster service.Poster
 	DB     client.DB
 	UserID int64
 }
@@ -41,22 +40,22 @@ type summary struct {
 }
 
 func NewUpdate(
-	client *mastodon.Client,
+	poster service.Poster,
 	db client.DB,
 	userID int64,
 ) service.Update {
 	return &update{
-		Client: client,
+		Poster: poster,
 		DB:     db,
 		UserID: userID,
 	}
 }
 
-func parse(account mastodon.Account) summary {
-	matches := DisplayNameRegex.FindStringSubmatch(account.DisplayName)
+func parse(profile service.Profile) summary {
+	matches := DisplayNameRegex.FindStringSubmatch(profile.DisplayName)
 	if matches == nil || matches[1] == "" {
 		return summary{
-			Name:      account.DisplayName,
+			Name:      profile.DisplayName,
 			Yesterday: 0,
 			Today:     0,
 		}
@@ -90,13 +89,13 @@ func message(date time.Time, summary summary) string {
 }
 
 func (u *update) Do(ctx context.Context, event service.UpdateEvent) error {
-	user, err := u.Client.GetAccountCurrentUser(ctx)
+	profile, err := u.Poster.Profile(ctx)
 	if err != nil {
 		UpdatesErrorTotal.WithLabelValues("get").Inc()
 		return fmt.Errorf("failed to get current user for updating: %w", err)
 	}
 
-	summary := parse(*user)
+	summary := parse(profile)
 	name := fmt.Sprintf(
 		"%s（昨日: %d / 今日: %d）",
 		summary.Name,
@@ -104,9 +103,7 @@ func (u *update) Do(ctx context.Context, event service.UpdateEvent) error {
 		0,
 	)
 
-	_, err = u.Client.AccountUpdate(ctx, &mastodon.Profile{
-		DisplayName: &name,
-	})
+	_, err = u.Poster.UpdateProfile(ctx, name)
 	if err != nil {
 		UpdatesErrorTotal.WithLabelValues("update").Inc()
 		return fmt.Errorf("failed to update current user: %w", err)
@@ -114,7 +111,7 @@ func (u *update) Do(ctx context.Context, event service.UpdateEvent) error {
 
 	date := time.Date(event.Year, time.Month(event.Month), event.Day, 0, 0, 0, 0, time.Local)
 	yesterday := date.AddDate(0, 0, -1)
-	_, err = u.Client.PostStatus(ctx, &mastodon.Toot{
+	_, err = u.Poster.Post(ctx, service.Post{
 		Status:     message(yesterday, summary),
 		Visibility: "private",
 	})
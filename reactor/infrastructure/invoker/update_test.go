@@ -0,0 +1,76 @@
+package invoker_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/client"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/invoker"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Update", func() {
+	var (
+		ctrl   *gomock.Controller
+		db     *client.MockDB
+		poster *client.FakePoster
+		update service.Update
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		db = client.NewMockDB(ctrl)
+		update = invoker.NewUpdate(poster, db, 1)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Do()", func() {
+		Context("count has changed", func() {
+			BeforeEach(func() {
+				poster = client.NewFakePoster(service.Profile{ID: "1", DisplayName: "テスト（昨日: 3 / 今日: 1）"})
+				update = invoker.NewUpdate(poster, db, 1)
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), time.Date(2006, 1, 1, 0, 0, 0, 0, time.Local), 1).Return(nil)
+			})
+
+			It("resets the profile and posts the summary", func() {
+				err := update.Do(context.Background(), service.UpdateEvent{Year: 2006, Month: 1, Day: 2})
+				Expect(err).NotTo(HaveOccurred())
+
+				profile, err := poster.Profile(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.DisplayName).To(Equal("テスト（昨日: 1 / 今日: 0）"))
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						Status:     "2006-01-01 は 1 回ぴゅっぴゅしました…",
+						Visibility: "private",
+					},
+				}))
+			})
+		})
+
+		Context("count has not changed", func() {
+			BeforeEach(func() {
+				poster = client.NewFakePoster(service.Profile{ID: "1", DisplayName: "テスト（昨日: 0 / 今日: 0）"})
+				update = invoker.NewUpdate(poster, db, 1)
+				db.EXPECT().UpdateCount(gomock.Any(), int64(1), time.Date(2006, 1, 1, 0, 0, 0, 0, time.Local), 0).Return(nil)
+			})
+
+			It("posts the summary", func() {
+				err := update.Do(context.Background(), service.UpdateEvent{Year: 2006, Month: 1, Day: 2})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(poster.Posts()).To(Equal([]service.Post{
+					{
+						Status:     "2006-01-01 もぴゅっぴゅしませんでした…",
+						Visibility: "private",
+					},
+				}))
+			})
+		})
+	})
+})
@@ -0,0 +1,14 @@
+//go:generate go tool mockgen -source=channel.go -destination=channel_mock.go -package=queue -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue
+
+package queue
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Channel is the part of the MQ channel that settles deliveries.
+type Channel interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Ack(tag uint64, multiple bool) error
+	Reject(tag uint64, requeue bool) error
+}
@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: channel.go
+//
+// Generated by this command:
+//
+//	mockgen -source=channel.go -destination=channel_mock.go -package=queue -self_package=github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue
+//
+
+// Package queue is a generated GoMock package.
+package queue
+
+import (
+	reflect "reflect"
+
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockChannel is a mock of Channel interface.
+type MockChannel struct {
+	ctrl     *gomock.Controller
+	recorder *MockChannelMockRecorder
+	isgomock struct{}
+}
+
+// MockChannelMockRecorder is the mock recorder for MockChannel.
+type MockChannelMockRecorder struct {
+	mock *MockChannel
+}
+
+// NewMockChannel creates a new mock instance.
+func NewMockChannel(ctrl *gomock.Controller) *MockChannel {
+	mock := &MockChannel{ctrl: ctrl}
+	mock.recorder = &MockChannelMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChannel) EXPECT() *MockChannelMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockChannel) Ack(tag uint64, multiple bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", tag, multiple)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockChannelMockRecorder) Ack(tag, multiple any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockChannel)(nil).Ack), tag, multiple)
+}
+
+// Publish mocks base method.
+func (m *MockChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", exchange, key, mandatory, immediate, msg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockChannelMockRecorder) Publish(exchange, key, mandatory, immediate, msg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockChannel)(nil).Publish), exchange, key, mandatory, immediate, msg)
+}
+
+// Reject mocks base method.
+func (m *MockChannel) Reject(tag uint64, requeue bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", tag, requeue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockChannelMockRecorder) Reject(tag, requeue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockChannel)(nil).Reject), tag, requeue)
+}
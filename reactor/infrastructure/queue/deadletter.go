@@ -0,0 +1,305 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	DeadLetterQueue  = "dl"
+	ParkingQueue     = "parked"
+	DeadLetterLimit  = 1000
+	DeduplicationKey = "x-deduplication-header"
+)
+
+var (
+	ReplayedMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "replayed_message_total",
+		Help:      "Total number of dead-lettered messages replayed to the exchange.",
+	}, []string{"queue"})
+	PurgedMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "purged_message_total",
+		Help:      "Total number of dead-lettered messages purged.",
+	}, []string{"queue"})
+)
+
+type deadLetters struct {
+	Exchange    string
+	QueueName   string
+	RoutingKey  string
+	Host        string
+	Username    string
+	Password    string
+	SSLCert     string
+	SSLKey      string
+	SSLRootCert string
+}
+
+// NewDeadLetters returns an inspector for the dead-letter and parking queues of the given queue.
+// It opens a connection for each operation, as they are only used by administrators.
+func NewDeadLetters(
+	exchange, queueName, routingKey string,
+	host, username, password string,
+	sslCert, sslKey, sslRootCert string,
+) service.DeadLetters {
+	return &deadLetters{
+		Exchange:    exchange,
+		QueueName:   queueName,
+		RoutingKey:  routingKey,
+		Host:        host,
+		Username:    username,
+		Password:    password,
+		SSLCert:     sslCert,
+		SSLKey:      sslKey,
+		SSLRootCert: sslRootCert,
+	}
+}
+
+// DeliveryID returns the identifier of a delivery, which is the deduplication header set by the supplier.
+func DeliveryID(delivery amqp.Delivery) string {
+	if id, ok := delivery.Headers[DeduplicationKey].(string); ok {
+		return id
+	}
+	return delivery.MessageId
+}
+
+func (d *deadLetters) queue(name string) (string, error) {
+	switch name {
+	case DeadLetterQueue:
+		return d.QueueName + DeadLetterSuffix, nil
+	case ParkingQueue:
+		return d.QueueName + ParkingSuffix, nil
+	default:
+		return "", fmt.Errorf("%w: %q", service.ErrUnknownQueue, name)
+	}
+}
+
+// each passes the messages in the queue to fn in batches of DeadLetterLimit until the queue is exhausted.
+// Messages are kept unacknowledged on the channel, so that the next batch starts after them.
+func each(ch *amqp.Channel, queue string, fn func(delivery amqp.Delivery) error) error {
+	for {
+		deliveries, err := fetch(ch, queue, DeadLetterLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			err := fn(delivery)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < DeadLetterLimit {
+			return nil
+		}
+	}
+}
+
+// open returns a channel on a new connection. Messages fetched and not acknowledged on the channel
+// are returned to the queue when the returned function is called.
+func (d *deadLetters) open() (*amqp.Channel, func(), error) {
+	uri, err := amqp.ParseURI(d.Host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse MQ URI: %w", err)
+	}
+
+	uri.Username = d.Username
+	uri.Password = d.Password
+
+	conn, _, err := dial(uri.String(), d.Username, d.Password, d.SSLCert, d.SSLKey, d.SSLRootCert)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to MQ broker: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("failed to open a channel for MQ connection: %w", err)
+	}
+
+	return ch, func() {
+		_ = ch.Close()
+		_ = conn.Close()
+	}, nil
+}
+
+// fetch gets up to limit messages from the queue without acknowledging them.
+func fetch(ch *amqp.Channel, queue string, limit int) ([]amqp.Delivery, error) {
+	var deliveries []amqp.Delivery
+	for len(deliveries) < limit {
+		delivery, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get message from %s: %w", queue, err)
+		}
+		if !ok {
+			break
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (d *deadLetters) List(ctx context.Context, name string, limit int) ([]service.DeadLetter, error) {
+	queue, err := d.queue(name)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > DeadLetterLimit {
+		limit = DeadLetterLimit
+	}
+
+	ch, closer, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	deliveries, err := fetch(ch, queue, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]service.DeadLetter, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deadLetter := service.DeadLetter{
+			ID:        DeliveryID(delivery),
+			Type:      delivery.Type,
+			Timestamp: delivery.Timestamp,
+			Deaths:    DeathCount(delivery.Headers, d.QueueName),
+		}
+
+		packet, err := DecodePacket(delivery.Type, schema.Version(delivery.Headers), delivery.DeliveryTag, delivery.Timestamp, delivery.Body)
+		if err != nil {
+			deadLetter.Body = json.RawMessage(delivery.Body)
+			if !json.Valid(delivery.Body) {
+				deadLetter.Body = string(delivery.Body)
+			}
+			deadLetter.Error = err.Error()
+		} else {
+			deadLetter.Body = packet
+		}
+
+		result = append(result, deadLetter)
+	}
+
+	return result, nil
+}
+
+func (d *deadLetters) Replay(ctx context.Context, name string, ids []string) (int, error) {
+	queue, err := d.queue(name)
+	if err != nil {
+		return 0, err
+	}
+
+	ch, closer, err := d.open()
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	var replayed int
+	err = each(ch, queue, func(delivery amqp.Delivery) error {
+		if !slices.Contains(ids, DeliveryID(delivery)) {
+			return nil
+		}
+
+		// Drop the history of dead-lettering so that the packet is retried from scratch.
+		headers := maps.Clone(delivery.Headers)
+		delete(headers, "x-death")
+		delete(headers, "x-first-death-exchange")
+		delete(headers, "x-first-death-queue")
+		delete(headers, "x-first-death-reason")
+		delete(headers, "x-last-death-exchange")
+		delete(headers, "x-last-death-queue")
+		delete(headers, "x-last-death-reason")
+
+		err := ch.PublishWithContext(
+			ctx,
+			d.Exchange,
+			d.RoutingKey,
+			false,
+			false,
+			amqp.Publishing{
+				Headers:      headers,
+				ContentType:  delivery.ContentType,
+				DeliveryMode: amqp.Persistent,
+				Timestamp:    delivery.Timestamp,
+				Type:         delivery.Type,
+				Body:         delivery.Body,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to replay message: %w", err)
+		}
+
+		err = ch.Ack(delivery.DeliveryTag, false)
+		if err != nil {
+			return fmt.Errorf("failed to remove replayed message: %w", err)
+		}
+
+		replayed++
+		ReplayedMessageTotal.WithLabelValues(name).Inc()
+		return nil
+	})
+	if err != nil {
+		return replayed, err
+	}
+
+	return replayed, nil
+}
+
+func (d *deadLetters) Purge(ctx context.Context, name string, ids []string) (int, error) {
+	queue, err := d.queue(name)
+	if err != nil {
+		return 0, err
+	}
+
+	ch, closer, err := d.open()
+	if err != nil {
+		return 0, err
+	}
+	defer closer()
+
+	if len(ids) == 0 {
+		purged, err := ch.QueuePurge(queue, false)
+		if err != nil {
+			return 0, fmt.Errorf("failed to purge %s: %w", queue, err)
+		}
+
+		PurgedMessageTotal.WithLabelValues(name).Add(float64(purged))
+		return purged, nil
+	}
+
+	var purged int
+	err = each(ch, queue, func(delivery amqp.Delivery) error {
+		if !slices.Contains(ids, DeliveryID(delivery)) {
+			return nil
+		}
+
+		err := ch.Ack(delivery.DeliveryTag, false)
+		if err != nil {
+			return fmt.Errorf("failed to purge message: %w", err)
+		}
+
+		purged++
+		PurgedMessageTotal.WithLabelValues(name).Inc()
+		return nil
+	})
+	if err != nil {
+		return purged, err
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,357 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	TypeHeader      = "Type"
+	TimestampHeader = "Timestamp"
+
+	// NATSAckWait is how long a message is kept from being redelivered since the last progress, which is no longer
+	// reported once consuming stops, so it must exceed NATSProgressInterval + service.DrainTimeout not to redeliver
+	// the messages in flight while draining.
+	NATSAckWait = 1 * time.Minute
+
+	// NATSProgressInterval is how often the messages not acknowledged yet are reported to be in progress.
+	NATSProgressInterval = 20 * time.Second
+
+	// NATSDrainTimeout is how long the connection waits for the messages in flight to be acknowledged on close.
+	NATSDrainTimeout = 30 * time.Second
+)
+
+type natsReader struct {
+	ch         chan service.Packet
+	closed     chan struct{}
+	tag        atomic.Uint64
+	mu         sync.Mutex
+	messages   map[uint64]jetstream.Msg
+	Stream     string
+	Subject    string
+	Durable    string
+	MaxRetries int64
+	Connection *nats.Conn
+	JetStream  jetstream.JetStream
+	Consumers  []jetstream.Consumer
+}
+
+// NewNATSReader returns a reader that consumes packets from the subject in the JetStream stream with the durable consumer.
+// Messages that fail to be processed are redelivered after DeadLetterTTL and moved to the parking stream after maxRetries.
+// Messages with high priority published to subject + schema.NATSPrioritySuffix are consumed by another consumer so as not to wait behind the others.
+func NewNATSReader(
+	ctx context.Context,
+	stream, subject, durable string,
+	host, username, password string,
+	sslCert, sslKey, sslRootCert string,
+	maxRetries int64,
+) (service.QueueReader, error) {
+	r := &natsReader{
+		ch:         make(chan service.Packet, QueueSize),
+		closed:     make(chan struct{}),
+		messages:   map[uint64]jetstream.Msg{},
+		Stream:     stream,
+		Subject:    subject,
+		Durable:    durable,
+		MaxRetries: maxRetries,
+	}
+
+	return r, r.connect(ctx, host, NATSOptions(username, password, sslCert, sslKey, sslRootCert)...)
+}
+
+// NATSOptions returns the options to connect to NATS, which reconnects by itself as long as the connection is open.
+func NATSOptions(username, password, sslCert, sslKey, sslRootCert string) []nats.Option {
+	options := []nats.Option{
+		nats.Timeout(ConnectionTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(ReconnectInitial),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			slog.Info("Disconnected from MQ", slog.Any("err", err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			slog.Info("Reconnected to MQ", slog.String("remote", nc.ConnectedUrlRedacted()))
+		}),
+	}
+
+	if username != "" || password != "" {
+		options = append(options, nats.UserInfo(username, password))
+	}
+	if sslCert != "" && sslKey != "" {
+		options = append(options, nats.ClientCert(sslCert, sslKey))
+	}
+	if sslRootCert != "" {
+		options = append(options, nats.RootCAs(sslRootCert))
+	}
+	return options
+}
+
+// DecodeNATSMsg decodes the message according to the headers set by the supplier.
+func DecodeNATSMsg(header nats.Header, data []byte, tag uint64) (service.Packet, error) {
+	var timestamp time.Time
+	if v := header.Get(TimestampHeader); v != "" {
+		var err error
+		timestamp, err = time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+	}
+
+	version := schema.Version(map[string]any{
+		schema.SchemaVersionHeader: header.Get(schema.SchemaVersionHeader),
+	})
+	return DecodePacket(header.Get(TypeHeader), version, tag, timestamp, data)
+}
+
+func (r *natsReader) connect(ctx context.Context, host string, options ...nats.Option) error {
+	slog.Debug("Connecting to MQ broker...")
+
+	options = append(options,
+		nats.DrainTimeout(NATSDrainTimeout),
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			close(r.closed)
+		}),
+	)
+
+	var err error
+	r.Connection, err = nats.Connect(host, options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQ broker: %w", err)
+	}
+
+	r.JetStream, err = jetstream.New(r.Connection)
+	if err != nil {
+		return fmt.Errorf("failed to initialize JetStream: %w", err)
+	}
+
+	slog.Debug("Declaring streams in MQ...")
+
+	_, err = r.JetStream.CreateOrUpdateStream(ctx, schema.NATSStream(r.Stream, r.Subject))
+	if err != nil {
+		return fmt.Errorf("failed to declare stream in MQ: %w", err)
+	}
+
+	_, err = r.JetStream.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     r.Stream + "-parked",
+		Subjects: []string{r.Subject + ParkingSuffix},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare stream for parked messages in MQ: %w", err)
+	}
+
+	slog.Debug("Declaring consumers in MQ...")
+
+	for durable, subject := range map[string]string{
+		r.Durable:               r.Subject,
+		r.Durable + "-priority": r.Subject + schema.NATSPrioritySuffix,
+	} {
+		consumer, err := r.JetStream.CreateOrUpdateConsumer(ctx, r.Stream, jetstream.ConsumerConfig{
+			Durable:       durable,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       NATSAckWait,
+			FilterSubject: subject,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to declare consumer in MQ: %w", err)
+		}
+		r.Consumers = append(r.Consumers, consumer)
+	}
+
+	slog.Info("Connected to MQ", slog.String("remote", r.Connection.ConnectedUrlRedacted()))
+	return nil
+}
+
+func (r *natsReader) track(tag uint64, msg jetstream.Msg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages[tag] = msg
+}
+
+// progress reports the messages not acknowledged yet to be in progress so as not to be redelivered
+// while they are waiting or being processed, until ctx is done.
+func (r *natsReader) progress(ctx context.Context) {
+	ticker := time.NewTicker(NATSProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		messages := slices.Collect(maps.Values(r.messages))
+		r.mu.Unlock()
+
+		for _, msg := range messages {
+			err := msg.InProgress()
+			if err != nil {
+				slog.Warn("Failed to report message in progress", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+func (r *natsReader) untrack(tag uint64) (jetstream.Msg, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	msg, ok := r.messages[tag]
+	delete(r.messages, tag)
+	return msg, ok
+}
+
+// park moves the message to the parking stream with the given headers added.
+func (r *natsReader) park(msg jetstream.Msg, header nats.Header) error {
+	m := nats.NewMsg(r.Subject + ParkingSuffix)
+	for k, v := range msg.Headers() {
+		if k == jetstream.MsgIDHeader {
+			continue
+		}
+		m.Header[k] = v
+	}
+	for k, v := range header {
+		m.Header[k] = v
+	}
+	m.Data = msg.Data()
+
+	_, err := r.JetStream.PublishMsg(context.Background(), m)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to parking stream: %w", err)
+	}
+
+	ParkedMessageTotal.WithLabelValues(msg.Headers().Get(TypeHeader)).Inc()
+	return nil
+}
+
+// reject moves the message that cannot be decoded to the parking stream, as retrying never succeeds.
+func (r *natsReader) reject(msg jetstream.Msg, decodeErr error) error {
+	typ := msg.Headers().Get(TypeHeader)
+	DeliveredMessageErrorTotal.WithLabelValues(typ).Inc()
+	slog.Error("Failed to decode message", slog.String("packet-type", typ), slog.Any("err", decodeErr))
+
+	err := r.park(msg, nats.Header{DecodeErrorHeader: []string{decodeErr.Error()}})
+	if err != nil {
+		return errors.Join(err, msg.NakWithDelay(DeadLetterTTL))
+	}
+	return msg.Term()
+}
+
+func (r *natsReader) Ack(tag uint64) error {
+	msg, ok := r.untrack(tag)
+	if !ok {
+		return fmt.Errorf("unknown tag: %d", tag)
+	}
+	return msg.Ack()
+}
+
+func (r *natsReader) Reject(tag uint64) error {
+	msg, ok := r.untrack(tag)
+	if !ok {
+		return fmt.Errorf("unknown tag: %d", tag)
+	}
+
+	// Unlike x-death in RabbitMQ, the number of deliveries includes the first one.
+	metadata, err := msg.Metadata()
+	if err != nil || int64(metadata.NumDelivered) <= r.MaxRetries {
+		return msg.NakWithDelay(DeadLetterTTL)
+	}
+
+	err = r.park(msg, nil)
+	if err != nil {
+		return errors.Join(err, msg.NakWithDelay(DeadLetterTTL))
+	}
+
+	slog.Warn("Parked message after exceeding retry limit", slog.String("packet-type", msg.Headers().Get(TypeHeader)), slog.Int64("max-retries", r.MaxRetries))
+	return msg.Term()
+}
+
+func (r *natsReader) Packets() <-chan service.Packet {
+	return r.ch
+}
+
+// Close drains the connection and waits until it is closed, as draining continues in the background.
+func (r *natsReader) Close() error {
+	err := r.Connection.Drain()
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return fmt.Errorf("failed to close the MQ connection: %w", err)
+	}
+
+	select {
+	case <-r.closed:
+		return nil
+	case <-time.After(NATSDrainTimeout + ConnectionTimeout):
+		r.Connection.Close()
+		return errors.New("failed to close the MQ connection: timed out draining")
+	}
+}
+
+func (r *natsReader) Consume(ctx context.Context) {
+	defer close(r.ch)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		r.progress(ctx)
+	})
+	for _, consumer := range r.Consumers {
+		wg.Go(func() {
+			r.consume(ctx, consumer)
+		})
+	}
+	wg.Wait()
+}
+
+func (r *natsReader) consume(ctx context.Context, consumer jetstream.Consumer) {
+	messages, err := consumer.Messages()
+	if err != nil {
+		slog.Error("Failed to consume from MQ", slog.Any("err", err))
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		messages.Stop()
+	}()
+
+	for {
+		msg, err := messages.Next()
+		if errors.Is(err, jetstream.ErrMsgIteratorClosed) || r.Connection.IsClosed() {
+			return
+		}
+		if err != nil {
+			slog.Error("Error from MQ", slog.Any("err", err))
+			continue
+		}
+
+		typ := msg.Headers().Get(TypeHeader)
+		DeliveredMessageTotal.WithLabelValues(typ).Inc()
+
+		tag := r.tag.Add(1)
+		decoded, err := DecodeNATSMsg(msg.Headers(), msg.Data(), tag)
+		if err != nil {
+			err := r.reject(msg, err)
+			if err != nil {
+				slog.Error("Failed to reject message", slog.String("packet-type", typ), slog.Any("err", err))
+			}
+			continue
+		}
+
+		r.track(tag, msg)
+		r.ch <- decoded
+	}
+}
@@ -0,0 +1,58 @@
+package queue_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeNATSMsg()", func() {
+	var (
+		header nats.Header
+	)
+
+	BeforeEach(func() {
+		header = nats.Header{}
+		header.Set("Type", "packets.deletion")
+		header.Set("Timestamp", "2024-01-02T15:04:05Z")
+		header.Set("schema_version", "2")
+	})
+
+	Context("headers are valid", func() {
+		It("returns a packet", func() {
+			actual, err := queue.DecodeNATSMsg(header, []byte(`{"id":"100","deleted_at":"2024-01-02T15:04:05Z"}`), 1)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := service.NewDeletion(1, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+			expected.ID = "100"
+			expected.DeletedAt = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	Context("timestamp is invalid", func() {
+		BeforeEach(func() {
+			header.Set("Timestamp", "yesterday")
+		})
+
+		It("returns an error", func() {
+			_, err := queue.DecodeNATSMsg(header, []byte(`{}`), 1)
+			Expect(err).To(MatchError(HavePrefix("failed to parse timestamp:")))
+		})
+	})
+
+	Context("schema version is newer", func() {
+		BeforeEach(func() {
+			header.Set("schema_version", "3")
+		})
+
+		It("returns an error", func() {
+			_, err := queue.DecodeNATSMsg(header, []byte(`{}`), 1)
+			Expect(err).To(MatchError("unsupported schema version: 3"))
+		})
+	})
+})
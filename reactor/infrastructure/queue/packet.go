@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+)
+
+// DecodePacket decodes the body of a delivery according to its type and schema version.
+// Older versions are accepted so that packets published before a rolling upgrade are still processed.
+func DecodePacket(typ string, version int, tag uint64, timestamp time.Time, body []byte) (service.Packet, error) {
+	if version > packet.SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version: %d", version)
+	}
+
+	switch typ {
+	case packet.TypeTick:
+		var p packet.Tick
+		err := json.Unmarshal(body, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		tick := service.NewTick(tag, timestamp)
+		tick.Year = p.Year
+		tick.Month = p.Month
+		tick.Day = p.Day
+		return tick, nil
+
+	case packet.TypeMessage:
+		var p packet.Message
+		err := json.Unmarshal(body, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		message := service.NewMessage(tag, timestamp)
+		message.ID = p.ID
+		message.Account = decodeAccount(p.Account)
+		message.CreatedAt = p.CreatedAt
+		message.EditedAt = p.EditedAt
+		message.Content = p.Content
+		message.Emojis = packet.Convert(p.Emojis, func(emoji packet.Emoji) service.Emoji {
+			return service.Emoji{Shortcode: emoji.Shortcode}
+		})
+		message.InReplyToID = p.InReplyToID
+		message.IsReblog = p.IsReblog
+		message.Mentions = packet.Convert(p.Mentions, func(mention packet.Mention) service.Mention {
+			return service.Mention{
+				ID:       mention.ID,
+				Acct:     mention.Acct,
+				Username: mention.Username,
+			}
+		})
+		message.Tags = packet.Convert(p.Tags, func(tag packet.Tag) service.Tag {
+			return service.Tag{Name: tag.Name}
+		})
+		message.Visibility = p.Visibility
+		return message, nil
+
+	case packet.TypeNotification:
+		var p packet.Notification
+		err := json.Unmarshal(body, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		notification := service.NewNotification(tag, timestamp)
+		notification.ID = p.ID
+		notification.Type = p.Type
+		notification.Account = decodeAccount(p.Account)
+		notification.CreatedAt = p.CreatedAt
+		notification.StatusID = p.StatusID
+		return notification, nil
+
+	case packet.TypeDeletion:
+		var p packet.Deletion
+		err := json.Unmarshal(body, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		deletion := service.NewDeletion(tag, timestamp)
+		deletion.ID = p.ID
+		deletion.DeletedAt = p.DeletedAt
+		return deletion, nil
+
+	default:
+		return nil, fmt.Errorf("unknown packet type: %q", typ)
+	}
+}
+
+func decodeAccount(account packet.Account) service.Account {
+	return service.Account{
+		ID:          account.ID,
+		Acct:        account.Acct,
+		DisplayName: account.DisplayName,
+		Username:    account.Username,
+	}
+}
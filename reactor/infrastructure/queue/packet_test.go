@@ -0,0 +1,97 @@
+package queue_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodePacket()", func() {
+	timestamp := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	Context("tick is given", func() {
+		It("returns a tick", func() {
+			actual, err := queue.DecodePacket("packets.tick", 2, 1, timestamp, []byte(`{"year":2024,"month":1,"day":2}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := service.NewTick(1, timestamp)
+			expected.Year = 2024
+			expected.Month = 1
+			expected.Day = 2
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	Context("deletion is given", func() {
+		It("returns a deletion", func() {
+			actual, err := queue.DecodePacket("packets.deletion", 2, 1, timestamp, []byte(`{"id":"100","deleted_at":"2024-01-02T15:04:05Z"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := service.NewDeletion(1, timestamp)
+			expected.ID = "100"
+			expected.DeletedAt = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	Context("message of version 1 is given", func() {
+		It("returns a message with the username", func() {
+			actual, err := queue.DecodePacket("packets.message", 1, 1, timestamp, []byte(`{"id":"100","account":{"id":"1","acct":"test","display_name":"テスト","user_name":"test"},"content":"ぴゅっ♡"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := service.NewMessage(1, timestamp)
+			expected.ID = "100"
+			expected.Account = service.Account{
+				ID:          "1",
+				Acct:        "test",
+				DisplayName: "テスト",
+				Username:    "test",
+			}
+			expected.Content = "ぴゅっ♡"
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	Context("notification of version 2 is given", func() {
+		It("returns a notification with the username", func() {
+			actual, err := queue.DecodePacket("packets.notification", 2, 1, timestamp, []byte(`{"id":"200","type":"follow","account":{"id":"1","acct":"test","display_name":"テスト","username":"test"},"created_at":"2024-01-02T15:04:05Z"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := service.NewNotification(1, timestamp)
+			expected.ID = "200"
+			expected.Type = "follow"
+			expected.Account = service.Account{
+				ID:          "1",
+				Acct:        "test",
+				DisplayName: "テスト",
+				Username:    "test",
+			}
+			expected.CreatedAt = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+			Expect(actual).To(Equal(expected))
+		})
+	})
+
+	Context("newer version is given", func() {
+		It("returns an error", func() {
+			_, err := queue.DecodePacket("packets.tick", 3, 1, timestamp, []byte(`{"year":2024,"month":1,"day":2}`))
+			Expect(err).To(MatchError("unsupported schema version: 3"))
+		})
+	})
+
+	Context("malformed body is given", func() {
+		It("returns an error", func() {
+			_, err := queue.DecodePacket("packets.message", 2, 1, timestamp, []byte(`{"id":`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("unknown type is given", func() {
+		It("returns an error", func() {
+			_, err := queue.DecodePacket("packets.unknown", 2, 1, timestamp, []byte(`{}`))
+			Expect(err).To(MatchError(`unknown packet type: "packets.unknown"`))
+		})
+	})
+})
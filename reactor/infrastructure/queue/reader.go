@@ -4,14 +4,16 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
 	"os"
+	"sync"
 	"time"
 
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
 	"github.com/chitoku-k/ejaculation-counter/reactor/service"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -27,6 +29,13 @@ const (
 
 	DeadLetterSuffix = ".dl"
 	DeadLetterTTL    = 5 * time.Minute
+
+	ParkingSuffix     = ".parked"
+	DefaultMaxRetries = 5
+
+	DecodeErrorHeader = "x-decode-error"
+
+	ConsumerTag = "reactor"
 )
 
 var (
@@ -40,10 +49,17 @@ var (
 		Name:      "delivered_message_error_total",
 		Help:      "Total number of errors when delivered from message queue.",
 	}, []string{"type"})
+	ParkedMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "parked_message_total",
+		Help:      "Total number of messages moved to the parking queue after exceeding the retry limit.",
+	}, []string{"type"})
 )
 
 type reader struct {
 	ch          chan service.Packet
+	mu          sync.Mutex
+	deliveries  map[uint64]amqp.Delivery
 	Exchange    string
 	QueueName   string
 	RoutingKey  string
@@ -53,6 +69,7 @@ type reader struct {
 	SSLCert     string
 	SSLKey      string
 	SSLRootCert string
+	MaxRetries  int64
 	Connection  *amqp.Connection
 	Channel     *amqp.Channel
 	Delivery    <-chan amqp.Delivery
@@ -63,9 +80,11 @@ func NewReader(
 	exchange, queueName, routingKey string,
 	host, username, password string,
 	sslCert, sslKey, sslRootCert string,
+	maxRetries int64,
 ) (service.QueueReader, error) {
 	r := &reader{
 		ch:          make(chan service.Packet, QueueSize),
+		deliveries:  map[uint64]amqp.Delivery{},
 		Exchange:    exchange,
 		QueueName:   queueName,
 		RoutingKey:  routingKey,
@@ -75,29 +94,34 @@ func NewReader(
 		SSLCert:     sslCert,
 		SSLKey:      sslKey,
 		SSLRootCert: sslRootCert,
+		MaxRetries:  maxRetries,
 	}
 
 	return r, r.connect()
 }
 
 func (r *reader) dial(url string) (*amqp.Connection, net.Conn, error) {
+	return dial(url, r.Username, r.Password, r.SSLCert, r.SSLKey, r.SSLRootCert)
+}
+
+func dial(url, username, password, sslCert, sslKey, sslRootCert string) (*amqp.Connection, net.Conn, error) {
 	tlsConfig := &tls.Config{}
 
 	var sasl []amqp.Authentication
-	if r.Username == "" && r.Password == "" {
+	if username == "" && password == "" {
 		sasl = append(sasl, &amqp.ExternalAuth{})
 	}
 
-	if r.SSLCert != "" && r.SSLKey != "" {
-		cert, err := tls.LoadX509KeyPair(r.SSLCert, r.SSLKey)
+	if sslCert != "" && sslKey != "" {
+		cert, err := tls.LoadX509KeyPair(sslCert, sslKey)
 		if err != nil {
 			return nil, nil, err
 		}
 		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	if r.SSLRootCert != "" {
-		ca, err := os.ReadFile(r.SSLRootCert)
+	if sslRootCert != "" {
+		ca, err := os.ReadFile(sslRootCert)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to read CA file for queue: %w", err)
 		}
@@ -144,6 +168,11 @@ func (r *reader) connect() error {
 
 	r.Closes = r.Connection.NotifyClose(make(chan *amqp.Error, 1))
 
+	// Delivery tags are scoped to a channel.
+	r.mu.Lock()
+	clear(r.deliveries)
+	r.mu.Unlock()
+
 	slog.Debug("Declaring queues in MQ...")
 
 	q, err := r.Channel.QueueDeclare(
@@ -162,23 +191,37 @@ func (r *reader) connect() error {
 		return fmt.Errorf("failed to declare queue in MQ channel: %w", err)
 	}
 
-	dlq, err := r.Channel.QueueDeclare(
+	// The dead-letter and parking queues are created with no delivery limit, as browsing them from the admin API
+	// increments the delivery count of the messages every time, which must not make them dropped.
+	dlq, err := r.declareQueue(
 		r.QueueName+DeadLetterSuffix,
-		true,
-		false,
-		false,
-		false,
 		amqp.Table{
 			"x-queue-type":              "quorum",
 			"x-dead-letter-exchange":    r.Exchange,
 			"x-dead-letter-routing-key": r.RoutingKey,
 			"x-message-ttl":             DeadLetterTTL.Milliseconds(),
 		},
+		amqp.Table{
+			"x-delivery-limit": -1,
+		},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to declare queue for dead letters in MQ channel: %w", err)
 	}
 
+	_, err = r.declareQueue(
+		r.QueueName+ParkingSuffix,
+		amqp.Table{
+			"x-queue-type": "quorum",
+		},
+		amqp.Table{
+			"x-delivery-limit": -1,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue for parked messages in MQ channel: %w", err)
+	}
+
 	slog.Debug("Binding queues in MQ...")
 
 	err = r.Channel.QueueBind(
@@ -203,11 +246,17 @@ func (r *reader) connect() error {
 		return fmt.Errorf("failed to bind queue for dead letters in MQ channel: %w", err)
 	}
 
+	// Keep the rest of messages in the queue so that the broker delivers ones with high priority first.
+	err = r.Channel.Qos(QueueSize, 0, false)
+	if err != nil {
+		return fmt.Errorf("failed to set prefetch count for MQ channel: %w", err)
+	}
+
 	slog.Debug("Consuming from MQ...")
 
 	r.Delivery, err = r.Channel.Consume(
 		q.Name,
-		"",
+		ConsumerTag,
 		false,
 		false,
 		false,
@@ -222,6 +271,30 @@ func (r *reader) connect() error {
 	return nil
 }
 
+// declareQueue declares the durable queue with args, along with fresh only if the queue does not exist yet,
+// as redeclaring an existing queue with different arguments fails and closes the channel.
+func (r *reader) declareQueue(name string, args, fresh amqp.Table) (amqp.Queue, error) {
+	// A passive declaration of a missing queue closes the channel, so it is made on another one.
+	ch, err := r.Connection.Channel()
+	if err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to open a channel for MQ connection: %w", err)
+	}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	var amqpErr *amqp.Error
+	_, err = ch.QueueDeclarePassive(name, true, false, false, false, nil)
+	if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+		args = maps.Clone(args)
+		maps.Copy(args, fresh)
+	} else if err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to inspect queue: %w", err)
+	}
+
+	return r.Channel.QueueDeclare(name, true, false, false, false, args)
+}
+
 func (r *reader) disconnect() error {
 	err := r.Channel.Close()
 	if err != nil {
@@ -270,23 +343,120 @@ func (r *reader) reconnect(ctx context.Context) error {
 	}
 }
 
+// DeathCount returns how many times the delivery has been dead-lettered from the queue.
+func DeathCount(headers amqp.Table, queue string) int64 {
+	deaths, ok := headers["x-death"].([]any)
+	if !ok {
+		return 0
+	}
+
+	var count int64
+	for _, v := range deaths {
+		death, ok := v.(amqp.Table)
+		if !ok || death["queue"] != queue {
+			continue
+		}
+		if n, ok := death["count"].(int64); ok {
+			count += n
+		}
+	}
+	return count
+}
+
+func (r *reader) track(delivery amqp.Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries[delivery.DeliveryTag] = delivery
+}
+
+func (r *reader) untrack(tag uint64) (amqp.Delivery, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery, ok := r.deliveries[tag]
+	delete(r.deliveries, tag)
+	return delivery, ok
+}
+
+// park moves the delivery to the parking queue of the given queue with the given headers added.
+func park(ch Channel, queueName string, delivery amqp.Delivery, headers amqp.Table) error {
+	h := maps.Clone(delivery.Headers)
+	if h == nil {
+		h = amqp.Table{}
+	}
+	maps.Copy(h, headers)
+
+	err := ch.Publish(
+		"",
+		queueName+ParkingSuffix,
+		false,
+		false,
+		amqp.Publishing{
+			Headers:      h,
+			ContentType:  delivery.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    delivery.Timestamp,
+			Type:         delivery.Type,
+			Body:         delivery.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to parking queue: %w", err)
+	}
+
+	ParkedMessageTotal.WithLabelValues(delivery.Type).Inc()
+	return nil
+}
+
+// Receive decodes the delivery, or moves it to the parking queue of the given queue if it cannot be decoded,
+// as retrying never succeeds. It reports whether the delivery has been decoded and is to be processed.
+func Receive(ch Channel, queueName string, delivery amqp.Delivery) (service.Packet, bool) {
+	decoded, err := DecodePacket(delivery.Type, schema.Version(delivery.Headers), delivery.DeliveryTag, delivery.Timestamp, delivery.Body)
+	if err == nil {
+		return decoded, true
+	}
+
+	DeliveredMessageErrorTotal.WithLabelValues(delivery.Type).Inc()
+	slog.Error("Failed to decode message", slog.String("packet-type", delivery.Type), slog.Any("err", err))
+
+	err = park(ch, queueName, delivery, amqp.Table{DecodeErrorHeader: err.Error()})
+	if err != nil {
+		err = errors.Join(err, ch.Reject(delivery.DeliveryTag, false))
+	} else {
+		err = ch.Ack(delivery.DeliveryTag, false)
+	}
+	if err != nil {
+		slog.Error("Failed to reject message", slog.String("packet-type", delivery.Type), slog.Any("err", err))
+	}
+	return nil, false
+}
+
 func (r *reader) Ack(tag uint64) error {
+	r.untrack(tag)
 	return r.Channel.Ack(tag, false)
 }
 
 func (r *reader) Reject(tag uint64) error {
-	return r.Channel.Reject(tag, false)
+	delivery, ok := r.untrack(tag)
+	if !ok || DeathCount(delivery.Headers, r.QueueName) < r.MaxRetries {
+		return r.Channel.Reject(tag, false)
+	}
+
+	err := park(r.Channel, r.QueueName, delivery, nil)
+	if err != nil {
+		return errors.Join(err, r.Channel.Reject(tag, false))
+	}
+
+	slog.Warn("Parked message after exceeding retry limit", slog.String("packet-type", delivery.Type), slog.Int64("max-retries", r.MaxRetries))
+	return r.Channel.Ack(tag, false)
 }
 
 func (r *reader) Packets() <-chan service.Packet {
 	return r.ch
 }
 
-func (r *reader) Close(exit bool) error {
-	if exit {
-		close(r.ch)
-		r.ch = nil
-	}
+func (r *reader) Close() error {
 	err := r.disconnect()
 	if !errors.Is(err, amqp.ErrClosed) {
 		return err
@@ -295,14 +465,31 @@ func (r *reader) Close(exit bool) error {
 }
 
 func (r *reader) Consume(ctx context.Context) {
+	defer close(r.ch)
+
+	done := ctx.Done()
 	for r.Closes != nil && r.Delivery != nil {
 		select {
+		case <-done:
+			done = nil
+
+			// Stop deliveries while keeping the channel open to acknowledge the packets in flight.
+			// The deliveries that have been received are still processed until the channel is closed.
+			err := r.Channel.Cancel(ConsumerTag, false)
+			if err != nil {
+				slog.Error("Failed to stop consuming from MQ", slog.Any("err", err))
+				return
+			}
+
 		case amqperr, ok := <-r.Closes:
 			if !ok {
 				r.Closes = nil
 				continue
 			}
 			slog.Info("Disconnected from MQ", slog.Any("err", amqperr))
+			if ctx.Err() != nil {
+				return
+			}
 			err := r.reconnect(ctx)
 			if err != nil {
 				continue
@@ -315,25 +502,13 @@ func (r *reader) Consume(ctx context.Context) {
 			}
 			DeliveredMessageTotal.WithLabelValues(packet.Type).Inc()
 
-			switch packet.Type {
-			case "packets.tick":
-				tick := service.NewTick(packet.DeliveryTag, packet.Timestamp)
-				err := json.Unmarshal(packet.Body, &tick)
-				if err != nil {
-					slog.Error("Failed to decode message", slog.String("packet-type", packet.Type), slog.Any("err", err))
-					continue
-				}
-				r.ch <- tick
-
-			case "packets.message":
-				message := service.NewMessage(packet.DeliveryTag, packet.Timestamp)
-				err := json.Unmarshal(packet.Body, &message)
-				if err != nil {
-					slog.Error("Failed to decode message", slog.String("packet-type", packet.Type), slog.Any("err", err))
-					continue
-				}
-				r.ch <- message
+			decoded, ok := Receive(r.Channel, r.QueueName, packet)
+			if !ok {
+				continue
 			}
+
+			r.track(packet)
+			r.ch <- decoded
 		}
 	}
 }
@@ -0,0 +1,160 @@
+package queue_test
+
+import (
+	"errors"
+	"time"
+
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/reactor/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("DeathCount()", func() {
+	Context("x-death is missing", func() {
+		It("returns 0", func() {
+			actual := queue.DeathCount(amqp.Table{}, "ejaculation-counter.packets.queue")
+			Expect(actual).To(Equal(int64(0)))
+		})
+	})
+
+	Context("x-death is given", func() {
+		It("returns the count for the queue", func() {
+			actual := queue.DeathCount(amqp.Table{
+				"x-death": []any{
+					amqp.Table{
+						"count":        int64(3),
+						"exchange":     "ejaculation-counter.packets",
+						"queue":        "ejaculation-counter.packets.queue",
+						"reason":       "rejected",
+						"routing-keys": []any{"packets"},
+						"time":         time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+					},
+					amqp.Table{
+						"count":        int64(3),
+						"exchange":     "ejaculation-counter.packets.dl",
+						"queue":        "ejaculation-counter.packets.queue.dl",
+						"reason":       "expired",
+						"routing-keys": []any{"packets"},
+						"time":         time.Date(2024, 1, 2, 15, 9, 5, 0, time.UTC),
+					},
+				},
+			}, "ejaculation-counter.packets.queue")
+			Expect(actual).To(Equal(int64(3)))
+		})
+	})
+})
+
+var _ = Describe("Receive()", func() {
+	var (
+		ctrl      *gomock.Controller
+		ch        *queue.MockChannel
+		timestamp time.Time
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		ch = queue.NewMockChannel(ctrl)
+		timestamp = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("delivery is decodable", func() {
+		It("returns a packet without settling the delivery", func() {
+			expected := service.NewTick(1, timestamp)
+			expected.Year = 2024
+			expected.Month = 1
+			expected.Day = 2
+
+			actual, ok := queue.Receive(ch, "ejaculation-counter.packets.queue", amqp.Delivery{
+				Headers:     amqp.Table{schema.SchemaVersionHeader: int32(schema.SchemaVersion)},
+				DeliveryTag: 1,
+				Timestamp:   timestamp,
+				Type:        schema.TypeTick,
+				Body:        []byte(`{"year":2024,"month":1,"day":2}`),
+			})
+			Expect(actual).To(Equal(expected))
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Context("body is undecodable", func() {
+		It("parks the delivery with the error and acknowledges it", func() {
+			gomock.InOrder(
+				ch.EXPECT().Publish("", "ejaculation-counter.packets.queue.parked", false, false, amqp.Publishing{
+					Headers: amqp.Table{
+						schema.SchemaVersionHeader: int32(schema.SchemaVersion),
+						queue.DecodeErrorHeader:    "unexpected end of JSON input",
+					},
+					ContentType:  "application/json",
+					DeliveryMode: amqp.Persistent,
+					Timestamp:    timestamp,
+					Type:         schema.TypeTick,
+					Body:         []byte(`{"year":`),
+				}).Return(nil),
+				ch.EXPECT().Ack(uint64(1), false).Return(nil),
+			)
+
+			actual, ok := queue.Receive(ch, "ejaculation-counter.packets.queue", amqp.Delivery{
+				Headers:     amqp.Table{schema.SchemaVersionHeader: int32(schema.SchemaVersion)},
+				ContentType: "application/json",
+				DeliveryTag: 1,
+				Timestamp:   timestamp,
+				Type:        schema.TypeTick,
+				Body:        []byte(`{"year":`),
+			})
+			Expect(actual).To(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("type is unknown", func() {
+		It("parks the delivery with the error and acknowledges it", func() {
+			gomock.InOrder(
+				ch.EXPECT().Publish("", "ejaculation-counter.packets.queue.parked", false, false, amqp.Publishing{
+					Headers: amqp.Table{
+						queue.DecodeErrorHeader: `unknown packet type: "packets.unknown"`,
+					},
+					DeliveryMode: amqp.Persistent,
+					Timestamp:    timestamp,
+					Type:         "packets.unknown",
+					Body:         []byte(`{}`),
+				}).Return(nil),
+				ch.EXPECT().Ack(uint64(1), false).Return(nil),
+			)
+
+			actual, ok := queue.Receive(ch, "ejaculation-counter.packets.queue", amqp.Delivery{
+				DeliveryTag: 1,
+				Timestamp:   timestamp,
+				Type:        "packets.unknown",
+				Body:        []byte(`{}`),
+			})
+			Expect(actual).To(BeNil())
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("parking fails", func() {
+			It("rejects the delivery", func() {
+				gomock.InOrder(
+					ch.EXPECT().Publish("", "ejaculation-counter.packets.queue.parked", false, false, gomock.Any()).Return(errors.New("error")),
+					ch.EXPECT().Reject(uint64(1), false).Return(nil),
+				)
+
+				actual, ok := queue.Receive(ch, "ejaculation-counter.packets.queue", amqp.Delivery{
+					DeliveryTag: 1,
+					Timestamp:   timestamp,
+					Type:        "packets.unknown",
+					Body:        []byte(`{}`),
+				})
+				Expect(actual).To(BeNil())
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+})
@@ -69,11 +69,31 @@ func main() {
 		os.Exit(1)
 	}
 
-	reader, err := queue.NewReader(
-		"ejaculation-counter.packets", "ejaculation-counter.packets.queue", "packets",
-		env.Queue.Host, env.Queue.Username, env.Queue.Password,
-		env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
-	)
+	maxRetries := env.Queue.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = queue.DefaultMaxRetries
+	}
+	var reader service.QueueReader
+	switch env.Queue.Backend {
+	case "", "rabbitmq":
+		reader, err = queue.NewReader(
+			"ejaculation-counter.packets", "ejaculation-counter.packets.queue", "packets",
+			env.Queue.Host, env.Queue.Username, env.Queue.Password,
+			env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
+			maxRetries,
+		)
+	case "nats":
+		reader, err = queue.NewNATSReader(
+			ctx,
+			"ejaculation-counter-packets", "ejaculation-counter.packets", "ejaculation-counter-packets-queue",
+			env.Queue.Host, env.Queue.Username, env.Queue.Password,
+			env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
+			maxRetries,
+		)
+	default:
+		slog.Error("Unknown queue backend", slog.String("backend", env.Queue.Backend))
+		os.Exit(1)
+	}
 	if err != nil {
 		slog.Error("Failed to initialize reader", slog.Any("err", err))
 		os.Exit(1)
@@ -83,14 +103,6 @@ func main() {
 		reader.Consume(ctx)
 	})
 
-	wg.Go(func() {
-		<-ctx.Done()
-		err := reader.Close(true)
-		if err != nil {
-			slog.Error("Failed to close reader", slog.Any("err", err))
-		}
-	})
-
 	wg.Go(func() {
 		c, err := client.NewHttpClient()
 		if err != nil {
@@ -98,17 +110,54 @@ func main() {
 			os.Exit(1)
 		}
 		shindan := client.NewShindanmaker(c)
+		if env.External.ShindanmakerDefinitionsDir != "" {
+			shindan, err = client.NewLocalShindanmaker(env.External.ShindanmakerDefinitionsDir, time.Now)
+			if err != nil {
+				slog.Error("Failed to initialize ShindanMaker", slog.Any("err", err))
+				os.Exit(1)
+			}
+		}
+		mpyw := client.NewMpyw(c)
+		if env.External.BreakerThreshold > 0 {
+			shindan = client.NewBreakerShindanmaker(shindan, client.NewBreaker("shindanmaker", int(env.External.BreakerThreshold), env.External.BreakerTimeout, time.Now))
+			mpyw = client.NewBreakerMpyw(mpyw, client.NewBreaker("mpyw", int(env.External.BreakerThreshold), env.External.BreakerTimeout, time.Now))
+		}
+		if env.External.ShindanmakerCacheSize > 0 {
+			var store client.ShindanmakerStore
+			if env.External.ShindanmakerCachePersisted {
+				store = db
+			}
+			shindan = client.NewCachedShindanmaker(shindan, store, int(env.External.ShindanmakerCacheSize), time.Now)
+		}
 		through := hardcoding.NewThroughRepository()
 		doublet := hardcoding.NewDoubletRepository()
-		mpyw := client.NewMpyw(c)
 
-		mc := client.NewMastodon(env.Mastodon.ServerURL, env.Mastodon.AccessToken)
+		var notificationActions []service.NotificationAction
+		if env.Mastodon.WelcomeMessage != "" {
+			notificationActions = append(notificationActions, action.NewFollowWelcome(env.Mastodon.WelcomeMessage))
+		}
+
+		var poster service.Poster
+		switch env.Platform {
+		case "", "mastodon":
+			poster = client.NewMastodon(env.Mastodon.ServerURL, env.Mastodon.AccessToken)
+		case "misskey":
+			poster = client.NewMisskey(c, env.Mastodon.ServerURL, env.Mastodon.AccessToken)
+		case "bluesky":
+			poster = client.NewBluesky(c, env.Mastodon.ServerURL, env.Mastodon.UserID, env.Mastodon.AccessToken, time.Now)
+		default:
+			slog.Error("Unknown platform", slog.String("platform", env.Platform))
+			os.Exit(1)
+		}
+
 		ps := service.NewProcessor(
 			reader,
-			invoker.NewReply(mc),
-			invoker.NewIncrement(mc, db, env.UserID),
-			invoker.NewUpdate(mc, db, env.UserID),
-			invoker.NewAdministration(mc, db),
+			invoker.NewReply(poster, env.External.FallbackMessage, env.TTL.ApologyMessage),
+			invoker.NewIncrement(poster, db, env.UserID),
+			invoker.NewDecrement(poster, db, env.UserID, time.Now),
+			invoker.NewUpdate(poster, db, env.UserID),
+			invoker.NewAdministration(poster, db),
+			db,
 			[]service.Action{
 				action.NewOfufutonChallenge(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), env.Mastodon.UserID),
 				action.NewDB(env.Mastodon.UserID),
@@ -127,10 +176,19 @@ func main() {
 				action.NewThrough(through, env.Mastodon.UserID),
 				action.NewDoublet(doublet, env.Mastodon.UserID),
 			},
+			notificationActions,
+			env.Mastodon.DeleteReplies,
+			service.NewTTLPolicy(env.TTL.Packets, env.TTL.Actions, env.TTL.ApologyMessage != ""),
 			time.Now,
 		)
 		ps.Execute(ctx, reader.Packets())
 
+		// Close the reader after the packets in flight are acknowledged, and then the DB they use.
+		err = reader.Close()
+		if err != nil {
+			slog.Error("Failed to close reader", slog.Any("err", err))
+		}
+
 		err = db.Close()
 		if err != nil {
 			slog.Error("Failed to close connection to DB", slog.Any("err", err))
@@ -140,7 +198,17 @@ func main() {
 	wg.Go(func() {
 		through := service.NewThrough(hardcoding.NewThroughRepository())
 		doublet := service.NewDoublet(hardcoding.NewDoubletRepository())
-		engine := server.NewEngine(through, doublet, env.Port, env.TLSCert, env.TLSKey)
+		deadLetters := queue.NewDeadLetters(
+			"ejaculation-counter.packets", "ejaculation-counter.packets.queue", "packets",
+			env.Queue.Host, env.Queue.Username, env.Queue.Password,
+			env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
+		)
+		adminToken := env.AdminToken
+		if adminToken != "" && env.Queue.Backend == "nats" {
+			slog.Warn("Admin API is not supported with NATS")
+			adminToken = ""
+		}
+		engine := server.NewEngine(through, doublet, deadLetters, adminToken, env.Port, env.TLSCert, env.TLSKey)
 		err := engine.Start(ctx)
 		if err != nil {
 			slog.Error("Failed to start web server", slog.Any("err", err))
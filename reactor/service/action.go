@@ -8,12 +8,14 @@ import (
 )
 
 var (
-	ErrNoMatch = fmt.Errorf("no matches found")
+	ErrNoMatch     = fmt.Errorf("no matches found")
+	ErrUnavailable = fmt.Errorf("service unavailable")
 )
 
 type actionResult struct {
-	Event Event
-	Index int
+	Action string
+	Event  Event
+	Index  int
 }
 
 type Action interface {
@@ -21,3 +23,9 @@ type Action interface {
 	Target(message Message) bool
 	Event(ctx context.Context, message Message) (Event, int, error)
 }
+
+type NotificationAction interface {
+	Name() string
+	Target(notification Notification) bool
+	Event(ctx context.Context, notification Notification) (Event, error)
+}
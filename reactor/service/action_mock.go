@@ -83,3 +83,70 @@ func (mr *MockActionMockRecorder) Target(message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Target", reflect.TypeOf((*MockAction)(nil).Target), message)
 }
+
+// MockNotificationAction is a mock of NotificationAction interface.
+type MockNotificationAction struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationActionMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationActionMockRecorder is the mock recorder for MockNotificationAction.
+type MockNotificationActionMockRecorder struct {
+	mock *MockNotificationAction
+}
+
+// NewMockNotificationAction creates a new mock instance.
+func NewMockNotificationAction(ctrl *gomock.Controller) *MockNotificationAction {
+	mock := &MockNotificationAction{ctrl: ctrl}
+	mock.recorder = &MockNotificationActionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationAction) EXPECT() *MockNotificationActionMockRecorder {
+	return m.recorder
+}
+
+// Event mocks base method.
+func (m *MockNotificationAction) Event(ctx context.Context, notification Notification) (Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Event", ctx, notification)
+	ret0, _ := ret[0].(Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Event indicates an expected call of Event.
+func (mr *MockNotificationActionMockRecorder) Event(ctx, notification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Event", reflect.TypeOf((*MockNotificationAction)(nil).Event), ctx, notification)
+}
+
+// Name mocks base method.
+func (m *MockNotificationAction) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockNotificationActionMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockNotificationAction)(nil).Name))
+}
+
+// Target mocks base method.
+func (m *MockNotificationAction) Target(notification Notification) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Target", notification)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Target indicates an expected call of Target.
+func (mr *MockNotificationActionMockRecorder) Target(notification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Target", reflect.TypeOf((*MockNotificationAction)(nil).Target), notification)
+}
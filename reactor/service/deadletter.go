@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownQueue = errors.New("unknown queue")
+)
+
+// DeadLetter is a packet that has failed to be processed and is held in a dead-letter or parking queue.
+type DeadLetter struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Deaths    int64     `json:"deaths"`
+	Body      any       `json:"body"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type DeadLetters interface {
+	List(ctx context.Context, queue string, limit int) ([]DeadLetter, error)
+	Replay(ctx context.Context, queue string, ids []string) (int, error)
+	Purge(ctx context.Context, queue string, ids []string) (int, error)
+}
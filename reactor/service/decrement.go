@@ -0,0 +1,7 @@
+package service
+
+import "context"
+
+type Decrement interface {
+	Do(ctx context.Context, event DecrementEvent) error
+}
@@ -24,6 +24,8 @@ type ReplyErrorEvent struct {
 	Acct        string
 	ActionName  string
 	Visibility  string
+	Unavailable bool
+	Expired     bool
 }
 
 func (ReplyErrorEvent) Name() string {
@@ -50,6 +52,24 @@ func (IncrementEvent) Name() string {
 	return "events.increment"
 }
 
+type DecrementEvent struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+func (DecrementEvent) Name() string {
+	return "events.decrement"
+}
+
+type DeleteReplyEvent struct {
+	ID string
+}
+
+func (DeleteReplyEvent) Name() string {
+	return "events.delete_reply"
+}
+
 type AdministrationEvent struct {
 	InReplyToID string
 	Acct        string
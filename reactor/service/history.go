@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// HistoryRetention is how long the history is kept to revert the events when the status is edited or deleted.
+const HistoryRetention = 30 * 24 * time.Hour
+
+type HistoryRecord struct {
+	ID       int64
+	StatusID string
+	Action   string
+	Event    string
+	ReplyID  string
+	Date     time.Time
+}
+
+type History interface {
+	FindHistory(ctx context.Context, statusID string) ([]HistoryRecord, error)
+	SaveHistory(ctx context.Context, record HistoryRecord) error
+	DeleteHistory(ctx context.Context, id int64) error
+	PruneHistory(ctx context.Context, before time.Time) error
+}
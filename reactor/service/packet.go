@@ -5,6 +5,7 @@ import (
 )
 
 type Packet interface {
+	Name() string
 	Tag() uint64
 	Timestamp() time.Time
 }
@@ -51,10 +52,12 @@ type Message struct {
 	ID          string    `json:"id"`
 	Account     Account   `json:"account"`
 	CreatedAt   time.Time `json:"created_at"`
+	EditedAt    time.Time `json:"edited_at"`
 	Content     string    `json:"content"`
 	Emojis      []Emoji   `json:"emojis"`
 	InReplyToID string    `json:"in_reply_to_id"`
 	IsReblog    bool      `json:"is_reblog"`
+	Mentions    []Mention `json:"mentions"`
 	Tags        []Tag     `json:"tags"`
 	Visibility  string    `json:"visibility"`
 }
@@ -63,7 +66,13 @@ type Account struct {
 	ID          string `json:"id"`
 	Acct        string `json:"acct"`
 	DisplayName string `json:"display_name"`
-	Username    string `json:"user_name"`
+	Username    string `json:"username"`
+}
+
+type Mention struct {
+	ID       string `json:"id"`
+	Acct     string `json:"acct"`
+	Username string `json:"username"`
 }
 
 type Emoji struct {
@@ -85,3 +94,60 @@ func (m Message) Tag() uint64 {
 func (m Message) Timestamp() time.Time {
 	return m.timestamp
 }
+
+func NewNotification(tag uint64, timestamp time.Time) Notification {
+	return Notification{
+		tag:       tag,
+		timestamp: timestamp,
+	}
+}
+
+type Notification struct {
+	tag       uint64
+	timestamp time.Time
+
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Account   Account   `json:"account"`
+	CreatedAt time.Time `json:"created_at"`
+	StatusID  string    `json:"status_id"`
+}
+
+func (n Notification) Name() string {
+	return "packets.notification"
+}
+
+func (n Notification) Tag() uint64 {
+	return n.tag
+}
+
+func (n Notification) Timestamp() time.Time {
+	return n.timestamp
+}
+
+func NewDeletion(tag uint64, timestamp time.Time) Deletion {
+	return Deletion{
+		tag:       tag,
+		timestamp: timestamp,
+	}
+}
+
+type Deletion struct {
+	tag       uint64
+	timestamp time.Time
+
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (d Deletion) Name() string {
+	return "packets.deletion"
+}
+
+func (d Deletion) Tag() uint64 {
+	return d.tag
+}
+
+func (d Deletion) Timestamp() time.Time {
+	return d.timestamp
+}
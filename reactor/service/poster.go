@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"io"
+)
+
+type Post struct {
+	InReplyToID string
+	Status      string
+	Visibility  string
+	MediaIDs    []string
+}
+
+type Profile struct {
+	ID          string
+	DisplayName string
+}
+
+type Poster interface {
+	Post(ctx context.Context, post Post) (string, error)
+	Delete(ctx context.Context, id string) error
+	Profile(ctx context.Context) (Profile, error)
+	UpdateProfile(ctx context.Context, displayName string) (Profile, error)
+	UploadMedia(ctx context.Context, r io.Reader) (string, error)
+
+	// MaxLength returns the maximum number of graphemes in a post on the platform.
+	MaxLength() int
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,6 +19,9 @@ const (
 )
 
 var (
+	// DrainTimeout is how long the packets in flight are waited for after consuming stops.
+	DrainTimeout = 20 * time.Second
+
 	EventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "ejaculation_counter",
 		Name:      "events_total",
@@ -31,13 +35,18 @@ var (
 )
 
 type processor struct {
-	Queue          QueueReader
-	Reply          Reply
-	Increment      Increment
-	Update         Update
-	Administration Administration
-	Actions        []Action
-	Clock          func() time.Time
+	Queue               QueueReader
+	Reply               Reply
+	Increment           Increment
+	Decrement           Decrement
+	Update              Update
+	Administration      Administration
+	History             History
+	Actions             []Action
+	NotificationActions []NotificationAction
+	DeleteReplies       bool
+	TTL                 TTLPolicy
+	Clock               func() time.Time
 }
 
 type Processor interface {
@@ -48,33 +57,52 @@ func NewProcessor(
 	queue QueueReader,
 	reply Reply,
 	increment Increment,
+	decrement Decrement,
 	update Update,
 	administration Administration,
+	history History,
 	actions []Action,
+	notificationActions []NotificationAction,
+	deleteReplies bool,
+	ttl TTLPolicy,
 	clock func() time.Time,
 ) Processor {
 	return &processor{
-		Queue:          queue,
-		Reply:          reply,
-		Increment:      increment,
-		Update:         update,
-		Administration: administration,
-		Actions:        actions,
-		Clock:          clock,
+		Queue:               queue,
+		Reply:               reply,
+		Increment:           increment,
+		Decrement:           decrement,
+		Update:              update,
+		Administration:      administration,
+		History:             history,
+		Actions:             actions,
+		NotificationActions: notificationActions,
+		DeleteReplies:       deleteReplies,
+		TTL:                 ttl,
+		Clock:               clock,
 	}
 }
 
 func (ps *processor) Execute(ctx context.Context, packets <-chan Packet) {
+	// Keep processing the packets in flight after ctx is done until they finish or DrainTimeout passes.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	var wg sync.WaitGroup
+	defer ps.drain(&wg, cancel)
+
 	for packet := range packets {
-		if ps.Clock().Sub(packet.Timestamp()) > PacketTTL {
-			slog.Warn("A message has been discarded as it is too old", slog.Any("message-timestamp", packet.Timestamp()))
+		// Messages are expired for each action so that some of them can be processed regardless of the age.
+		if _, ok := packet.(Message); !ok && ps.TTL.Expired(packet, "", ps.Clock()) {
+			slog.Warn("A message has been discarded as it is too old", slog.String("packet-type", packet.Name()), slog.Any("message-timestamp", packet.Timestamp()))
+			ExpiredPacketsTotal.WithLabelValues(packet.Name(), "").Inc()
 			_ = ps.Queue.Ack(packet.Tag())
 			continue
 		}
 
 		switch p := packet.(type) {
 		case Tick:
-			go func() {
+			wg.Go(func() {
 				err := ps.Update.Do(ctx, UpdateEvent{
 					Year:  p.Year,
 					Month: p.Month,
@@ -82,25 +110,47 @@ func (ps *processor) Execute(ctx context.Context, packets <-chan Packet) {
 				})
 				if err != nil {
 					slog.Error("Failed to update", slog.Any("err", err))
+				}
+				ps.pruneHistory(ctx)
+				ps.complete(p.Tag(), err)
+			})
 
-					err := ps.Queue.Reject(p.Tag())
+		case Message:
+			wg.Go(func() {
+				var executed []string
+				if ps.History != nil && !p.EditedAt.IsZero() {
+					records, err := ps.History.FindHistory(ctx, p.ID)
 					if err != nil {
-						slog.Warn("The message could not be rejected", slog.Any("err", err))
+						slog.Error("Failed to find history", slog.Any("err", err))
+						ps.complete(p.Tag(), err)
+						return
+					}
+					for _, record := range records {
+						executed = append(executed, record.Action)
 					}
-					return
-				}
-
-				err = ps.Queue.Ack(p.Tag())
-				if err != nil {
-					slog.Warn("The message could not be acknowledged", slog.Any("err", err))
 				}
-			}()
 
-		case Message:
-			go func() {
 				var result []actionResult
 				for _, action := range ps.Actions {
-					if !action.Target(p) {
+					if slices.Contains(executed, action.Name()) || !action.Target(p) {
+						continue
+					}
+
+					if ps.TTL.Expired(p, action.Name(), ps.Clock()) {
+						slog.Warn("An action has been skipped as the message is too old", slog.String("action", action.Name()), slog.Any("message-timestamp", p.Timestamp()))
+						ExpiredPacketsTotal.WithLabelValues(p.Name(), action.Name()).Inc()
+						if ps.TTL.Apology() {
+							result = append(result, actionResult{
+								Action: action.Name(),
+								Event: ReplyErrorEvent{
+									InReplyToID: p.ID,
+									Acct:        p.Account.Acct,
+									Visibility:  p.Visibility,
+									ActionName:  action.Name(),
+									Expired:     true,
+								},
+							})
+						}
 						continue
 					}
 
@@ -109,17 +159,19 @@ func (ps *processor) Execute(ctx context.Context, packets <-chan Packet) {
 						slog.Error("Error in processing", slog.String("action", action.Name()), slog.Any("err", err))
 						EventsErrorTotal.WithLabelValues(action.Name()).Inc()
 						result = append(result, actionResult{
+							Action: action.Name(),
 							Event: ReplyErrorEvent{
 								InReplyToID: p.ID,
 								Acct:        p.Account.Acct,
 								Visibility:  p.Visibility,
 								ActionName:  action.Name(),
+								Unavailable: errors.Is(err, ErrUnavailable),
 							},
 						})
 						continue
 					}
 
-					result = append(result, actionResult{event, index})
+					result = append(result, actionResult{action.Name(), event, index})
 					EventsTotal.WithLabelValues(event.Name(), action.Name()).Inc()
 				}
 
@@ -127,35 +179,174 @@ func (ps *processor) Execute(ctx context.Context, packets <-chan Packet) {
 					return cmp.Compare(a.Index, b.Index)
 				})
 
-				err := ps.doEvents(ctx, result)
+				records, err := ps.doEvents(ctx, result)
+				ps.saveHistory(ctx, p.ID, records)
 				if err != nil {
 					slog.Error("Failed to process", slog.Any("err", err))
+				}
+				ps.complete(p.Tag(), err)
+			})
 
-					err := ps.Queue.Reject(p.Tag())
+		case Notification:
+			wg.Go(func() {
+				var result []actionResult
+				for _, action := range ps.NotificationActions {
+					if !action.Target(p) {
+						continue
+					}
+
+					event, err := action.Event(ctx, p)
 					if err != nil {
-						slog.Warn("The message could not be rejected", slog.Any("err", err))
+						slog.Error("Error in processing", slog.String("action", action.Name()), slog.Any("err", err))
+						EventsErrorTotal.WithLabelValues(action.Name()).Inc()
+						continue
 					}
+
+					result = append(result, actionResult{Action: action.Name(), Event: event})
+					EventsTotal.WithLabelValues(event.Name(), action.Name()).Inc()
+				}
+
+				_, err := ps.doEvents(ctx, result)
+				if err != nil {
+					slog.Error("Failed to process", slog.Any("err", err))
+				}
+				ps.complete(p.Tag(), err)
+			})
+
+		case Deletion:
+			wg.Go(func() {
+				if ps.History == nil {
+					ps.complete(p.Tag(), nil)
 					return
 				}
 
-				err = ps.Queue.Ack(p.Tag())
+				records, err := ps.History.FindHistory(ctx, p.ID)
 				if err != nil {
-					slog.Warn("The message could not be acknowledged", slog.Any("err", err))
+					slog.Error("Failed to find history", slog.Any("err", err))
+					ps.complete(p.Tag(), err)
+					return
 				}
-			}()
+
+				// Each history is deleted as soon as its event succeeds so that a redelivery only retries the rest.
+				var errs error
+				for _, record := range records {
+					var result []actionResult
+					switch record.Event {
+					case IncrementEvent{}.Name():
+						result = append(result, actionResult{
+							Action: record.Action,
+							Event: DecrementEvent{
+								Year:  record.Date.Year(),
+								Month: int(record.Date.Month()),
+								Day:   record.Date.Day(),
+							},
+						})
+
+					case ReplyEvent{}.Name():
+						if ps.DeleteReplies && record.ReplyID != "" {
+							result = append(result, actionResult{
+								Action: record.Action,
+								Event: DeleteReplyEvent{
+									ID: record.ReplyID,
+								},
+							})
+						}
+					}
+
+					_, err := ps.doEvents(ctx, result)
+					if err != nil {
+						errs = errors.Join(errs, err)
+						continue
+					}
+
+					err = ps.History.DeleteHistory(ctx, record.ID)
+					if err != nil {
+						errs = errors.Join(errs, err)
+					}
+				}
+				if errs != nil {
+					slog.Error("Failed to process", slog.Any("err", errs))
+				}
+				ps.complete(p.Tag(), errs)
+			})
+		}
+	}
+}
+
+// drain waits for the packets in flight, which are cancelled if they do not finish in DrainTimeout.
+func (ps *processor) drain(wg *sync.WaitGroup, cancel context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(DrainTimeout):
+		slog.Warn("Cancelling the packets in flight as they did not finish in time", slog.Duration("timeout", DrainTimeout))
+		cancel()
+		<-done
+	}
+}
+
+func (ps *processor) complete(tag uint64, err error) {
+	if err != nil {
+		err := ps.Queue.Reject(tag)
+		if err != nil {
+			slog.Warn("The message could not be rejected", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = ps.Queue.Ack(tag)
+	if err != nil {
+		slog.Warn("The message could not be acknowledged", slog.Any("err", err))
+	}
+}
+
+func (ps *processor) saveHistory(ctx context.Context, statusID string, records []HistoryRecord) {
+	if ps.History == nil {
+		return
+	}
+
+	for _, record := range records {
+		record.StatusID = statusID
+		err := ps.History.SaveHistory(ctx, record)
+		if err != nil {
+			slog.Warn("Failed to save history", slog.Any("err", err))
 		}
 	}
 }
 
-func (ps *processor) doEvents(ctx context.Context, result []actionResult) error {
+// pruneHistory deletes the history older than HistoryRetention, which is no longer needed to revert the events.
+func (ps *processor) pruneHistory(ctx context.Context) {
+	if ps.History == nil {
+		return
+	}
+
+	err := ps.History.PruneHistory(ctx, ps.Clock().Add(-HistoryRetention))
+	if err != nil {
+		slog.Warn("Failed to prune history", slog.Any("err", err))
+	}
+}
+
+func (ps *processor) doEvents(ctx context.Context, result []actionResult) ([]HistoryRecord, error) {
+	var records []HistoryRecord
 	var errs error
 	for _, r := range result {
 		switch event := r.Event.(type) {
 		case ReplyEvent:
-			err := ps.Reply.Send(ctx, event)
+			id, err := ps.Reply.Send(ctx, event)
 			if err != nil {
 				errs = errors.Join(errs, err)
+				continue
 			}
+			records = append(records, HistoryRecord{
+				Action:  r.Action,
+				Event:   event.Name(),
+				ReplyID: id,
+			})
 
 		case ReplyErrorEvent:
 			err := ps.Reply.SendError(ctx, event)
@@ -163,8 +354,26 @@ func (ps *processor) doEvents(ctx context.Context, result []actionResult) error
 				errs = errors.Join(errs, err)
 			}
 
+		case DeleteReplyEvent:
+			err := ps.Reply.Delete(ctx, event)
+			if err != nil {
+				errs = errors.Join(errs, err)
+			}
+
 		case IncrementEvent:
 			err := ps.Increment.Do(ctx, event)
+			if err != nil {
+				errs = errors.Join(errs, err)
+				continue
+			}
+			records = append(records, HistoryRecord{
+				Action: r.Action,
+				Event:  event.Name(),
+				Date:   time.Date(event.Year, time.Month(event.Month), event.Day, 0, 0, 0, 0, time.Local),
+			})
+
+		case DecrementEvent:
+			err := ps.Decrement.Do(ctx, event)
 			if err != nil {
 				errs = errors.Join(errs, err)
 			}
@@ -180,5 +389,5 @@ func (ps *processor) doEvents(ctx context.Context, result []actionResult) error
 		}
 	}
 
-	return errs
+	return records, errs
 }
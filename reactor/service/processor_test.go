@@ -0,0 +1,189 @@
+package service_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Processor", func() {
+	var (
+		ctrl    *gomock.Controller
+		queue   *service.MockQueueReader
+		reply   *service.MockReply
+		update  *service.MockUpdate
+		sushi   *service.MockAction
+		av      *service.MockAction
+		now     time.Time
+		message service.Message
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		queue = service.NewMockQueueReader(ctrl)
+		reply = service.NewMockReply(ctrl)
+		update = service.NewMockUpdate(ctrl)
+		sushi = service.NewMockAction(ctrl)
+		av = service.NewMockAction(ctrl)
+		now = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+		message = service.NewMessage(1, now.Add(-10*time.Minute))
+		message.ID = "100"
+		message.Account.Acct = "test"
+		message.Visibility = "public"
+
+		sushi.EXPECT().Name().Return("寿司職人").AnyTimes()
+		sushi.EXPECT().Target(gomock.Any()).Return(true).AnyTimes()
+		av.EXPECT().Name().Return("AV").AnyTimes()
+		av.EXPECT().Target(gomock.Any()).Return(true).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("Execute()", func() {
+		var (
+			apology bool
+		)
+
+		execute := func() {
+			ttl := service.NewTTLPolicy(nil, map[string]time.Duration{"寿司職人": 5 * time.Minute}, apology)
+			processor := service.NewProcessor(queue, reply, nil, nil, nil, nil, nil, []service.Action{sushi, av}, nil, false, ttl, func() time.Time {
+				return now
+			})
+
+			packets := make(chan service.Packet, 1)
+			packets <- message
+			close(packets)
+			processor.Execute(context.Background(), packets)
+		}
+
+		Context("action has been expired without apology", func() {
+			BeforeEach(func() {
+				apology = false
+
+				av.EXPECT().Event(gomock.Any(), message).Return(service.ReplyEvent{
+					InReplyToID: "100",
+					Acct:        "test",
+					Visibility:  "public",
+				}, 0, nil)
+				send := reply.EXPECT().Send(gomock.Any(), service.ReplyEvent{
+					InReplyToID: "100",
+					Acct:        "test",
+					Visibility:  "public",
+				}).Return("200", nil)
+				queue.EXPECT().Ack(uint64(1)).Return(nil).After(send)
+			})
+
+			It("skips the action and processes the others", func() {
+				sushi.EXPECT().Event(gomock.Any(), gomock.Any()).Times(0)
+				reply.EXPECT().SendError(gomock.Any(), gomock.Any()).Times(0)
+				execute()
+			})
+		})
+
+		Context("action has been expired with apology", func() {
+			BeforeEach(func() {
+				apology = true
+
+				av.EXPECT().Event(gomock.Any(), message).Return(service.ReplyEvent{
+					InReplyToID: "100",
+					Acct:        "test",
+					Visibility:  "public",
+				}, 0, nil)
+				send := reply.EXPECT().Send(gomock.Any(), service.ReplyEvent{
+					InReplyToID: "100",
+					Acct:        "test",
+					Visibility:  "public",
+				}).Return("200", nil)
+				sendError := reply.EXPECT().SendError(gomock.Any(), service.ReplyErrorEvent{
+					InReplyToID: "100",
+					Acct:        "test",
+					Visibility:  "public",
+					ActionName:  "寿司職人",
+					Expired:     true,
+				}).Return(nil)
+				queue.EXPECT().Ack(uint64(1)).Return(nil).After(send).After(sendError)
+			})
+
+			It("replies with an apology instead of the action and processes the others", func() {
+				sushi.EXPECT().Event(gomock.Any(), gomock.Any()).Times(0)
+				execute()
+			})
+		})
+
+		Context("consuming stops while packets are in flight", func() {
+			var (
+				ctx    context.Context
+				cancel context.CancelFunc
+				tick   service.Tick
+			)
+
+			BeforeEach(func() {
+				ctx, cancel = context.WithCancel(context.Background())
+				tick = service.NewTick(2, now)
+				tick.Year, tick.Month, tick.Day = 2024, 1, 2
+
+				DeferCleanup(func(timeout time.Duration) {
+					service.DrainTimeout = timeout
+				}, service.DrainTimeout)
+				service.DrainTimeout = 100 * time.Millisecond
+			})
+
+			executeInFlight := func() {
+				processor := service.NewProcessor(queue, reply, nil, nil, update, nil, nil, nil, nil, false, service.NewTTLPolicy(nil, nil, false), func() time.Time {
+					return now
+				})
+
+				packets := make(chan service.Packet, 1)
+				packets <- tick
+				close(packets)
+				processor.Execute(ctx, packets)
+			}
+
+			Context("packets finish in time", func() {
+				It("acknowledges them before returning", func() {
+					var acked atomic.Bool
+					update.EXPECT().Do(gomock.Any(), service.UpdateEvent{Year: 2024, Month: 1, Day: 2}).DoAndReturn(func(ctx context.Context, event service.UpdateEvent) error {
+						cancel()
+						time.Sleep(10 * time.Millisecond)
+						return ctx.Err()
+					})
+					queue.EXPECT().Ack(uint64(2)).DoAndReturn(func(tag uint64) error {
+						acked.Store(true)
+						return nil
+					})
+
+					executeInFlight()
+					Expect(acked.Load()).To(BeTrue())
+				})
+			})
+
+			Context("packets do not finish in time", func() {
+				It("cancels and rejects them before returning", func() {
+					var rejected atomic.Bool
+					update.EXPECT().Do(gomock.Any(), service.UpdateEvent{Year: 2024, Month: 1, Day: 2}).DoAndReturn(func(ctx context.Context, event service.UpdateEvent) error {
+						cancel()
+						<-ctx.Done()
+						return ctx.Err()
+					})
+					queue.EXPECT().Reject(uint64(2)).DoAndReturn(func(tag uint64) error {
+						rejected.Store(true)
+						return nil
+					})
+
+					start := time.Now()
+					executeInFlight()
+					Expect(rejected.Load()).To(BeTrue())
+					Expect(time.Since(start)).To(BeNumerically(">=", service.DrainTimeout))
+				})
+			})
+		})
+	})
+})
@@ -1,11 +1,15 @@
+//go:generate go tool mockgen -source=queue.go -destination=queue_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+
 package service
 
 import "context"
 
 type QueueReader interface {
+	// Consume delivers packets until ctx is done and closes Packets when it returns,
+	// whereas the packets in flight can be acknowledged until Close is called.
 	Consume(ctx context.Context)
 	Packets() <-chan Packet
 	Ack(tag uint64) error
 	Reject(tag uint64) error
-	Close(exit bool) error
+	Close() error
 }
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: queue.go
+//
+// Generated by this command:
+//
+//	mockgen -source=queue.go -destination=queue_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockQueueReader is a mock of QueueReader interface.
+type MockQueueReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueReaderMockRecorder
+	isgomock struct{}
+}
+
+// MockQueueReaderMockRecorder is the mock recorder for MockQueueReader.
+type MockQueueReaderMockRecorder struct {
+	mock *MockQueueReader
+}
+
+// NewMockQueueReader creates a new mock instance.
+func NewMockQueueReader(ctrl *gomock.Controller) *MockQueueReader {
+	mock := &MockQueueReader{ctrl: ctrl}
+	mock.recorder = &MockQueueReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueueReader) EXPECT() *MockQueueReaderMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockQueueReader) Ack(tag uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack", tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockQueueReaderMockRecorder) Ack(tag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockQueueReader)(nil).Ack), tag)
+}
+
+// Close mocks base method.
+func (m *MockQueueReader) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockQueueReaderMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockQueueReader)(nil).Close))
+}
+
+// Consume mocks base method.
+func (m *MockQueueReader) Consume(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Consume", ctx)
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockQueueReaderMockRecorder) Consume(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockQueueReader)(nil).Consume), ctx)
+}
+
+// Packets mocks base method.
+func (m *MockQueueReader) Packets() <-chan Packet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Packets")
+	ret0, _ := ret[0].(<-chan Packet)
+	return ret0
+}
+
+// Packets indicates an expected call of Packets.
+func (mr *MockQueueReaderMockRecorder) Packets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Packets", reflect.TypeOf((*MockQueueReader)(nil).Packets))
+}
+
+// Reject mocks base method.
+func (m *MockQueueReader) Reject(tag uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockQueueReaderMockRecorder) Reject(tag any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockQueueReader)(nil).Reject), tag)
+}
@@ -1,8 +1,11 @@
+//go:generate go tool mockgen -source=reply.go -destination=reply_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+
 package service
 
 import "context"
 
 type Reply interface {
-	Send(ctx context.Context, event ReplyEvent) error
+	Send(ctx context.Context, event ReplyEvent) (string, error)
 	SendError(ctx context.Context, event ReplyErrorEvent) error
+	Delete(ctx context.Context, event DeleteReplyEvent) error
 }
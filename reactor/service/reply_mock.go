@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: reply.go
+//
+// Generated by this command:
+//
+//	mockgen -source=reply.go -destination=reply_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReply is a mock of Reply interface.
+type MockReply struct {
+	ctrl     *gomock.Controller
+	recorder *MockReplyMockRecorder
+	isgomock struct{}
+}
+
+// MockReplyMockRecorder is the mock recorder for MockReply.
+type MockReplyMockRecorder struct {
+	mock *MockReply
+}
+
+// NewMockReply creates a new mock instance.
+func NewMockReply(ctrl *gomock.Controller) *MockReply {
+	mock := &MockReply{ctrl: ctrl}
+	mock.recorder = &MockReplyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReply) EXPECT() *MockReplyMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockReply) Delete(ctx context.Context, event DeleteReplyEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockReplyMockRecorder) Delete(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockReply)(nil).Delete), ctx, event)
+}
+
+// Send mocks base method.
+func (m *MockReply) Send(ctx context.Context, event ReplyEvent) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, event)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockReplyMockRecorder) Send(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockReply)(nil).Send), ctx, event)
+}
+
+// SendError mocks base method.
+func (m *MockReply) SendError(ctx context.Context, event ReplyErrorEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendError", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendError indicates an expected call of SendError.
+func (mr *MockReplyMockRecorder) SendError(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendError", reflect.TypeOf((*MockReply)(nil).SendError), ctx, event)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"maps"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// DefaultPacketTTLs keeps ticks until they are processed, as missing one leaves the count of the day behind.
+	DefaultPacketTTLs = map[string]time.Duration{
+		Tick{}.Name(): 0,
+	}
+
+	// DefaultActionTTLs keeps the increments and the administrative commands by the owner until they are processed.
+	DefaultActionTTLs = map[string]time.Duration{
+		"ぴゅっ♡": 0,
+		"DB":   0,
+	}
+
+	ExpiredPacketsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "expired_packets_total",
+		Help:      "Total number of packets or actions for messages discarded as they are too old.",
+	}, []string{"type", "action"})
+)
+
+type ttlPolicy struct {
+	Packets     map[string]time.Duration
+	Actions     map[string]time.Duration
+	SendApology bool
+}
+
+type TTLPolicy interface {
+	TTL(packet string, action string) time.Duration
+	Expired(packet Packet, action string, now time.Time) bool
+	Apology() bool
+}
+
+// NewTTLPolicy returns the policy that expires packets after the TTL for their names or the actions for messages,
+// where zero never expires and PacketTTL applies to ones not in packets, actions, or the defaults.
+func NewTTLPolicy(packets, actions map[string]time.Duration, apology bool) TTLPolicy {
+	p := &ttlPolicy{
+		Packets:     maps.Clone(DefaultPacketTTLs),
+		Actions:     maps.Clone(DefaultActionTTLs),
+		SendApology: apology,
+	}
+	maps.Copy(p.Packets, packets)
+	maps.Copy(p.Actions, actions)
+	return p
+}
+
+func (p *ttlPolicy) TTL(packet string, action string) time.Duration {
+	if ttl, ok := p.Actions[action]; ok && action != "" {
+		return ttl
+	}
+	if ttl, ok := p.Packets[packet]; ok {
+		return ttl
+	}
+	return PacketTTL
+}
+
+// Expired reports whether the packet is older than the TTL for the action, or for the packet itself if action is empty.
+func (p *ttlPolicy) Expired(packet Packet, action string, now time.Time) bool {
+	ttl := p.TTL(packet.Name(), action)
+	return ttl > 0 && now.Sub(packet.Timestamp()) > ttl
+}
+
+// Apology reports whether to reply to the messages whose actions have been expired.
+func (p *ttlPolicy) Apology() bool {
+	return p.SendApology
+}
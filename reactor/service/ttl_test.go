@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/reactor/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TTLPolicy", func() {
+	var (
+		now    time.Time
+		policy service.TTLPolicy
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		policy = service.NewTTLPolicy(
+			map[string]time.Duration{
+				"packets.message": 10 * time.Minute,
+			},
+			map[string]time.Duration{
+				"寿司職人": 5 * time.Minute,
+				"おふとん": 0,
+			},
+			false,
+		)
+	})
+
+	Describe("TTL()", func() {
+		Context("action is given", func() {
+			It("returns the TTL for the action over the packet", func() {
+				Expect(policy.TTL("packets.message", "寿司職人")).To(Equal(5 * time.Minute))
+			})
+
+			It("returns the TTL for the packet if the action is not configured", func() {
+				Expect(policy.TTL("packets.message", "AV")).To(Equal(10 * time.Minute))
+			})
+
+			It("returns the defaults for increments and administrative commands", func() {
+				Expect(policy.TTL("packets.message", "ぴゅっ♡")).To(BeZero())
+				Expect(policy.TTL("packets.message", "DB")).To(BeZero())
+			})
+		})
+
+		Context("action is empty", func() {
+			It("returns the TTL for the packet", func() {
+				Expect(policy.TTL("packets.message", "")).To(Equal(10 * time.Minute))
+			})
+
+			It("returns the default for ticks", func() {
+				Expect(policy.TTL("packets.tick", "")).To(BeZero())
+			})
+
+			It("returns PacketTTL if the packet is not configured", func() {
+				Expect(policy.TTL("packets.deletion", "")).To(Equal(service.PacketTTL))
+			})
+		})
+
+		Context("defaults are overridden", func() {
+			BeforeEach(func() {
+				policy = service.NewTTLPolicy(
+					map[string]time.Duration{
+						"packets.tick": time.Hour,
+					},
+					map[string]time.Duration{
+						"ぴゅっ♡": 2 * time.Hour,
+					},
+					false,
+				)
+			})
+
+			It("returns the configured TTL", func() {
+				Expect(policy.TTL("packets.tick", "")).To(Equal(time.Hour))
+				Expect(policy.TTL("packets.message", "ぴゅっ♡")).To(Equal(2 * time.Hour))
+				Expect(policy.TTL("packets.message", "DB")).To(BeZero())
+			})
+
+			It("does not modify the defaults", func() {
+				Expect(service.DefaultPacketTTLs).To(HaveKeyWithValue("packets.tick", time.Duration(0)))
+				Expect(service.DefaultActionTTLs).To(HaveKeyWithValue("ぴゅっ♡", time.Duration(0)))
+			})
+		})
+	})
+
+	Describe("Expired()", func() {
+		Context("packet is older than the TTL for the action", func() {
+			It("returns true", func() {
+				packet := service.NewMessage(1, now.Add(-6*time.Minute))
+				Expect(policy.Expired(packet, "寿司職人", now)).To(BeTrue())
+			})
+		})
+
+		Context("packet is as old as the TTL for the action", func() {
+			It("returns false", func() {
+				packet := service.NewMessage(1, now.Add(-5*time.Minute))
+				Expect(policy.Expired(packet, "寿司職人", now)).To(BeFalse())
+			})
+		})
+
+		Context("packet is older than the TTL for the action but not for the packet", func() {
+			It("returns true only for the action", func() {
+				packet := service.NewMessage(1, now.Add(-7*time.Minute))
+				Expect(policy.Expired(packet, "寿司職人", now)).To(BeTrue())
+				Expect(policy.Expired(packet, "AV", now)).To(BeFalse())
+				Expect(policy.Expired(packet, "", now)).To(BeFalse())
+			})
+		})
+
+		Context("TTL is zero", func() {
+			It("returns false however old the packet is", func() {
+				packet := service.NewMessage(1, now.Add(-365*24*time.Hour))
+				Expect(policy.Expired(packet, "おふとん", now)).To(BeFalse())
+				Expect(policy.Expired(packet, "ぴゅっ♡", now)).To(BeFalse())
+				Expect(policy.Expired(packet, "DB", now)).To(BeFalse())
+
+				tick := service.NewTick(1, now.Add(-365*24*time.Hour))
+				Expect(policy.Expired(tick, "", now)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Apology()", func() {
+		It("returns whether to send an apology", func() {
+			Expect(policy.Apology()).To(BeFalse())
+			Expect(service.NewTTLPolicy(nil, nil, true).Apology()).To(BeTrue())
+		})
+	})
+})
@@ -1,3 +1,5 @@
+//go:generate go tool mockgen -source=update.go -destination=update_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+
 package service
 
 import "context"
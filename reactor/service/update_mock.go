@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: update.go
+//
+// Generated by this command:
+//
+//	mockgen -source=update.go -destination=update_mock.go -package=service -self_package=github.com/chitoku-k/ejaculation-counter/reactor/service
+//
+
+// Package service is a generated GoMock package.
+package service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUpdate is a mock of Update interface.
+type MockUpdate struct {
+	ctrl     *gomock.Controller
+	recorder *MockUpdateMockRecorder
+	isgomock struct{}
+}
+
+// MockUpdateMockRecorder is the mock recorder for MockUpdate.
+type MockUpdateMockRecorder struct {
+	mock *MockUpdate
+}
+
+// NewMockUpdate creates a new mock instance.
+func NewMockUpdate(ctrl *gomock.Controller) *MockUpdate {
+	mock := &MockUpdate{ctrl: ctrl}
+	mock.recorder = &MockUpdateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpdate) EXPECT() *MockUpdateMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockUpdate) Do(ctx context.Context, event UpdateEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockUpdateMockRecorder) Do(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockUpdate)(nil).Do), ctx, event)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Environment struct {
@@ -13,24 +15,35 @@ type Environment struct {
 	Queue    Queue
 
 	LogLevel slog.Level
+	Platform string
 	Port     string
 	TLSCert  string
 	TLSKey   string
 }
 
 type Mastodon struct {
-	ServerURL   string
-	Stream      string
-	AccessToken string
+	ServerURL         string
+	Streams           []string
+	AccessToken       string
+	UserID            string
+	FallbackThreshold int64
+	PollingInterval   time.Duration
+	PollingStateFile  string
+	RecordFile        string
+	ReplayFile        string
+	ReplaySpeed       float64
 }
 
 type Queue struct {
+	Backend     string
 	Host        string
 	Username    string
 	Password    string
 	SSLCert     string
 	SSLKey      string
 	SSLRootCert string
+	OutboxFile  string
+	OutboxSize  int64
 }
 
 func Get() (env Environment, errs error) {
@@ -40,15 +53,26 @@ func Get() (env Environment, errs error) {
 		optional bool
 	}{
 		{name: "MASTODON_SERVER_URL", field: &env.Mastodon.ServerURL},
-		{name: "MASTODON_STREAM", field: &env.Mastodon.Stream},
+		{name: "MASTODON_STREAM", field: &env.Mastodon.Streams},
 		{name: "MASTODON_ACCESS_TOKEN", field: &env.Mastodon.AccessToken},
+		{name: "MASTODON_USER_ID", field: &env.Mastodon.UserID, optional: true},
+		{name: "MASTODON_FALLBACK_THRESHOLD", field: &env.Mastodon.FallbackThreshold, optional: true},
+		{name: "MASTODON_POLLING_INTERVAL_SEC", field: &env.Mastodon.PollingInterval, optional: true},
+		{name: "MASTODON_POLLING_STATE_FILE", field: &env.Mastodon.PollingStateFile, optional: true},
+		{name: "MASTODON_RECORD_FILE", field: &env.Mastodon.RecordFile, optional: true},
+		{name: "MASTODON_REPLAY_FILE", field: &env.Mastodon.ReplayFile, optional: true},
+		{name: "MASTODON_REPLAY_SPEED", field: &env.Mastodon.ReplaySpeed, optional: true},
+		{name: "MQ_BACKEND", field: &env.Queue.Backend, optional: true},
 		{name: "MQ_HOST", field: &env.Queue.Host},
 		{name: "MQ_USERNAME", field: &env.Queue.Username, optional: true},
 		{name: "MQ_PASSWORD", field: &env.Queue.Password, optional: true},
 		{name: "MQ_SSL_CERT", field: &env.Queue.SSLCert, optional: true},
 		{name: "MQ_SSL_KEY", field: &env.Queue.SSLKey, optional: true},
 		{name: "MQ_SSL_ROOT_CERT", field: &env.Queue.SSLRootCert, optional: true},
+		{name: "MQ_OUTBOX_FILE", field: &env.Queue.OutboxFile, optional: true},
+		{name: "MQ_OUTBOX_SIZE", field: &env.Queue.OutboxSize, optional: true},
 		{name: "LOG_LEVEL", field: &env.LogLevel, optional: true},
+		{name: "PLATFORM", field: &env.Platform, optional: true},
 		{name: "PORT", field: &env.Port},
 		{name: "TLS_CERT", field: &env.TLSCert, optional: true},
 		{name: "TLS_KEY", field: &env.TLSKey, optional: true},
@@ -65,6 +89,38 @@ func Get() (env Environment, errs error) {
 		case *string:
 			*field = v
 
+		case *[]string:
+			for s := range strings.SplitSeq(v, ",") {
+				s = strings.TrimSpace(s)
+				if s != "" {
+					*field = append(*field, s)
+				}
+			}
+
+		case *int64:
+			v, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
+		case *float64:
+			v, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = v
+
+		case *time.Duration:
+			v, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("%s is invalid: %w", entry.name, err))
+				continue
+			}
+			*field = time.Duration(v) * time.Second
+
 		case *slog.Level:
 			v, err := parseLogLevel(v)
 			if err != nil {
@@ -3,6 +3,7 @@ package config_test
 import (
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/config"
 	. "github.com/onsi/ginkgo/v2"
@@ -51,7 +52,7 @@ var _ = Describe("Environment", func() {
 				Expect(env).To(Equal(config.Environment{
 					Mastodon: config.Mastodon{
 						ServerURL:   "mastodon",
-						Stream:      "direct",
+						Streams:     []string{"direct"},
 						AccessToken: "token",
 					},
 					Queue: config.Queue{
@@ -100,7 +101,7 @@ var _ = Describe("Environment", func() {
 				})
 			})
 
-			Context("valid log level is given", func() {
+			Context("invalid fallback threshold is given", func() {
 				BeforeEach(func() {
 					err := os.Setenv("MASTODON_SERVER_URL", "mastodon")
 					Expect(err).NotTo(HaveOccurred())
@@ -111,6 +112,36 @@ var _ = Describe("Environment", func() {
 					err = os.Setenv("MASTODON_ACCESS_TOKEN", "token")
 					Expect(err).NotTo(HaveOccurred())
 
+					err = os.Setenv("MASTODON_FALLBACK_THRESHOLD", "five")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MQ_HOST", "mq")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("PORT", "8080")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("LOG_LEVEL", "debug")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("returns an error", func() {
+					_, err := config.Get()
+					Expect(err).To(MatchError(HavePrefix("MASTODON_FALLBACK_THRESHOLD is invalid:")))
+				})
+			})
+
+			Context("valid log level is given", func() {
+				BeforeEach(func() {
+					err := os.Setenv("MASTODON_SERVER_URL", "mastodon")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_STREAM", "user, hashtag:ejaculation_counter,,list:1")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_ACCESS_TOKEN", "token")
+					Expect(err).NotTo(HaveOccurred())
+
 					err = os.Setenv("MQ_HOST", "mq")
 					Expect(err).NotTo(HaveOccurred())
 
@@ -125,20 +156,60 @@ var _ = Describe("Environment", func() {
 
 					err = os.Setenv("LOG_LEVEL", "debug")
 					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_USER_ID", "1")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_FALLBACK_THRESHOLD", "5")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_POLLING_INTERVAL_SEC", "30")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_POLLING_STATE_FILE", "/var/lib/supplier/polling.json")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_RECORD_FILE", "/var/lib/supplier/record.jsonl")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_REPLAY_FILE", "/var/lib/supplier/replay.jsonl")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MASTODON_REPLAY_SPEED", "2.5")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MQ_BACKEND", "nats")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MQ_OUTBOX_FILE", "/var/lib/supplier/outbox.jsonl")
+					Expect(err).NotTo(HaveOccurred())
+
+					err = os.Setenv("MQ_OUTBOX_SIZE", "5000")
+					Expect(err).NotTo(HaveOccurred())
 				})
 
 				It("returns config", func() {
 					env, err := config.Get()
 					Expect(env).To(Equal(config.Environment{
 						Mastodon: config.Mastodon{
-							ServerURL:   "mastodon",
-							Stream:      "direct",
-							AccessToken: "token",
+							ServerURL:         "mastodon",
+							Streams:           []string{"user", "hashtag:ejaculation_counter", "list:1"},
+							AccessToken:       "token",
+							UserID:            "1",
+							FallbackThreshold: 5,
+							PollingInterval:   30 * time.Second,
+							PollingStateFile:  "/var/lib/supplier/polling.json",
+							RecordFile:        "/var/lib/supplier/record.jsonl",
+							ReplayFile:        "/var/lib/supplier/replay.jsonl",
+							ReplaySpeed:       2.5,
 						},
 						Queue: config.Queue{
-							Host:     "mq",
-							Username: "shiko",
-							Password: "shiko",
+							Backend:    "nats",
+							Host:       "mq",
+							Username:   "shiko",
+							Password:   "shiko",
+							OutboxFile: "/var/lib/supplier/outbox.jsonl",
+							OutboxSize: 5000,
 						},
 						Port:     "8080",
 						LogLevel: slog.LevelDebug,
@@ -0,0 +1,3 @@
+package queue
+
+var Send = send
@@ -0,0 +1,177 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	TypeHeader      = "Type"
+	TimestampHeader = "Timestamp"
+)
+
+type natsWriter struct {
+	Stream     string
+	Subject    string
+	Connection *nats.Conn
+	JetStream  jetstream.JetStream
+	Outbox     Outbox
+	mu         sync.Mutex
+}
+
+// NewNATSWriter returns a writer that publishes packets to the subject in the JetStream stream,
+// where the broker drops duplicates by Nats-Msg-Id instead of the deduplication plugin of RabbitMQ.
+// As JetStream has no message priority, packets with high priority are published to subject + schema.NATSPrioritySuffix instead.
+func NewNATSWriter(
+	ctx context.Context,
+	stream, subject string,
+	host, username, password string,
+	sslCert, sslKey, sslRootCert string,
+	outbox Outbox,
+) (service.QueueWriter, error) {
+	w := &natsWriter{
+		Stream:  stream,
+		Subject: subject,
+		Outbox:  outbox,
+	}
+
+	return w, w.connect(ctx, host, NATSOptions(username, password, sslCert, sslKey, sslRootCert)...)
+}
+
+// NATSOptions returns the options to connect to NATS, which reconnects by itself as long as the connection is open.
+func NATSOptions(username, password, sslCert, sslKey, sslRootCert string) []nats.Option {
+	options := []nats.Option{
+		nats.Timeout(ConnectionTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(ReconnectInitial),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			slog.Info("Disconnected from MQ", slog.Any("err", err))
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			slog.Info("Reconnected to MQ", slog.String("remote", nc.ConnectedUrlRedacted()))
+		}),
+	}
+
+	if username != "" || password != "" {
+		options = append(options, nats.UserInfo(username, password))
+	}
+	if sslCert != "" && sslKey != "" {
+		options = append(options, nats.ClientCert(sslCert, sslKey))
+	}
+	if sslRootCert != "" {
+		options = append(options, nats.RootCAs(sslRootCert))
+	}
+	return options
+}
+
+// NewNATSMsg returns the message for the packet with the metadata that AMQP carries in its properties put in the headers.
+func NewNATSMsg(subject string, packet service.Packet, priority service.Priority) (*nats.Msg, error) {
+	entry, err := NewOutboxEntry(packet, priority, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newNATSMsg(subject, entry), nil
+}
+
+func newNATSMsg(subject string, entry OutboxEntry) *nats.Msg {
+	if entry.Priority == service.PriorityHigh {
+		subject += schema.NATSPrioritySuffix
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(jetstream.MsgIDHeader, entry.ID)
+	msg.Header.Set(schema.SchemaVersionHeader, strconv.Itoa(entry.Version))
+	msg.Header.Set(TypeHeader, entry.Type)
+	msg.Header.Set(TimestampHeader, entry.Timestamp.Format(time.RFC3339Nano))
+	msg.Data = entry.Body
+	return msg
+}
+
+func (w *natsWriter) connect(ctx context.Context, host string, options ...nats.Option) error {
+	slog.Debug("Connecting to MQ broker...")
+
+	var err error
+	w.Connection, err = nats.Connect(host, options...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQ broker: %w", err)
+	}
+
+	w.JetStream, err = jetstream.New(w.Connection)
+	if err != nil {
+		return fmt.Errorf("failed to initialize JetStream: %w", err)
+	}
+
+	slog.Debug("Declaring streams in MQ...")
+
+	_, err = w.JetStream.CreateOrUpdateStream(ctx, schema.NATSStream(w.Stream, w.Subject))
+	if err != nil {
+		return fmt.Errorf("failed to declare stream in MQ: %w", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(ReconnectInitial)
+		defer ticker.Stop()
+
+		w.flush(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				if !w.Connection.IsConnected() {
+					continue
+				}
+				w.flush(ctx)
+			}
+		}
+	}()
+
+	slog.Info("Connected to MQ", slog.String("remote", w.Connection.ConnectedUrlRedacted()))
+	return nil
+}
+
+func (w *natsWriter) Close() error {
+	err := w.Connection.Drain()
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to close the MQ connection: %w", err), w.Outbox.Close())
+	}
+	return w.Outbox.Close()
+}
+
+func (w *natsWriter) flush(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := flush(ctx, w.Outbox, w.publish)
+	if err != nil {
+		slog.Error("Error in publishing from outbox", slog.Any("err", err))
+	}
+}
+
+func (w *natsWriter) publish(ctx context.Context, entry OutboxEntry) error {
+	_, err := w.JetStream.PublishMsg(ctx, newNATSMsg(w.Subject, entry))
+	return err
+}
+
+func (w *natsWriter) Publish(ctx context.Context, packet service.Packet, priority service.Priority) error {
+	entry, err := NewOutboxEntry(packet, priority, time.Now())
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return send(ctx, w.Outbox, entry, w.publish)
+}
@@ -0,0 +1,37 @@
+package queue_test
+
+import (
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewNATSMsg()", func() {
+	It("returns a message with headers", func() {
+		msg, err := queue.NewNATSMsg("ejaculation-counter.packets", service.Deletion{
+			ID:        "100",
+			DeletedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		}, service.PriorityNormal)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Subject).To(Equal("ejaculation-counter.packets"))
+		Expect(msg.Header.Get("Nats-Msg-Id")).To(Equal("packets.deletion-317"))
+		Expect(msg.Header.Get("schema_version")).To(Equal("2"))
+		Expect(msg.Header.Get("Type")).To(Equal("packets.deletion"))
+		Expect(msg.Header.Get("Timestamp")).To(Equal("2024-01-02T15:04:05Z"))
+		Expect(msg.Data).To(MatchJSON(`{"id":"100","deleted_at":"2024-01-02T15:04:05Z"}`))
+	})
+
+	It("returns a message to the priority subject for high priority", func() {
+		msg, err := queue.NewNATSMsg("ejaculation-counter.packets", service.Tick{
+			Year:  2024,
+			Month: 1,
+			Day:   2,
+		}, service.PriorityHigh)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Subject).To(Equal("ejaculation-counter.packets.priority"))
+		Expect(msg.Header.Get("Type")).To(Equal("packets.tick"))
+	})
+})
@@ -0,0 +1,393 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	OffsetSuffix      = ".offset"
+	DefaultOutboxSize = 10000
+)
+
+var (
+	ErrOutboxFull = errors.New("outbox is full")
+
+	oldestEntry atomic.Int64
+
+	OutboxBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "outbox_backlog",
+		Help:      "Number of packets waiting in the outbox to be published to message queue.",
+	})
+	OutboxOldestEntryAge = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "outbox_oldest_entry_age_seconds",
+		Help:      "Age of the oldest packet waiting in the outbox, or zero if the outbox is empty.",
+	}, func() float64 {
+		oldest := oldestEntry.Load()
+		if oldest == 0 {
+			return 0
+		}
+		return time.Since(time.Unix(0, oldest)).Seconds()
+	})
+)
+
+// OutboxEntry is a packet encoded for publishing, kept as is so that it is published the same way after a restart.
+type OutboxEntry struct {
+	Type      string           `json:"type"`
+	ID        string           `json:"id"`
+	Version   int              `json:"version"`
+	Priority  service.Priority `json:"priority,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+	QueuedAt  time.Time        `json:"queued_at"`
+	Body      json.RawMessage  `json:"body"`
+}
+
+type Outbox interface {
+	Append(entry OutboxEntry) error
+	Peek() (OutboxEntry, bool)
+	Remove() error
+	Len() int
+	Close() error
+}
+
+type outbox struct {
+	mu      sync.Mutex
+	entries []OutboxEntry
+	file    *os.File
+	head    int
+	Path    string
+	Size    int
+}
+
+// NewOutboxEntry encodes the packet in the current version of the schema.
+func NewOutboxEntry(packet service.Packet, priority service.Priority, queuedAt time.Time) (OutboxEntry, error) {
+	body, err := EncodePacket(packet)
+	if err != nil {
+		return OutboxEntry{}, fmt.Errorf("failed to marshal packet: %w", err)
+	}
+
+	return OutboxEntry{
+		Type:      packet.Name(),
+		ID:        fmt.Sprintf("%v-%v", packet.Name(), packet.HashCode()),
+		Version:   schema.SchemaVersion,
+		Priority:  priority,
+		Timestamp: packet.Timestamp(),
+		QueuedAt:  queuedAt,
+		Body:      body,
+	}, nil
+}
+
+// NewOutbox returns an outbox that keeps packets in the append-only file at path until they are published,
+// along with the number of published entries in path + OffsetSuffix.
+// If path is empty, packets are kept in memory and lost on exit.
+// Either way the outbox holds up to size packets, or DefaultOutboxSize if size is not positive.
+func NewOutbox(path string, size int) (Outbox, error) {
+	if size <= 0 {
+		size = DefaultOutboxSize
+	}
+
+	o := &outbox{
+		Path: path,
+		Size: size,
+	}
+	if path == "" {
+		return o, nil
+	}
+
+	err := o.load()
+	if err != nil {
+		return nil, err
+	}
+
+	o.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+
+	o.update()
+	if len(o.entries) > o.Size {
+		// Packets already persisted are kept, while new ones are rejected until the backlog is drained.
+		slog.Warn("Loaded packets from outbox beyond its size", slog.Int("backlog", len(o.entries)), slog.Int("size", o.Size))
+	} else if len(o.entries) > 0 {
+		slog.Info("Loaded packets from outbox", slog.Int("backlog", len(o.entries)))
+	}
+	return o, nil
+}
+
+func (o *outbox) load() error {
+	offset := 0
+	b, err := os.ReadFile(o.Path + OffsetSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read outbox offset: %w", err)
+	}
+	if err == nil {
+		offset, err = strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil {
+			return fmt.Errorf("failed to parse outbox offset: %w", err)
+		}
+	}
+
+	f, err := os.Open(o.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open outbox: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for n := 0; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// The last entry was being written when the process exited.
+				slog.Warn("Discarded incomplete entry in outbox", slog.Int("entry", n+1))
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read outbox: %w", err)
+		}
+		if n < offset {
+			continue
+		}
+
+		var entry OutboxEntry
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return fmt.Errorf("failed to decode entry %d in outbox: %w", n+1, err)
+		}
+		o.entries = append(o.entries, entry)
+	}
+
+	if len(o.entries) == 0 {
+		return o.truncate()
+	}
+
+	// Rewrite the file without published entries so that the offset starts from zero.
+	return o.compact()
+}
+
+func (o *outbox) compact() error {
+	tmp := o.Path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for _, entry := range o.entries {
+		err = writeEntry(w, entry)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	err = errors.Join(w.Flush(), f.Sync(), f.Close())
+	if err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+
+	err = os.Rename(tmp, o.Path)
+	if err != nil {
+		return fmt.Errorf("failed to replace outbox: %w", err)
+	}
+	return o.removeOffset()
+}
+
+func (o *outbox) truncate() error {
+	err := os.Truncate(o.Path, 0)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to truncate outbox: %w", err)
+	}
+	return o.removeOffset()
+}
+
+func (o *outbox) removeOffset() error {
+	err := os.Remove(o.Path + OffsetSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove outbox offset: %w", err)
+	}
+	return nil
+}
+
+func (o *outbox) writeOffset() error {
+	tmp := o.Path + OffsetSuffix + ".tmp"
+	err := os.WriteFile(tmp, []byte(strconv.Itoa(o.head)), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox offset: %w", err)
+	}
+
+	err = os.Rename(tmp, o.Path+OffsetSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to replace outbox offset: %w", err)
+	}
+	return nil
+}
+
+func writeEntry(w io.Writer, entry OutboxEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry in outbox: %w", err)
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return nil
+}
+
+// update reports the backlog, which must be called with the lock held.
+func (o *outbox) update() {
+	backlog := len(o.entries) - o.head
+	OutboxBacklog.Set(float64(backlog))
+	if backlog == 0 {
+		oldestEntry.Store(0)
+	} else {
+		oldestEntry.Store(o.entries[o.head].QueuedAt.UnixNano())
+	}
+}
+
+func (o *outbox) Append(entry OutboxEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.entries)-o.head >= o.Size {
+		return ErrOutboxFull
+	}
+
+	if o.file != nil {
+		err := writeEntry(o.file, entry)
+		if err != nil {
+			return err
+		}
+
+		err = o.file.Sync()
+		if err != nil {
+			return fmt.Errorf("failed to sync outbox: %w", err)
+		}
+	}
+
+	o.entries = append(o.entries, entry)
+	o.update()
+	return nil
+}
+
+func (o *outbox) Peek() (OutboxEntry, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.head == len(o.entries) {
+		return OutboxEntry{}, false
+	}
+	return o.entries[o.head], true
+}
+
+func (o *outbox) Remove() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.head == len(o.entries) {
+		return nil
+	}
+
+	o.entries[o.head] = OutboxEntry{}
+	o.head++
+	defer o.update()
+
+	if o.head < len(o.entries) {
+		if o.file == nil {
+			return nil
+		}
+		return o.writeOffset()
+	}
+
+	o.entries = nil
+	o.head = 0
+	if o.file == nil {
+		return nil
+	}
+	return o.truncate()
+}
+
+func (o *outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.entries) - o.head
+}
+
+func (o *outbox) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
+
+// send publishes the entry, or appends it to the outbox if publishing fails or earlier entries are waiting to keep the order.
+// The entry is appended even if ctx has been cancelled so that it is published after reconnecting or restarting.
+func send(ctx context.Context, o Outbox, entry OutboxEntry, publish func(ctx context.Context, entry OutboxEntry) error) error {
+	if o.Len() > 0 {
+		err := o.Append(entry)
+		if err != nil {
+			return fmt.Errorf("failed to append message to outbox: %w", err)
+		}
+		return nil
+	}
+
+	err := publish(ctx, entry)
+	QueuedMessageTotal.Inc()
+	if err == nil {
+		return nil
+	}
+
+	QueuedMessageErrorTotal.Inc()
+	outboxErr := o.Append(entry)
+	if outboxErr != nil {
+		return fmt.Errorf("failed to publish message (%w): %w", outboxErr, err)
+	}
+	return fmt.Errorf("failed to publish message (queued in outbox): %w", err)
+}
+
+// flush publishes the entries in the outbox in order until it is empty or publishing fails.
+func flush(ctx context.Context, o Outbox, publish func(ctx context.Context, entry OutboxEntry) error) error {
+	for {
+		entry, ok := o.Peek()
+		if !ok {
+			return nil
+		}
+
+		err := publish(ctx, entry)
+		QueuedMessageTotal.Inc()
+		if err != nil {
+			QueuedMessageErrorTotal.Inc()
+			return fmt.Errorf("failed to publish message from outbox: %w", err)
+		}
+
+		err = o.Remove()
+		if err != nil {
+			return fmt.Errorf("failed to remove message from outbox: %w", err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package queue_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/queue"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outbox", func() {
+	var (
+		entries []queue.OutboxEntry
+	)
+
+	BeforeEach(func() {
+		entries = nil
+		for _, id := range []string{"100", "200", "300"} {
+			entry, err := queue.NewOutboxEntry(service.Deletion{
+				ID:        id,
+				DeletedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			}, service.PriorityNormal, time.Date(2024, 1, 2, 15, 4, 6, 0, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+			entries = append(entries, entry)
+		}
+	})
+
+	Describe("NewOutboxEntry()", func() {
+		It("returns an encoded entry", func() {
+			Expect(entries[0].Type).To(Equal("packets.deletion"))
+			Expect(entries[0].ID).To(Equal("packets.deletion-317"))
+			Expect(entries[0].Version).To(Equal(2))
+			Expect(entries[0].Timestamp).To(Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)))
+			Expect(entries[0].QueuedAt).To(Equal(time.Date(2024, 1, 2, 15, 4, 6, 0, time.UTC)))
+			Expect(entries[0].Body).To(MatchJSON(`{"id":"100","deleted_at":"2024-01-02T15:04:05Z"}`))
+		})
+	})
+
+	Context("path is empty", func() {
+		It("keeps entries in order", func() {
+			outbox, err := queue.NewOutbox("", 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, entry := range entries {
+				Expect(outbox.Append(entry)).To(Succeed())
+			}
+			Expect(outbox.Len()).To(Equal(3))
+
+			for _, expected := range entries {
+				entry, ok := outbox.Peek()
+				Expect(ok).To(BeTrue())
+				Expect(entry).To(Equal(expected))
+				Expect(outbox.Remove()).To(Succeed())
+			}
+
+			_, ok := outbox.Peek()
+			Expect(ok).To(BeFalse())
+			Expect(outbox.Len()).To(Equal(0))
+			Expect(outbox.Close()).To(Succeed())
+		})
+
+		It("returns an error when full", func() {
+			outbox, err := queue.NewOutbox("", 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outbox.Append(entries[0])).To(Succeed())
+			Expect(outbox.Append(entries[1])).To(Succeed())
+			Expect(outbox.Append(entries[2])).To(MatchError(queue.ErrOutboxFull))
+
+			Expect(outbox.Remove()).To(Succeed())
+			Expect(outbox.Append(entries[2])).To(Succeed())
+		})
+	})
+
+	Context("path is given", func() {
+		var (
+			path string
+		)
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "outbox.jsonl")
+		})
+
+		It("restores entries that are not removed after reopening", func() {
+			outbox, err := queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, entry := range entries {
+				Expect(outbox.Append(entry)).To(Succeed())
+			}
+			Expect(outbox.Remove()).To(Succeed())
+			Expect(outbox.Close()).To(Succeed())
+
+			Expect(os.ReadFile(path + queue.OffsetSuffix)).To(BeEquivalentTo("1"))
+
+			outbox, err = queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Len()).To(Equal(2))
+
+			for _, expected := range entries[1:] {
+				entry, ok := outbox.Peek()
+				Expect(ok).To(BeTrue())
+				Expect(entry.ID).To(Equal(expected.ID))
+				Expect(entry.Body).To(MatchJSON(expected.Body))
+				Expect(outbox.Remove()).To(Succeed())
+			}
+			Expect(outbox.Close()).To(Succeed())
+
+			Expect(os.ReadFile(path)).To(BeEmpty())
+			Expect(path + queue.OffsetSuffix).NotTo(BeAnExistingFile())
+		})
+
+		It("discards an incomplete entry at the end", func() {
+			outbox, err := queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Append(entries[0])).To(Succeed())
+			Expect(outbox.Close()).To(Succeed())
+
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = f.WriteString(`{"type":"packets.deletion",`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Close()).To(Succeed())
+
+			outbox, err = queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Len()).To(Equal(1))
+
+			Expect(outbox.Append(entries[1])).To(Succeed())
+			Expect(outbox.Close()).To(Succeed())
+
+			outbox, err = queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Len()).To(Equal(2))
+			Expect(outbox.Close()).To(Succeed())
+		})
+
+		It("returns an error when full without writing to the file", func() {
+			outbox, err := queue.NewOutbox(path, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outbox.Append(entries[0])).To(Succeed())
+			Expect(outbox.Append(entries[1])).To(Succeed())
+			Expect(outbox.Append(entries[2])).To(MatchError(queue.ErrOutboxFull))
+			Expect(outbox.Close()).To(Succeed())
+
+			outbox, err = queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Len()).To(Equal(2))
+			Expect(outbox.Close()).To(Succeed())
+		})
+
+		It("keeps restored entries beyond the size", func() {
+			outbox, err := queue.NewOutbox(path, 0)
+			Expect(err).NotTo(HaveOccurred())
+			for _, entry := range entries {
+				Expect(outbox.Append(entry)).To(Succeed())
+			}
+			Expect(outbox.Close()).To(Succeed())
+
+			outbox, err = queue.NewOutbox(path, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(outbox.Len()).To(Equal(3))
+			Expect(outbox.Append(entries[0])).To(MatchError(queue.ErrOutboxFull))
+			Expect(outbox.Close()).To(Succeed())
+		})
+	})
+
+	Describe("Send()", func() {
+		var (
+			outbox queue.Outbox
+		)
+
+		BeforeEach(func() {
+			var err error
+			outbox, err = queue.NewOutbox("", 0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("publishing succeeds", func() {
+			It("does not append the entry", func() {
+				err := queue.Send(context.Background(), outbox, entries[0], func(ctx context.Context, entry queue.OutboxEntry) error {
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outbox.Len()).To(Equal(0))
+			})
+		})
+
+		Context("publishing fails after the context is cancelled", func() {
+			It("appends the entry", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				err := queue.Send(ctx, outbox, entries[0], func(ctx context.Context, entry queue.OutboxEntry) error {
+					return ctx.Err()
+				})
+				Expect(err).To(MatchError(context.Canceled))
+				Expect(err).To(MatchError(ContainSubstring("queued in outbox")))
+
+				entry, ok := outbox.Peek()
+				Expect(ok).To(BeTrue())
+				Expect(entry).To(Equal(entries[0]))
+			})
+		})
+
+		Context("earlier entries are waiting", func() {
+			It("appends the entry without publishing", func() {
+				Expect(outbox.Append(entries[0])).To(Succeed())
+
+				err := queue.Send(context.Background(), outbox, entries[1], func(ctx context.Context, entry queue.OutboxEntry) error {
+					Fail("publish must not be called")
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(outbox.Len()).To(Equal(2))
+			})
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chitoku-k/ejaculation-counter/packet"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+)
+
+// EncodePacket encodes the packet in the current version of the schema.
+func EncodePacket(p service.Packet) ([]byte, error) {
+	switch p := p.(type) {
+	case service.Tick:
+		return json.Marshal(packet.Tick{
+			Year:  p.Year,
+			Month: p.Month,
+			Day:   p.Day,
+		})
+
+	case service.Message:
+		return json.Marshal(packet.Message{
+			ID:        p.ID,
+			Account:   encodeAccount(p.Account),
+			CreatedAt: p.CreatedAt,
+			EditedAt:  p.EditedAt,
+			Content:   p.Content,
+			Emojis: packet.Convert(p.Emojis, func(emoji service.Emoji) packet.Emoji {
+				return packet.Emoji{Shortcode: emoji.Shortcode}
+			}),
+			InReplyToID: p.InReplyToID,
+			IsReblog:    p.IsReblog,
+			Mentions: packet.Convert(p.Mentions, func(mention service.Mention) packet.Mention {
+				return packet.Mention{
+					ID:       mention.ID,
+					Acct:     mention.Acct,
+					Username: mention.Username,
+				}
+			}),
+			Tags: packet.Convert(p.Tags, func(tag service.Tag) packet.Tag {
+				return packet.Tag{Name: tag.Name}
+			}),
+			Visibility: p.Visibility,
+		})
+
+	case service.Notification:
+		return json.Marshal(packet.Notification{
+			ID:        p.ID,
+			Type:      p.Type,
+			Account:   encodeAccount(p.Account),
+			CreatedAt: p.CreatedAt,
+			StatusID:  p.StatusID,
+		})
+
+	case service.Deletion:
+		return json.Marshal(packet.Deletion{
+			ID:        p.ID,
+			DeletedAt: p.DeletedAt,
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown packet type: %T", p)
+	}
+}
+
+func encodeAccount(account service.Account) packet.Account {
+	return packet.Account{
+		ID:          account.ID,
+		Acct:        account.Acct,
+		DisplayName: account.DisplayName,
+		Username:    account.Username,
+	}
+}
@@ -4,14 +4,15 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
+	"sync"
 	"time"
 
+	schema "github.com/chitoku-k/ejaculation-counter/packet"
 	"github.com/chitoku-k/ejaculation-counter/supplier/service"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -29,6 +30,9 @@ const (
 
 	CacheSize = 1024
 	CacheTTL  = 1 * time.Minute
+
+	// Quorum queues deliver messages with priority above 4 ahead of the others.
+	HighPriority = 5
 )
 
 var (
@@ -57,7 +61,8 @@ type writer struct {
 	Channel       *amqp.Channel
 	Confirmations chan amqp.Confirmation
 	Closes        chan *amqp.Error
-	Queue         chan service.Packet
+	Outbox        Outbox
+	mu            sync.Mutex
 }
 
 func NewWriter(
@@ -65,6 +70,7 @@ func NewWriter(
 	exchange, routingKey string,
 	host, username, password string,
 	sslCert, sslKey, sslRootCert string,
+	outbox Outbox,
 ) (service.QueueWriter, error) {
 	w := &writer{
 		Exchange:    exchange,
@@ -75,7 +81,7 @@ func NewWriter(
 		SSLCert:     sslCert,
 		SSLKey:      sslKey,
 		SSLRootCert: sslRootCert,
-		Queue:       make(chan service.Packet, QueueSize),
+		Outbox:      outbox,
 	}
 
 	return w, w.connect(ctx)
@@ -183,6 +189,10 @@ func (w *writer) connect(ctx context.Context) error {
 	}
 
 	go func() {
+		ticker := time.NewTicker(ReconnectInitial)
+		defer ticker.Stop()
+
+		w.flush(ctx)
 		for {
 			select {
 			case <-ctx.Done():
@@ -193,11 +203,8 @@ func (w *writer) connect(ctx context.Context) error {
 				w.reconnect(ctx)
 				return
 
-			case packet := <-w.Queue:
-				err := w.Publish(ctx, packet)
-				if err != nil {
-					slog.Error("Error in publishing from queue", slog.Any("err", err))
-				}
+			case <-ticker.C:
+				w.flush(ctx)
 			}
 		}
 	}()
@@ -255,18 +262,31 @@ func (w *writer) reconnect(ctx context.Context) {
 func (w *writer) Close() error {
 	err := w.disconnect()
 	if !errors.Is(err, amqp.ErrClosed) {
-		return err
+		return errors.Join(err, w.Outbox.Close())
 	}
-	return nil
+	return w.Outbox.Close()
 }
 
-func (w *writer) Publish(ctx context.Context, packet service.Packet) error {
-	body, err := json.Marshal(packet)
+// AMQPPriority returns the priority of AMQP messages for the priority of packets.
+func AMQPPriority(priority service.Priority) uint8 {
+	if priority == service.PriorityHigh {
+		return HighPriority
+	}
+	return 0
+}
+
+func (w *writer) flush(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := flush(ctx, w.Outbox, w.publish)
 	if err != nil {
-		return fmt.Errorf("failed to marshal packet: %w", err)
+		slog.Error("Error in publishing from outbox", slog.Any("err", err))
 	}
+}
 
-	err = w.Channel.PublishWithContext(
+func (w *writer) publish(ctx context.Context, entry OutboxEntry) error {
+	err := w.Channel.PublishWithContext(
 		ctx,
 		w.Exchange,
 		w.RoutingKey,
@@ -274,30 +294,35 @@ func (w *writer) Publish(ctx context.Context, packet service.Packet) error {
 		false,
 		amqp.Publishing{
 			ContentType: "application/json",
-			Timestamp:   packet.Timestamp(),
-			Type:        packet.Name(),
+			Priority:    AMQPPriority(entry.Priority),
+			Timestamp:   entry.Timestamp,
+			Type:        entry.Type,
 			Headers: amqp.Table{
-				"x-deduplication-header": fmt.Sprintf("%v-%v", packet.Name(), packet.HashCode()),
+				"x-deduplication-header":   entry.ID,
+				schema.SchemaVersionHeader: int32(entry.Version),
 			},
-			Body: body,
+			Body: entry.Body,
 		},
 	)
-	<-w.Confirmations
-	QueuedMessageTotal.Inc()
-
 	if err != nil {
-		QueuedMessageErrorTotal.Inc()
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("failed to publish message (%w): %w", ctx.Err(), err)
+		return err
+	}
 
-		case w.Queue <- packet:
-			return fmt.Errorf("failed to publish message (requeued): %w", err)
+	confirmation := <-w.Confirmations
+	if !confirmation.Ack {
+		return errors.New("message was not confirmed by MQ broker")
+	}
+	return nil
+}
 
-		default:
-			return fmt.Errorf("failed to publish message (queue is full): %w", err)
-		}
+func (w *writer) Publish(ctx context.Context, packet service.Packet, priority service.Priority) error {
+	entry, err := NewOutboxEntry(packet, priority, time.Now())
+	if err != nil {
+		return err
 	}
 
-	return nil
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return send(ctx, w.Outbox, entry, w.publish)
 }
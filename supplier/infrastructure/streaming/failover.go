@@ -0,0 +1,140 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	StreamingFallbackActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "streaming_fallback_active",
+		Help:      "Whether the fallback for streaming is active (0: inactive, 1: active).",
+	})
+)
+
+type failover struct {
+	ch        chan service.Status
+	Primary   service.Streaming
+	Fallback  service.Streaming
+	Threshold int
+}
+
+// NewFailover returns a streaming that runs fallback while primary has failed to reconnect
+// threshold times in a row, and stops it once primary connects again.
+func NewFailover(primary, fallback service.Streaming, threshold int) service.Streaming {
+	return &failover{
+		ch:        make(chan service.Status),
+		Primary:   primary,
+		Fallback:  fallback,
+		Threshold: threshold,
+	}
+}
+
+func (f *failover) Statuses() <-chan service.Status {
+	return f.ch
+}
+
+func (f *failover) Close(exit bool) error {
+	err := errors.Join(f.Primary.Close(exit), f.Fallback.Close(exit))
+	if exit {
+		close(f.ch)
+		f.ch = nil
+	}
+	return err
+}
+
+func (f *failover) startFallback(ctx context.Context) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- f.Fallback.Run(ctx)
+	}()
+	return cancel, done
+}
+
+func (f *failover) Run(ctx context.Context) error {
+	primaryDone := make(chan error, 1)
+	go func() {
+		primaryDone <- f.Primary.Run(ctx)
+	}()
+
+	var (
+		cancel       context.CancelFunc
+		fallbackDone chan error
+	)
+	stop := func() {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-fallbackDone
+
+		cancel, fallbackDone = nil, nil
+		StreamingFallbackActive.Set(0)
+		slog.Info("Stopped fallback for streaming")
+	}
+	defer stop()
+
+	failures := 0
+	primary := f.Primary.Statuses()
+	fallback := f.Fallback.Statuses()
+	for {
+		var status service.Status
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-primaryDone:
+			return err
+
+		case err := <-fallbackDone:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("Error in fallback for streaming", slog.Any("err", err))
+			}
+			cancel, fallbackDone = nil, nil
+			StreamingFallbackActive.Set(0)
+			continue
+
+		case s, ok := <-primary:
+			if !ok {
+				primary = nil
+				continue
+			}
+			status = s
+
+			switch status.(type) {
+			case service.Connection:
+				failures = 0
+				stop()
+
+			case service.Reconnection:
+				failures++
+				if failures >= f.Threshold && cancel == nil {
+					slog.Info("Starting fallback for streaming", slog.Int("failures", failures))
+					cancel, fallbackDone = f.startFallback(ctx)
+					StreamingFallbackActive.Set(1)
+				}
+			}
+
+		case s, ok := <-fallback:
+			if !ok {
+				fallback = nil
+				continue
+			}
+			status = s
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case f.ch <- status:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package streaming_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Failover", func() {
+	var (
+		ctrl       *gomock.Controller
+		primary    *service.MockStreaming
+		fallback   *service.MockStreaming
+		primaryCh  chan service.Status
+		fallbackCh chan service.Status
+		failover   service.Streaming
+		ctx        context.Context
+		cancel     context.CancelFunc
+		done       chan error
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		primary = service.NewMockStreaming(ctrl)
+		fallback = service.NewMockStreaming(ctrl)
+		primaryCh = make(chan service.Status)
+		fallbackCh = make(chan service.Status)
+		failover = streaming.NewFailover(primary, fallback, 2)
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan error, 1)
+
+		primary.EXPECT().Statuses().Return(primaryCh)
+		fallback.EXPECT().Statuses().Return(fallbackCh)
+		primary.EXPECT().Run(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	})
+
+	AfterEach(func() {
+		cancel()
+		Eventually(done).Should(Receive(MatchError(context.Canceled)))
+		ctrl.Finish()
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			done <- failover.Run(ctx)
+		}()
+	})
+
+	Describe("Run()", func() {
+		Context("primary fails fewer times than threshold", func() {
+			It("does not start fallback", func() {
+				primaryCh <- service.Reconnection{In: 5 * time.Second}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Reconnection{In: 5 * time.Second})))
+
+				primaryCh <- service.Connection{Server: "mastodon"}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Connection{Server: "mastodon"})))
+
+				primaryCh <- service.Reconnection{In: 5 * time.Second}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Reconnection{In: 5 * time.Second})))
+			})
+		})
+
+		Context("primary fails as many times as threshold", func() {
+			var (
+				stopped chan struct{}
+			)
+
+			BeforeEach(func() {
+				stopped = make(chan struct{})
+				fallback.EXPECT().Run(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+					fallbackCh <- service.Connection{Server: "polling"}
+					<-ctx.Done()
+					close(stopped)
+					return ctx.Err()
+				})
+			})
+
+			It("starts fallback and stops it once primary recovers", func() {
+				primaryCh <- service.Reconnection{In: 5 * time.Second}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Reconnection{In: 5 * time.Second})))
+
+				primaryCh <- service.Reconnection{In: 5 * time.Second}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Reconnection{In: 5 * time.Second})))
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Connection{Server: "polling"})))
+				Consistently(stopped).ShouldNot(BeClosed())
+
+				primaryCh <- service.Connection{Server: "mastodon"}
+				Eventually(failover.Statuses()).Should(Receive(Equal(service.Connection{Server: "mastodon"})))
+				Expect(stopped).To(BeClosed())
+			})
+		})
+	})
+
+	Describe("Close()", func() {
+		It("closes both primary and fallback", func() {
+			primary.EXPECT().Close(false).Return(nil)
+			fallback.EXPECT().Close(false).Return(nil)
+
+			err := failover.Close(false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,387 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	JetstreamCollection = "app.bsky.feed.post"
+
+	// BlueskyAppViewURL is the public AppView that serves the profiles without authentication.
+	BlueskyAppViewURL = "https://public.api.bsky.app"
+
+	JetstreamAccountCacheSize = 1000
+
+	// JetstreamRewind is subtracted from the cursor on reconnection so that no event is lost.
+	JetstreamRewind = 5 * time.Second
+)
+
+type JetstreamEvent struct {
+	DID      string             `json:"did"`
+	TimeUS   int64              `json:"time_us"`
+	Kind     string             `json:"kind"`
+	Commit   *JetstreamCommit   `json:"commit"`
+	Identity *JetstreamIdentity `json:"identity"`
+}
+
+type JetstreamCommit struct {
+	Operation  string           `json:"operation"`
+	Collection string           `json:"collection"`
+	RKey       string           `json:"rkey"`
+	Record     *JetstreamRecord `json:"record"`
+}
+
+type JetstreamIdentity struct {
+	DID    string `json:"did"`
+	Handle string `json:"handle"`
+}
+
+type JetstreamRecord struct {
+	Text      string           `json:"text"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Facets    []JetstreamFacet `json:"facets"`
+	Tags      []string         `json:"tags"`
+	Reply     *struct {
+		Parent struct {
+			URI string `json:"uri"`
+		} `json:"parent"`
+	} `json:"reply"`
+}
+
+type BlueskyProfile struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+}
+
+type JetstreamFacet struct {
+	Features []struct {
+		Type string `json:"$type"`
+		DID  string `json:"did"`
+		Tag  string `json:"tag"`
+	} `json:"features"`
+}
+
+type jetstream struct {
+	ch       chan service.Status
+	conn     wrapper.Conn
+	cursor   int64
+	accounts map[string]service.Account
+	Dialer   wrapper.Dialer
+	Timer    wrapper.Timer
+	Client   *http.Client
+	Server   string
+	AppView  string
+	DIDs     []string
+}
+
+func ParseDIDs(specs []string) (dids []string, err error) {
+	for _, spec := range specs {
+		if !strings.HasPrefix(spec, "did:") {
+			return nil, fmt.Errorf("invalid DID: %q", spec)
+		}
+		dids = append(dids, spec)
+	}
+	return
+}
+
+// NewJetstream returns a streaming of posts from the given DIDs through Jetstream,
+// whose authors and mentioned users are looked up on the AppView at appViewURL.
+func NewJetstream(
+	dialer wrapper.Dialer,
+	timer wrapper.Timer,
+	client *http.Client,
+	serverURL string,
+	appViewURL string,
+	dids []string,
+) service.Streaming {
+	return &jetstream{
+		ch:       make(chan service.Status),
+		accounts: map[string]service.Account{},
+		Dialer:   dialer,
+		Timer:    timer,
+		Client:   client,
+		Server:   serverURL,
+		AppView:  appViewURL,
+		DIDs:     dids,
+	}
+}
+
+func jetstreamURI(did, rkey string) string {
+	return "at://" + did + "/" + JetstreamCollection + "/" + rkey
+}
+
+// profile looks up the profile of the user on the AppView.
+func (j *jetstream) profile(ctx context.Context, did string) (BlueskyProfile, error) {
+	ctx, cancel := context.WithTimeout(ctx, LookupTimeout)
+	defer cancel()
+
+	u, err := url.Parse(j.AppView)
+	if err != nil {
+		return BlueskyProfile{}, fmt.Errorf("failed to parse AppView URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, "/xrpc/app.bsky.actor.getProfile")
+	u.RawQuery = url.Values{"actor": {did}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return BlueskyProfile{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := j.Client.Do(req)
+	if err != nil {
+		return BlueskyProfile{}, fmt.Errorf("failed to call app.bsky.actor.getProfile: %w", err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 399 {
+		return BlueskyProfile{}, fmt.Errorf("failed response from app.bsky.actor.getProfile (%v)", res.Status)
+	}
+
+	var profile BlueskyProfile
+	err = json.NewDecoder(res.Body).Decode(&profile)
+	if err != nil {
+		return BlueskyProfile{}, fmt.Errorf("failed to decode response from app.bsky.actor.getProfile: %w", err)
+	}
+	return profile, nil
+}
+
+// convertAccount returns the account of the user with the handle, which falls back to the DID if it cannot be looked up.
+func (j *jetstream) convertAccount(ctx context.Context, did string) service.Account {
+	if account, ok := j.accounts[did]; ok {
+		return account
+	}
+
+	profile, err := j.profile(ctx, did)
+	if err != nil || profile.Handle == "" {
+		slog.Warn("Failed to resolve handle", slog.String("did", did), slog.Any("err", err))
+		return service.Account{
+			ID:          did,
+			Acct:        did,
+			DisplayName: did,
+			Username:    did,
+		}
+	}
+
+	account := service.Account{
+		ID:          did,
+		Acct:        profile.Handle,
+		DisplayName: profile.DisplayName,
+	}
+	account.Username, _, _ = strings.Cut(profile.Handle, ".")
+	if account.DisplayName == "" {
+		account.DisplayName = profile.Handle
+	}
+
+	if len(j.accounts) >= JetstreamAccountCacheSize {
+		clear(j.accounts)
+	}
+	j.accounts[did] = account
+	return account
+}
+
+func (j *jetstream) convertPost(ctx context.Context, event JetstreamEvent) service.Message {
+	record := event.Commit.Record
+	message := service.Message{
+		ID:         jetstreamURI(event.DID, event.Commit.RKey),
+		Account:    j.convertAccount(ctx, event.DID),
+		CreatedAt:  record.CreatedAt,
+		Content:    record.Text,
+		Emojis:     []service.Emoji{},
+		Mentions:   []service.Mention{},
+		Tags:       []service.Tag{},
+		Visibility: "public",
+	}
+
+	for _, facet := range record.Facets {
+		for _, feature := range facet.Features {
+			switch feature.Type {
+			case "app.bsky.richtext.facet#mention":
+				account := j.convertAccount(ctx, feature.DID)
+				message.Mentions = append(message.Mentions, service.Mention{
+					ID:       account.ID,
+					Acct:     account.Acct,
+					Username: account.Username,
+				})
+
+			case "app.bsky.richtext.facet#tag":
+				message.Tags = append(message.Tags, service.Tag{Name: feature.Tag})
+			}
+		}
+	}
+	for _, tag := range record.Tags {
+		message.Tags = append(message.Tags, service.Tag{Name: tag})
+	}
+
+	if record.Reply != nil {
+		message.InReplyToID = record.Reply.Parent.URI
+	}
+
+	return message
+}
+
+func (j *jetstream) url(base url.URL) string {
+	u := base
+	params := url.Values{}
+	params.Set("wantedCollections", JetstreamCollection)
+	for _, did := range j.DIDs {
+		params.Add("wantedDids", did)
+	}
+	if j.cursor > 0 {
+		params.Set("cursor", strconv.FormatInt(j.cursor-JetstreamRewind.Microseconds(), 10))
+	}
+	u.RawQuery = params.Encode()
+
+	return u.String()
+}
+
+func (j *jetstream) Statuses() <-chan service.Status {
+	return j.ch
+}
+
+func (j *jetstream) Close(exit bool) error {
+	if exit {
+		close(j.ch)
+		j.ch = nil
+	}
+	if j.conn != nil {
+		_ = j.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown"))
+		return j.conn.Close()
+	}
+	return nil
+}
+
+func (j *jetstream) reconnect(ctx context.Context, current time.Duration, err error) (time.Duration, error) {
+	return reconnect(ctx, j.ch, j.Timer, current, err)
+}
+
+func (j *jetstream) disconnect(ctx context.Context, err error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case j.ch <- service.Disconnection{Err: err}:
+		err = j.Close(false)
+		if err == nil {
+			return nil
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case j.ch <- service.Error{Err: err}:
+		j.conn = nil
+		return nil
+	}
+}
+
+func (j *jetstream) Run(ctx context.Context) error {
+	reconnect := ReconnectNone
+
+	u, err := url.Parse(j.Server)
+	if err != nil {
+		return fmt.Errorf("failed to parse server URL: %w", err)
+	}
+
+	server := u.Host
+	for {
+		slog.Debug("Connecting to streaming...")
+
+		var res *http.Response
+		j.conn, res, err = j.Dialer.DialContext(ctx, j.url(*u), nil)
+		if err != nil {
+			if res != nil {
+				err = fmt.Errorf("failed to connect: %v: %w", res.Status, err)
+			}
+
+			reconnect, err = j.reconnect(ctx, reconnect, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		reconnect = ReconnectNone
+		StreamingMessageTotal.WithLabelValues(server)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case j.ch <- service.Connection{Server: server}:
+			break
+		}
+
+		for {
+			var event JetstreamEvent
+			err := j.conn.ReadJSON(&event)
+			if err != nil {
+				err := j.disconnect(ctx, err)
+				if err != nil {
+					return err
+				}
+				break
+			}
+			j.cursor = max(j.cursor, event.TimeUS)
+
+			var packet service.Status
+			switch event.Kind {
+			case "identity":
+				// The handle may have been changed, so the account is looked up again next time.
+				delete(j.accounts, event.DID)
+
+			case "commit":
+				if event.Commit == nil || event.Commit.Collection != JetstreamCollection {
+					break
+				}
+
+				switch event.Commit.Operation {
+				case "create", "update":
+					if event.Commit.Record == nil {
+						break
+					}
+					message := j.convertPost(ctx, event)
+					if event.Commit.Operation == "update" {
+						message.EditedAt = time.UnixMicro(event.TimeUS)
+					}
+					packet = message
+
+				case "delete":
+					packet = service.Deletion{
+						ID:        jetstreamURI(event.DID, event.Commit.RKey),
+						DeletedAt: time.UnixMicro(event.TimeUS),
+					}
+				}
+			}
+
+			if packet == nil {
+				continue
+			}
+
+			StreamingMessageTotal.WithLabelValues(server).Inc()
+			StreamingStreamMessageTotal.WithLabelValues(JetstreamCollection).Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case j.ch <- packet:
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package streaming_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("ParseDIDs()", func() {
+	Context("valid DIDs are given", func() {
+		It("returns DIDs", func() {
+			actual, err := streaming.ParseDIDs([]string{"did:plc:ewvi7nxzyoun6zhxrhs64oiz", "did:web:example.com"})
+			Expect(actual).To(Equal([]string{"did:plc:ewvi7nxzyoun6zhxrhs64oiz", "did:web:example.com"}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("invalid DID is given", func() {
+		It("returns an error", func() {
+			_, err := streaming.ParseDIDs([]string{"test.bsky.social"})
+			Expect(err).To(MatchError(`invalid DID: "test.bsky.social"`))
+		})
+	})
+})
+
+var _ = Describe("Jetstream", func() {
+	var (
+		ctrl      *gomock.Controller
+		conn      *wrapper.MockConn
+		d         *wrapper.MockDialer
+		t         *wrapper.MockTimer
+		server    *ghttp.Server
+		jetstream service.Streaming
+		ctx       context.Context
+		cancel    context.CancelFunc
+		event     streaming.JetstreamEvent
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		conn = wrapper.NewMockConn(ctrl)
+		d = wrapper.NewMockDialer(ctrl)
+		t = wrapper.NewMockTimer(ctrl)
+		server = ghttp.NewServer()
+		jetstream = streaming.NewJetstream(d, t, http.DefaultClient, "wss://jetstream.example.com/subscribe", server.URL(), []string{"did:plc:ewvi7nxzyoun6zhxrhs64oiz"})
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		ctrl.Finish()
+	})
+
+	Describe("Run()", func() {
+		Context("recorded events are read", func() {
+			BeforeEach(func() {
+				fixture, err := os.ReadFile("testdata/jetstream.jsonl")
+				Expect(err).NotTo(HaveOccurred())
+
+				gomock.InOrder(
+					d.EXPECT().DialContext(
+						ctx,
+						"wss://jetstream.example.com/subscribe?wantedCollections=app.bsky.feed.post&wantedDids=did%3Aplc%3Aewvi7nxzyoun6zhxrhs64oiz",
+						nil,
+					).Return(conn, &http.Response{}, nil),
+					d.EXPECT().DialContext(
+						ctx,
+						"wss://jetstream.example.com/subscribe?cursor=1725911157332000&wantedCollections=app.bsky.feed.post&wantedDids=did%3Aplc%3Aewvi7nxzyoun6zhxrhs64oiz",
+						nil,
+					).Do(func(context.Context, string, http.Header) {
+						cancel()
+					}).Return(nil, nil, context.Canceled),
+				)
+
+				var calls []any
+				for line := range bytes.Lines(fixture) {
+					calls = append(calls, conn.EXPECT().ReadJSON(&event).Do(func(e *streaming.JetstreamEvent) {
+						err := json.Unmarshal(line, e)
+						Expect(err).NotTo(HaveOccurred())
+					}).Return(nil))
+				}
+				calls = append(calls, conn.EXPECT().ReadJSON(&event).Return(errors.New("unexpected EOF")))
+				gomock.InOrder(calls...)
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/app.bsky.actor.getProfile", "actor=did%3Aplc%3Aewvi7nxzyoun6zhxrhs64oiz"),
+						ghttp.RespondWith(http.StatusOK, `{"did":"did:plc:ewvi7nxzyoun6zhxrhs64oiz","handle":"test.bsky.social","displayName":"テスト"}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/xrpc/app.bsky.actor.getProfile", "actor=did%3Aplc%3A4ylvfhsbtvwjqvgfdvuglhvi"),
+						ghttp.RespondWith(http.StatusBadRequest, `{"error":"InvalidRequest","message":"Profile not found"}`),
+					),
+				)
+
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
+				conn.EXPECT().WriteMessage(websocket.CloseMessage, message)
+				conn.EXPECT().Close().Return(nil)
+			})
+
+			It("sends posts and deletions and resumes from the cursor", func() {
+				actual := jetstream.Statuses()
+				go func() {
+					defer GinkgoRecover()
+
+					err := jetstream.Run(ctx)
+					Expect(err).To(Equal(context.Canceled))
+				}()
+
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: "jetstream.example.com",
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Message{
+					ID: "at://did:plc:ewvi7nxzyoun6zhxrhs64oiz/app.bsky.feed.post/3l3qo2vuowo2b",
+					Account: service.Account{
+						ID:          "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+						Acct:        "test.bsky.social",
+						DisplayName: "テスト",
+						Username:    "test",
+					},
+					CreatedAt: time.Date(2024, 9, 9, 19, 46, 2, 102000000, time.UTC),
+					Content:   "@ejaculation-counter.bsky.social ちんぽ揃えゲーム",
+					Emojis:    []service.Emoji{},
+					Mentions: []service.Mention{
+						{
+							ID:       "did:plc:4ylvfhsbtvwjqvgfdvuglhvi",
+							Acct:     "did:plc:4ylvfhsbtvwjqvgfdvuglhvi",
+							Username: "did:plc:4ylvfhsbtvwjqvgfdvuglhvi",
+						},
+					},
+					Tags: []service.Tag{
+						{Name: "ちんぽ揃えゲーム"},
+					},
+					InReplyToID: "at://did:plc:4ylvfhsbtvwjqvgfdvuglhvi/app.bsky.feed.post/3l3qo2vaaaa2b",
+					Visibility:  "public",
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Deletion{
+					ID:        "at://did:plc:ewvi7nxzyoun6zhxrhs64oiz/app.bsky.feed.post/3l3qo2vuowo2b",
+					DeletedAt: time.UnixMicro(1725911162332000),
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Disconnection{
+					Err: errors.New("unexpected EOF"),
+				})))
+				Eventually(ctx.Done()).Should(BeClosed())
+			})
+		})
+	})
+})
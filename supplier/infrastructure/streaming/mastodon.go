@@ -3,12 +3,14 @@ package streaming
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,6 +29,16 @@ var (
 		Name:      "streaming_message_total",
 		Help:      "Total number of messages from streaming.",
 	}, []string{"server"})
+	StreamingStreamMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "streaming_stream_message_total",
+		Help:      "Total number of messages from streaming per stream.",
+	}, []string{"stream"})
+	StreamingBackfillMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "streaming_backfill_message_total",
+		Help:      "Total number of messages fetched to fill the gap in streaming after reconnection.",
+	}, []string{"source"})
 	StreamingRetryTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: "ejaculation_counter",
 		Name:      "streaming_retry_total",
@@ -34,37 +46,124 @@ var (
 	})
 )
 
+var (
+	NotificationTypes = []string{"favourite", "reblog", "follow"}
+)
+
 const (
 	ReconnectNone    = 0 * time.Second
 	ReconnectInitial = 5 * time.Second
 	ReconnectMax     = 320 * time.Second
 	ServerHeader     = "X-Served-By"
+
+	// LookupTimeout bounds each lookup of users made while reading events, which holds back the following ones.
+	LookupTimeout = 5 * time.Second
+
+	// StateSaveInterval throttles writing the last seen IDs while streaming, which are written on disconnection anyway.
+	StateSaveInterval = 10 * time.Second
 )
 
+type Stream struct {
+	Name string
+	Tag  string
+	List string
+}
+
+type StreamRequest struct {
+	Type   string `json:"type"`
+	Stream string `json:"stream"`
+	Tag    string `json:"tag,omitempty"`
+	List   string `json:"list,omitempty"`
+}
+
+type StreamEvent struct {
+	Stream  []string `json:"stream"`
+	Event   string   `json:"event"`
+	Payload string   `json:"payload"`
+}
+
 type mastodon struct {
-	ch     chan service.Status
-	conn   wrapper.Conn
-	Client *mast.Client
-	Dialer wrapper.Dialer
-	Timer  wrapper.Timer
-	Stream string
+	ch      chan service.Status
+	conn    wrapper.Conn
+	sources []pollingSource
+	saved   time.Time
+	Client  *mast.Client
+	Dialer  wrapper.Dialer
+	Timer   wrapper.Timer
+	Streams []Stream
+	State   PollingState
+}
+
+func ParseStream(s string) (Stream, error) {
+	switch s {
+	case "user", "user:notification", "public", "public:media", "public:local", "public:local:media", "public:remote", "public:remote:media", "direct":
+		return Stream{Name: s}, nil
+	}
+
+	for _, name := range []string{"hashtag:local", "hashtag"} {
+		tag, ok := strings.CutPrefix(s, name+":")
+		if !ok {
+			continue
+		}
+		if tag == "" {
+			return Stream{}, fmt.Errorf("missing tag for stream: %q", s)
+		}
+		return Stream{Name: name, Tag: tag}, nil
+	}
+
+	if list, ok := strings.CutPrefix(s, "list:"); ok {
+		if list == "" {
+			return Stream{}, fmt.Errorf("missing list for stream: %q", s)
+		}
+		return Stream{Name: "list", List: list}, nil
+	}
+
+	return Stream{}, fmt.Errorf("unknown stream: %q", s)
+}
+
+func ParseStreams(specs []string) (streams []Stream, errs error) {
+	for _, spec := range specs {
+		stream, err := ParseStream(spec)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		streams = append(streams, stream)
+	}
+	return
+}
+
+func (s Stream) String() string {
+	switch {
+	case s.Tag != "":
+		return s.Name + ":" + s.Tag
+	case s.List != "":
+		return s.Name + ":" + s.List
+	default:
+		return s.Name
+	}
 }
 
 func NewMastodon(
 	dialer wrapper.Dialer,
 	timer wrapper.Timer,
-	serverURL, accessToken, stream string,
+	serverURL, accessToken string,
+	streams []Stream,
+	state PollingState,
 ) service.Streaming {
-	return &mastodon{
+	m := &mastodon{
 		ch: make(chan service.Status),
 		Client: mast.NewClient(&mast.Config{
 			Server:      serverURL,
 			AccessToken: accessToken,
 		}),
-		Dialer: dialer,
-		Timer:  timer,
-		Stream: stream,
+		Dialer:  dialer,
+		Timer:   timer,
+		Streams: streams,
+		State:   state,
 	}
+	m.sources = newPollingSources(m.Client, streams)
+	return m
 }
 
 func stripTags(s string) string {
@@ -87,6 +186,18 @@ func convertEmojis(emojis []mast.Emoji) []service.Emoji {
 	return result
 }
 
+func convertMentions(mentions []mast.Mention) []service.Mention {
+	result := make([]service.Mention, 0, len(mentions))
+	for _, v := range mentions {
+		result = append(result, service.Mention{
+			ID:       string(v.ID),
+			Acct:     v.Acct,
+			Username: v.Username,
+		})
+	}
+	return result
+}
+
 func convertTags(tags []mast.Tag) []service.Tag {
 	result := make([]service.Tag, 0, len(tags))
 
@@ -99,6 +210,64 @@ func convertTags(tags []mast.Tag) []service.Tag {
 	return result
 }
 
+func convertAccount(account mast.Account) service.Account {
+	return service.Account{
+		ID:          string(account.ID),
+		Acct:        account.Acct,
+		DisplayName: account.DisplayName,
+		Username:    account.Username,
+	}
+}
+
+func convertMessage(status mast.Status) service.Message {
+	message := service.Message{
+		ID:         string(status.ID),
+		Account:    convertAccount(status.Account),
+		CreatedAt:  status.CreatedAt,
+		EditedAt:   status.EditedAt,
+		Content:    convertContent(status.Content),
+		Emojis:     convertEmojis(status.Emojis),
+		IsReblog:   status.Reblog != nil,
+		Mentions:   convertMentions(status.Mentions),
+		Tags:       convertTags(status.Tags),
+		Visibility: status.Visibility,
+	}
+
+	if status.InReplyToID != nil {
+		message.InReplyToID = status.InReplyToID.(string)
+	}
+
+	return message
+}
+
+func convertNotification(notification mast.Notification) service.Notification {
+	result := service.Notification{
+		ID:        string(notification.ID),
+		Type:      notification.Type,
+		Account:   convertAccount(notification.Account),
+		CreatedAt: notification.CreatedAt,
+	}
+
+	if notification.Status != nil {
+		result.StatusID = string(notification.Status.ID)
+	}
+
+	return result
+}
+
+func convertNotificationStatus(notification mast.Notification) service.Status {
+	switch {
+	case notification.Type == "mention" && notification.Status != nil:
+		return convertMessage(*notification.Status)
+
+	case slices.Contains(NotificationTypes, notification.Type):
+		return convertNotification(notification)
+
+	default:
+		return nil
+	}
+}
+
 func (m *mastodon) Statuses() <-chan service.Status {
 	return m.ch
 }
@@ -116,44 +285,12 @@ func (m *mastodon) Close(exit bool) error {
 }
 
 func (m *mastodon) reconnect(ctx context.Context, current time.Duration, err error) (time.Duration, error) {
-	var reconnect time.Duration
-	select {
-	case <-ctx.Done():
-		return reconnect, ctx.Err()
-
-	case m.ch <- service.Error{Err: err}:
-		break
-	}
-
-	reconnect = time.Duration(
-		min(
-			max(
-				current*2,
-				ReconnectInitial,
-			),
-			ReconnectMax,
-		),
-	)
-	StreamingRetryTotal.Inc()
-
-	select {
-	case <-ctx.Done():
-		return reconnect, ctx.Err()
-
-	case m.ch <- service.Reconnection{In: reconnect}:
-		break
-	}
-
-	select {
-	case <-ctx.Done():
-		return reconnect, ctx.Err()
-
-	case <-m.Timer.After(reconnect):
-		return reconnect, nil
-	}
+	return reconnect(ctx, m.ch, m.Timer, current, err)
 }
 
 func (m *mastodon) disconnect(ctx context.Context, err error) error {
+	m.save()
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -175,12 +312,102 @@ func (m *mastodon) disconnect(ctx context.Context, err error) error {
 	}
 }
 
+func (m *mastodon) subscribe() error {
+	for _, stream := range m.Streams {
+		StreamingStreamMessageTotal.WithLabelValues(stream.String())
+
+		err := m.conn.WriteJSON(StreamRequest{
+			Type:   "subscribe",
+			Stream: stream.Name,
+			Tag:    stream.Tag,
+			List:   stream.List,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", stream, err)
+		}
+	}
+	return nil
+}
+
+// sourceName returns the name of the polling source that corresponds to the event.
+func sourceName(event StreamEvent) string {
+	if event.Event == "notification" {
+		return "notifications"
+	}
+	if len(event.Stream) == 0 {
+		return ""
+	}
+	if event.Stream[0] == "user" {
+		return "home"
+	}
+	return strings.Join(event.Stream, ":")
+}
+
+func (m *mastodon) published(name string, id mast.ID) {
+	if name == "" || id == "" {
+		return
+	}
+	m.State.Update(name, id)
+	if time.Since(m.saved) >= StateSaveInterval {
+		m.save()
+	}
+}
+
+func (m *mastodon) save() {
+	m.saved = time.Now()
+	err := m.State.Flush()
+	if err != nil {
+		slog.Warn("Failed to save the last seen IDs", slog.Any("err", err))
+	}
+}
+
+// backfill fetches statuses that were posted while disconnected and sends them before resuming streaming.
+// Sources that have never been seen are seeded with the newest ID instead, so that the next reconnection can fill the gap.
+func (m *mastodon) backfill(ctx context.Context) error {
+	for _, source := range m.sources {
+		var err error
+		since, ok := m.State.ID(source.Name)
+		if ok {
+			err = fetchSince(ctx, source, since, func(items []pollingItem) error {
+				for _, item := range items {
+					if item.Status == nil {
+						continue
+					}
+
+					StreamingBackfillMessageTotal.WithLabelValues(source.Name).Inc()
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+
+					case m.ch <- item.Status:
+					}
+				}
+				m.published(source.Name, items[len(items)-1].ID)
+				return nil
+			})
+		} else {
+			err = seed(ctx, source, m.State)
+		}
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case m.ch <- service.Error{Err: err}:
+			}
+		}
+	}
+	return nil
+}
+
 func (m *mastodon) Run(ctx context.Context) error {
 	reconnect := ReconnectNone
 
 	params := url.Values{}
 	params.Set("access_token", m.Client.Config.AccessToken)
-	params.Set("stream", m.Stream)
 
 	u, err := url.Parse(m.Client.Config.Server)
 	if err != nil {
@@ -190,6 +417,12 @@ func (m *mastodon) Run(ctx context.Context) error {
 	u.Path = path.Join(u.Path, "/api/v1/streaming")
 	u.RawQuery = params.Encode()
 
+	err = m.State.Load()
+	if err != nil {
+		return err
+	}
+	defer m.save()
+
 	for {
 		slog.Debug("Connecting to streaming...")
 
@@ -220,9 +453,23 @@ func (m *mastodon) Run(ctx context.Context) error {
 			}
 		}
 
+		err = m.subscribe()
+		if err != nil {
+			err := m.disconnect(ctx, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = m.backfill(ctx)
+		if err != nil {
+			return err
+		}
+
 		for {
-			var stream mast.Stream
-			err := m.conn.ReadJSON(&stream)
+			var event StreamEvent
+			err := m.conn.ReadJSON(&event)
 			if err != nil {
 				err := m.disconnect(ctx, err)
 				if err != nil {
@@ -231,20 +478,39 @@ func (m *mastodon) Run(ctx context.Context) error {
 				break
 			}
 
-			var status mast.Status
-			switch stream.Event {
-			case "update":
-				err = json.NewDecoder(strings.NewReader(stream.Payload.(string))).Decode(&status)
+			var (
+				packet service.Status
+				id     mast.ID
+			)
+			switch event.Event {
+			case "update", "status.update":
+				var status mast.Status
+				err = json.NewDecoder(strings.NewReader(event.Payload)).Decode(&status)
+				packet = convertMessage(status)
+				id = status.ID
 
 			case "conversation":
 				var conversation mast.Conversation
-				err = json.NewDecoder(strings.NewReader(stream.Payload.(string))).Decode(&conversation)
+				err = json.NewDecoder(strings.NewReader(event.Payload)).Decode(&conversation)
+
+				var status mast.Status
 				if conversation.LastStatus != nil {
 					status = *conversation.LastStatus
 				}
-
-			default:
-				continue
+				packet = convertMessage(status)
+				id = status.ID
+
+			case "notification":
+				var notification mast.Notification
+				err = json.NewDecoder(strings.NewReader(event.Payload)).Decode(&notification)
+				packet = convertNotificationStatus(notification)
+				id = notification.ID
+
+			case "delete":
+				packet = service.Deletion{
+					ID:        event.Payload,
+					DeletedAt: time.Now(),
+				}
 			}
 
 			if err != nil {
@@ -257,32 +523,18 @@ func (m *mastodon) Run(ctx context.Context) error {
 				}
 			}
 
-			message := service.Message{
-				ID: string(status.ID),
-				Account: service.Account{
-					ID:          string(status.Account.ID),
-					Acct:        status.Account.Acct,
-					DisplayName: status.Account.DisplayName,
-					Username:    status.Account.Username,
-				},
-				CreatedAt:  status.CreatedAt,
-				Content:    convertContent(status.Content),
-				Emojis:     convertEmojis(status.Emojis),
-				IsReblog:   status.Reblog != nil,
-				Tags:       convertTags(status.Tags),
-				Visibility: status.Visibility,
-			}
-
-			if status.InReplyToID != nil {
-				message.InReplyToID = status.InReplyToID.(string)
+			if packet == nil {
+				continue
 			}
 
 			StreamingMessageTotal.WithLabelValues(server).Inc()
+			StreamingStreamMessageTotal.WithLabelValues(strings.Join(event.Stream, ":")).Inc()
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 
-			case m.ch <- message:
+			case m.ch <- packet:
+				m.published(sourceName(event), id)
 			}
 		}
 	}
@@ -5,18 +5,49 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
 	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
 	"github.com/chitoku-k/ejaculation-counter/supplier/service"
 	"github.com/gorilla/websocket"
-	mast "github.com/mattn/go-mastodon"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 	"go.uber.org/mock/gomock"
 )
 
+var _ = Describe("ParseStream()", func() {
+	DescribeTable("valid stream is given",
+		func(s string, expected streaming.Stream) {
+			actual, err := streaming.ParseStream(s)
+			Expect(actual).To(Equal(expected))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.String()).To(Equal(s))
+		},
+		Entry("user", "user", streaming.Stream{Name: "user"}),
+		Entry("user:notification", "user:notification", streaming.Stream{Name: "user:notification"}),
+		Entry("direct", "direct", streaming.Stream{Name: "direct"}),
+		Entry("hashtag", "hashtag:ejaculation_counter", streaming.Stream{Name: "hashtag", Tag: "ejaculation_counter"}),
+		Entry("hashtag:local", "hashtag:local:ejaculation_counter", streaming.Stream{Name: "hashtag:local", Tag: "ejaculation_counter"}),
+		Entry("list", "list:1", streaming.Stream{Name: "list", List: "1"}),
+	)
+
+	DescribeTable("invalid stream is given",
+		func(s string, expected string) {
+			_, err := streaming.ParseStream(s)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("unknown", "home", `unknown stream: "home"`),
+		Entry("hashtag without tag", "hashtag:", `missing tag for stream: "hashtag:"`),
+		Entry("list without ID", "list:", `missing list for stream: "list:"`),
+	)
+})
+
 var _ = Describe("Mastodon", func() {
 	var (
 		ctrl *gomock.Controller
@@ -38,20 +69,21 @@ var _ = Describe("Mastodon", func() {
 
 	Describe("Run()", func() {
 		var (
-			serverURL   string
-			accessToken string
-			mastodon    service.Streaming
-			ctx         context.Context
-			cancel      context.CancelFunc
-			ch          chan time.Time
-			stream      mast.Stream
+			serverURL    string
+			streamingURL string
+			accessToken  string
+			mastodon     service.Streaming
+			ctx          context.Context
+			cancel       context.CancelFunc
+			ch           chan time.Time
+			stream       streaming.StreamEvent
 		)
 
 		Context("parsing server URL fails", func() {
 			BeforeEach(func() {
 				serverURL = ":/"
 				accessToken = "token"
-				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, "user")
+				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(""))
 			})
 
 			It("returns an error", func() {
@@ -61,13 +93,25 @@ var _ = Describe("Mastodon", func() {
 		})
 
 		Context("parsing server URL succeeds", func() {
+			var (
+				server *ghttp.Server
+			)
+
 			BeforeEach(func() {
-				serverURL = "https://mastodon.example.com"
+				server = ghttp.NewServer()
+				server.RouteToHandler(http.MethodGet, regexp.MustCompile(`^/api/v1/(timelines/.+|notifications)$`), ghttp.RespondWith(http.StatusOK, `[]`))
+
+				serverURL = server.URL()
+				streamingURL = strings.Replace(serverURL, "http", "ws", 1) + "/api/v1/streaming?access_token=token"
 				accessToken = "token"
-				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, "user")
+				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(""))
 				ctx, cancel = context.WithCancel(context.Background())
 			})
 
+			AfterEach(func() {
+				server.Close()
+			})
+
 			Context("websocket connection fails", func() {
 				Context("HTTP response unavailable", func() {
 					BeforeEach(func() {
@@ -80,7 +124,7 @@ var _ = Describe("Mastodon", func() {
 							// (1)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -90,7 +134,7 @@ var _ = Describe("Mastodon", func() {
 							// (2)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Do(func(context.Context, string, http.Header) {
 								cancel()
@@ -132,7 +176,7 @@ var _ = Describe("Mastodon", func() {
 							// (1)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -144,7 +188,7 @@ var _ = Describe("Mastodon", func() {
 							// (2)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Do(func(context.Context, string, http.Header) {
 								cancel()
@@ -177,6 +221,102 @@ var _ = Describe("Mastodon", func() {
 			})
 
 			Context("websocket connection succeeds", func() {
+				Context("subscribing fails", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							// (1)
+							d.EXPECT().DialContext(
+								ctx,
+								streamingURL,
+								nil,
+							).Return(
+								conn,
+								&http.Response{
+									Header: http.Header{
+										"X-Served-By": []string{"192.0.2.1:4000"},
+									},
+								},
+								nil,
+							),
+							// (2)
+							d.EXPECT().DialContext(
+								ctx,
+								streamingURL,
+								nil,
+							).Do(func(context.Context, string, http.Header) {
+								cancel()
+							}).Return(
+								nil,
+								nil,
+								context.Canceled,
+							),
+						)
+
+						message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
+						conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(errors.New("broken pipe"))
+						conn.EXPECT().WriteMessage(websocket.CloseMessage, message)
+						conn.EXPECT().Close().Return(nil)
+					})
+
+					It("sends disconnection and eventually exits", func() {
+						actual := mastodon.Statuses()
+						go func() {
+							defer GinkgoRecover()
+
+							err := mastodon.Run(ctx)
+							Expect(err).To(Equal(context.Canceled))
+						}()
+
+						Eventually(actual).Should(Receive(Equal(service.Connection{
+							Server: "192.0.2.1:4000",
+						})))
+						Eventually(actual).Should(Receive(WithTransform(func(m service.Disconnection) error {
+							return m.Err
+						}, MatchError("failed to subscribe to user: broken pipe"))))
+						Eventually(ctx.Done()).Should(BeClosed())
+					})
+				})
+
+				Context("multiple streams are given", func() {
+					BeforeEach(func() {
+						mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{
+							{Name: "user:notification"},
+							{Name: "hashtag", Tag: "ejaculation_counter"},
+							{Name: "list", List: "1"},
+						}, streaming.NewPollingState(""))
+
+						d.EXPECT().DialContext(
+							ctx,
+							streamingURL,
+							nil,
+						).Return(
+							conn,
+							&http.Response{},
+							nil,
+						)
+
+						gomock.InOrder(
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user:notification"}).Return(nil),
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "hashtag", Tag: "ejaculation_counter"}).Return(nil),
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "list", List: "1"}).Return(nil),
+							conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+								cancel()
+							}).Return(context.Canceled),
+						)
+					})
+
+					It("subscribes to all streams and eventually exits", func() {
+						go func() {
+							defer GinkgoRecover()
+
+							err := mastodon.Run(ctx)
+							Expect(err).To(Equal(context.Canceled))
+						}()
+
+						Eventually(ctx.Done()).Should(BeClosed())
+					})
+				})
+
 				Context("event cannot be read", func() {
 					Context("connection cannot be closed", func() {
 						BeforeEach(func() {
@@ -184,7 +324,7 @@ var _ = Describe("Mastodon", func() {
 								// (1)
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -198,7 +338,7 @@ var _ = Describe("Mastodon", func() {
 								// (2)
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Do(func(context.Context, string, http.Header) {
 									cancel()
@@ -209,6 +349,8 @@ var _ = Describe("Mastodon", func() {
 								),
 							)
 
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 							message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
 							conn.EXPECT().ReadJSON(&stream).Return(errors.New("dial tcp [::1]:443: connect: connection refused"))
 							conn.EXPECT().WriteMessage(websocket.CloseMessage, message)
@@ -243,7 +385,7 @@ var _ = Describe("Mastodon", func() {
 								// (1)
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -257,7 +399,7 @@ var _ = Describe("Mastodon", func() {
 								// (2)
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Do(func(context.Context, string, http.Header) {
 									cancel()
@@ -268,6 +410,8 @@ var _ = Describe("Mastodon", func() {
 								),
 							)
 
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 							message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
 							conn.EXPECT().ReadJSON(&stream).Return(errors.New("dial tcp [::1]:443: connect: connection refused"))
 							conn.EXPECT().WriteMessage(websocket.CloseMessage, message)
@@ -295,11 +439,11 @@ var _ = Describe("Mastodon", func() {
 				})
 
 				Context("event is read", func() {
-					Context("event is not update", func() {
+					Context("event is not handled", func() {
 						BeforeEach(func() {
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								conn,
@@ -311,16 +455,17 @@ var _ = Describe("Mastodon", func() {
 								nil,
 							)
 
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 							gomock.InOrder(
 								// (1)
-								conn.EXPECT().ReadJSON(&stream).Do(func(s *mast.Stream) {
-									*s = mast.Stream{
-										Event:   "notification",
-										Payload: "{}",
+								conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+									*s = streaming.StreamEvent{
+										Event: "filters_changed",
 									}
 								}).Return(nil),
 								// (2)
-								conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+								conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 									cancel()
 								}).Return(context.Canceled),
 							)
@@ -347,7 +492,7 @@ var _ = Describe("Mastodon", func() {
 							BeforeEach(func() {
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -359,16 +504,18 @@ var _ = Describe("Mastodon", func() {
 									nil,
 								)
 
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 								gomock.InOrder(
 									// (1)
-									conn.EXPECT().ReadJSON(&stream).Do(func(s *mast.Stream) {
-										*s = mast.Stream{
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
 											Event:   "update",
 											Payload: "{",
 										}
 									}).Return(nil),
 									// (2)
-									conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 										cancel()
 									}).Return(context.Canceled),
 								)
@@ -397,7 +544,7 @@ var _ = Describe("Mastodon", func() {
 							BeforeEach(func() {
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -409,10 +556,12 @@ var _ = Describe("Mastodon", func() {
 									nil,
 								)
 
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 								gomock.InOrder(
 									// (1)
-									conn.EXPECT().ReadJSON(&stream).Do(func(s *mast.Stream) {
-										*s = mast.Stream{
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
 											Event: "update",
 											Payload: `
 												{
@@ -438,6 +587,13 @@ var _ = Describe("Mastodon", func() {
 															"shortcode": "ios_big_sushi_4"
 														}
 													],
+													"mentions": [
+														{
+															"id": "3",
+															"acct": "test2@example.com",
+															"username": "test2"
+														}
+													],
 													"reblog": null,
 													"in_reply_to_id": "1",
 													"tags": [
@@ -451,7 +607,7 @@ var _ = Describe("Mastodon", func() {
 										}
 									}).Return(nil),
 									// (2)
-									conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 										cancel()
 									}).Return(context.Canceled),
 								)
@@ -484,6 +640,9 @@ var _ = Describe("Mastodon", func() {
 										{Shortcode: "ios_big_sushi_3"},
 										{Shortcode: "ios_big_sushi_4"},
 									},
+									Mentions: []service.Mention{
+										{ID: "3", Acct: "test2@example.com", Username: "test2"},
+									},
 									Tags: []service.Tag{
 										{Name: "同人avタイトルジェネレーター"},
 									},
@@ -496,12 +655,459 @@ var _ = Describe("Mastodon", func() {
 						})
 					})
 
+					Context("event is notification", func() {
+						Context("notification is mention", func() {
+							BeforeEach(func() {
+								d.EXPECT().DialContext(
+									ctx,
+									streamingURL,
+									nil,
+								).Return(
+									conn,
+									&http.Response{
+										Header: http.Header{
+											"X-Served-By": []string{"192.0.2.1:4000"},
+										},
+									},
+									nil,
+								)
+
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+								gomock.InOrder(
+									// (1)
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
+											Stream: []string{"user"},
+											Event:  "notification",
+											Payload: `
+												{
+													"id": "10",
+													"type": "mention",
+													"account": {
+														"id": "1",
+														"acct": "@test",
+														"display_name": "テスト",
+														"username": "test"
+													},
+													"status": {
+														"id": "2",
+														"account": {
+															"id": "1",
+															"acct": "@test",
+															"display_name": "テスト",
+															"username": "test"
+														},
+														"content": "<p>@ejaculation_counter テスト</p>",
+														"emojis": [],
+														"mentions": [
+															{
+																"id": "3",
+																"acct": "ejaculation_counter",
+																"username": "ejaculation_counter"
+															}
+														],
+														"reblog": null,
+														"in_reply_to_id": null,
+														"tags": [],
+														"visibility": "public"
+													}
+												}
+											`,
+										}
+									}).Return(nil),
+									// (2)
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+										cancel()
+									}).Return(context.Canceled),
+								)
+							})
+
+							It("sends status and eventually exits", func() {
+								actual := mastodon.Statuses()
+								go func() {
+									defer GinkgoRecover()
+
+									err := mastodon.Run(ctx)
+									Expect(err).To(Equal(context.Canceled))
+								}()
+
+								Eventually(actual).Should(Receive(Equal(service.Connection{
+									Server: "192.0.2.1:4000",
+								})))
+								Eventually(actual).Should(Receive(Equal(service.Message{
+									ID: "2",
+									Account: service.Account{
+										ID:          "1",
+										Acct:        "@test",
+										DisplayName: "テスト",
+										Username:    "test",
+									},
+									Content: "@ejaculation_counter テスト",
+									Emojis:  []service.Emoji{},
+									Mentions: []service.Mention{
+										{ID: "3", Acct: "ejaculation_counter", Username: "ejaculation_counter"},
+									},
+									Tags:       []service.Tag{},
+									Visibility: "public",
+								})))
+								Eventually(ctx.Done()).Should(BeClosed())
+							})
+						})
+
+						Context("notification is follow", func() {
+							BeforeEach(func() {
+								d.EXPECT().DialContext(
+									ctx,
+									streamingURL,
+									nil,
+								).Return(
+									conn,
+									&http.Response{
+										Header: http.Header{
+											"X-Served-By": []string{"192.0.2.1:4000"},
+										},
+									},
+									nil,
+								)
+
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+								gomock.InOrder(
+									// (1)
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
+											Stream: []string{"user"},
+											Event:  "notification",
+											Payload: `
+												{
+													"id": "10",
+													"type": "follow",
+													"account": {
+														"id": "1",
+														"acct": "@test",
+														"display_name": "テスト",
+														"username": "test"
+													}
+												}
+											`,
+										}
+									}).Return(nil),
+									// (2)
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+										cancel()
+									}).Return(context.Canceled),
+								)
+							})
+
+							It("sends notification and eventually exits", func() {
+								actual := mastodon.Statuses()
+								go func() {
+									defer GinkgoRecover()
+
+									err := mastodon.Run(ctx)
+									Expect(err).To(Equal(context.Canceled))
+								}()
+
+								Eventually(actual).Should(Receive(Equal(service.Connection{
+									Server: "192.0.2.1:4000",
+								})))
+								Eventually(actual).Should(Receive(Equal(service.Notification{
+									ID:   "10",
+									Type: "follow",
+									Account: service.Account{
+										ID:          "1",
+										Acct:        "@test",
+										DisplayName: "テスト",
+										Username:    "test",
+									},
+								})))
+								Eventually(ctx.Done()).Should(BeClosed())
+							})
+						})
+
+						Context("notification is favourite", func() {
+							BeforeEach(func() {
+								d.EXPECT().DialContext(
+									ctx,
+									streamingURL,
+									nil,
+								).Return(
+									conn,
+									&http.Response{
+										Header: http.Header{
+											"X-Served-By": []string{"192.0.2.1:4000"},
+										},
+									},
+									nil,
+								)
+
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+								gomock.InOrder(
+									// (1)
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
+											Stream: []string{"user"},
+											Event:  "notification",
+											Payload: `
+												{
+													"id": "10",
+													"type": "favourite",
+													"account": {
+														"id": "1",
+														"acct": "@test",
+														"display_name": "テスト",
+														"username": "test"
+													},
+													"status": {
+														"id": "2"
+													}
+												}
+											`,
+										}
+									}).Return(nil),
+									// (2)
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+										cancel()
+									}).Return(context.Canceled),
+								)
+							})
+
+							It("sends notification and eventually exits", func() {
+								actual := mastodon.Statuses()
+								go func() {
+									defer GinkgoRecover()
+
+									err := mastodon.Run(ctx)
+									Expect(err).To(Equal(context.Canceled))
+								}()
+
+								Eventually(actual).Should(Receive(Equal(service.Connection{
+									Server: "192.0.2.1:4000",
+								})))
+								Eventually(actual).Should(Receive(Equal(service.Notification{
+									ID:   "10",
+									Type: "favourite",
+									Account: service.Account{
+										ID:          "1",
+										Acct:        "@test",
+										DisplayName: "テスト",
+										Username:    "test",
+									},
+									StatusID: "2",
+								})))
+								Eventually(ctx.Done()).Should(BeClosed())
+							})
+						})
+
+						Context("notification is not handled", func() {
+							BeforeEach(func() {
+								d.EXPECT().DialContext(
+									ctx,
+									streamingURL,
+									nil,
+								).Return(
+									conn,
+									&http.Response{
+										Header: http.Header{
+											"X-Served-By": []string{"192.0.2.1:4000"},
+										},
+									},
+									nil,
+								)
+
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+								gomock.InOrder(
+									// (1)
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
+											Stream: []string{"user"},
+											Event:  "notification",
+											Payload: `
+												{
+													"id": "10",
+													"type": "poll",
+													"account": {
+														"id": "1",
+														"acct": "@test",
+														"display_name": "テスト",
+														"username": "test"
+													}
+												}
+											`,
+										}
+									}).Return(nil),
+									// (2)
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+										cancel()
+									}).Return(context.Canceled),
+								)
+							})
+
+							It("eventually exits", func() {
+								actual := mastodon.Statuses()
+								go func() {
+									defer GinkgoRecover()
+
+									err := mastodon.Run(ctx)
+									Expect(err).To(Equal(context.Canceled))
+								}()
+
+								Eventually(actual).Should(Receive(Equal(service.Connection{
+									Server: "192.0.2.1:4000",
+								})))
+								Consistently(actual).ShouldNot(Receive())
+								Eventually(ctx.Done()).Should(BeClosed())
+							})
+						})
+					})
+
+					Context("event is status.update", func() {
+						BeforeEach(func() {
+							d.EXPECT().DialContext(
+								ctx,
+								streamingURL,
+								nil,
+							).Return(
+								conn,
+								&http.Response{
+									Header: http.Header{
+										"X-Served-By": []string{"192.0.2.1:4000"},
+									},
+								},
+								nil,
+							)
+
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+							gomock.InOrder(
+								// (1)
+								conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+									*s = streaming.StreamEvent{
+										Stream: []string{"user"},
+										Event:  "status.update",
+										Payload: `
+											{
+												"id": "2",
+												"account": {
+													"id": "1",
+													"acct": "@test",
+													"display_name": "テスト",
+													"username": "test"
+												},
+												"created_at": "2006-01-02T15:04:05Z",
+												"edited_at": "2006-01-02T15:04:06Z",
+												"content": "<p>ぴゅっ♡</p>",
+												"emojis": [],
+												"mentions": [],
+												"reblog": null,
+												"in_reply_to_id": null,
+												"tags": [],
+												"visibility": "public"
+											}
+										`,
+									}
+								}).Return(nil),
+								// (2)
+								conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+									cancel()
+								}).Return(context.Canceled),
+							)
+						})
+
+						It("sends edited status and eventually exits", func() {
+							actual := mastodon.Statuses()
+							go func() {
+								defer GinkgoRecover()
+
+								err := mastodon.Run(ctx)
+								Expect(err).To(Equal(context.Canceled))
+							}()
+
+							Eventually(actual).Should(Receive(Equal(service.Connection{
+								Server: "192.0.2.1:4000",
+							})))
+							Eventually(actual).Should(Receive(Equal(service.Message{
+								ID: "2",
+								Account: service.Account{
+									ID:          "1",
+									Acct:        "@test",
+									DisplayName: "テスト",
+									Username:    "test",
+								},
+								CreatedAt:  time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+								EditedAt:   time.Date(2006, 1, 2, 15, 4, 6, 0, time.UTC),
+								Content:    "ぴゅっ♡",
+								Emojis:     []service.Emoji{},
+								Mentions:   []service.Mention{},
+								Tags:       []service.Tag{},
+								Visibility: "public",
+							})))
+							Eventually(ctx.Done()).Should(BeClosed())
+						})
+					})
+
+					Context("event is delete", func() {
+						BeforeEach(func() {
+							d.EXPECT().DialContext(
+								ctx,
+								streamingURL,
+								nil,
+							).Return(
+								conn,
+								&http.Response{
+									Header: http.Header{
+										"X-Served-By": []string{"192.0.2.1:4000"},
+									},
+								},
+								nil,
+							)
+
+							conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+							gomock.InOrder(
+								// (1)
+								conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+									*s = streaming.StreamEvent{
+										Stream:  []string{"user"},
+										Event:   "delete",
+										Payload: `2`,
+									}
+								}).Return(nil),
+								// (2)
+								conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+									cancel()
+								}).Return(context.Canceled),
+							)
+						})
+
+						It("sends deletion and eventually exits", func() {
+							actual := mastodon.Statuses()
+							go func() {
+								defer GinkgoRecover()
+
+								err := mastodon.Run(ctx)
+								Expect(err).To(Equal(context.Canceled))
+							}()
+
+							Eventually(actual).Should(Receive(Equal(service.Connection{
+								Server: "192.0.2.1:4000",
+							})))
+							Eventually(actual).Should(Receive(WithTransform(func(d service.Deletion) string {
+								return d.ID
+							}, Equal("2"))))
+							Eventually(ctx.Done()).Should(BeClosed())
+						})
+					})
+
 					Context("event is conversation", func() {
 						Context("parsing fails", func() {
 							BeforeEach(func() {
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -513,16 +1119,18 @@ var _ = Describe("Mastodon", func() {
 									nil,
 								)
 
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 								gomock.InOrder(
 									// (1)
-									conn.EXPECT().ReadJSON(&stream).Do(func(s *mast.Stream) {
-										*s = mast.Stream{
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
 											Event:   "conversation",
 											Payload: "{",
 										}
 									}).Return(nil),
 									// (2)
-									conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 										cancel()
 									}).Return(context.Canceled),
 								)
@@ -551,7 +1159,7 @@ var _ = Describe("Mastodon", func() {
 							BeforeEach(func() {
 								d.EXPECT().DialContext(
 									ctx,
-									"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+									streamingURL,
 									nil,
 								).Return(
 									conn,
@@ -563,10 +1171,12 @@ var _ = Describe("Mastodon", func() {
 									nil,
 								)
 
+								conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
 								gomock.InOrder(
 									// (1)
-									conn.EXPECT().ReadJSON(&stream).Do(func(s *mast.Stream) {
-										*s = mast.Stream{
+									conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+										*s = streaming.StreamEvent{
 											Event: "conversation",
 											Payload: `
 												{
@@ -607,7 +1217,7 @@ var _ = Describe("Mastodon", func() {
 										}
 									}).Return(nil),
 									// (2)
-									conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+									conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 										cancel()
 									}).Return(context.Canceled),
 								)
@@ -640,6 +1250,7 @@ var _ = Describe("Mastodon", func() {
 										{Shortcode: "ios_big_sushi_3"},
 										{Shortcode: "ios_big_sushi_4"},
 									},
+									Mentions: []service.Mention{},
 									Tags: []service.Tag{
 										{Name: "同人avタイトルジェネレーター"},
 									},
@@ -666,7 +1277,7 @@ var _ = Describe("Mastodon", func() {
 							// (1)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -676,7 +1287,7 @@ var _ = Describe("Mastodon", func() {
 							// (2)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								conn,
@@ -689,7 +1300,9 @@ var _ = Describe("Mastodon", func() {
 							),
 						)
 
-						conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+						conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+						conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 							cancel()
 						}).Return(context.Canceled)
 					})
@@ -731,7 +1344,7 @@ var _ = Describe("Mastodon", func() {
 							// (1)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -741,7 +1354,7 @@ var _ = Describe("Mastodon", func() {
 							// (2)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -751,7 +1364,7 @@ var _ = Describe("Mastodon", func() {
 							// (3)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								conn,
@@ -764,7 +1377,9 @@ var _ = Describe("Mastodon", func() {
 							),
 						)
 
-						conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+						conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+						conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 							cancel()
 						}).Return(context.Canceled)
 					})
@@ -814,7 +1429,7 @@ var _ = Describe("Mastodon", func() {
 							// (1)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -824,7 +1439,7 @@ var _ = Describe("Mastodon", func() {
 							// (2)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -834,7 +1449,7 @@ var _ = Describe("Mastodon", func() {
 							// (3)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								nil,
@@ -844,7 +1459,7 @@ var _ = Describe("Mastodon", func() {
 							// (4)
 							d.EXPECT().DialContext(
 								ctx,
-								"wss://mastodon.example.com/api/v1/streaming?access_token=token&stream=user",
+								streamingURL,
 								nil,
 							).Return(
 								conn,
@@ -857,7 +1472,9 @@ var _ = Describe("Mastodon", func() {
 							),
 						)
 
-						conn.EXPECT().ReadJSON(&stream).Do(func(*mast.Stream) {
+						conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil)
+
+						conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
 							cancel()
 						}).Return(context.Canceled)
 					})
@@ -897,6 +1514,210 @@ var _ = Describe("Mastodon", func() {
 				})
 			})
 		})
+
+		Context("reconnecting after statuses are published", func() {
+			var (
+				server *ghttp.Server
+			)
+
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				serverURL = server.URL()
+				accessToken = "token"
+				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(""))
+				ctx, cancel = context.WithCancel(context.Background())
+
+				streamingURL = strings.Replace(serverURL, "http", "ws", 1) + "/api/v1/streaming?access_token=token"
+				d.EXPECT().DialContext(ctx, streamingURL, nil).Return(
+					conn,
+					&http.Response{
+						Header: http.Header{
+							"X-Served-By": []string{"192.0.2.1:4000"},
+						},
+					},
+					nil,
+				).Times(2)
+
+				conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil).Times(2)
+
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
+				gomock.InOrder(
+					// (1)
+					conn.EXPECT().ReadJSON(&stream).Do(func(s *streaming.StreamEvent) {
+						*s = streaming.StreamEvent{
+							Stream:  []string{"user"},
+							Event:   "update",
+							Payload: `{"id":"100","account":{"id":"1","acct":"@test"},"content":"<p>切断前</p>"}`,
+						}
+					}).Return(nil),
+					// (2)
+					conn.EXPECT().ReadJSON(&stream).Return(errors.New("unexpected EOF")),
+					conn.EXPECT().WriteMessage(websocket.CloseMessage, message),
+					conn.EXPECT().Close().Return(nil),
+					// (3)
+					conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+						cancel()
+					}).Return(context.Canceled),
+				)
+
+				server.AppendHandlers(
+					// (1)
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+					// (3)
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "min_id=100&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"102","account":{"id":"1","acct":"@test"},"content":"<p>切断中 2</p>"},
+							{"id":"101","account":{"id":"1","acct":"@test"},"content":"<p>切断中 1</p>"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("sends statuses posted while disconnected before resuming", func() {
+				actual := mastodon.Statuses()
+				go func() {
+					defer GinkgoRecover()
+
+					err := mastodon.Run(ctx)
+					Expect(err).To(Equal(context.Canceled))
+				}()
+
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: "192.0.2.1:4000",
+				})))
+				Eventually(actual).Should(Receive(HaveField("ID", "100")))
+				Eventually(actual).Should(Receive(Equal(service.Disconnection{
+					Err: errors.New("unexpected EOF"),
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: "192.0.2.1:4000",
+				})))
+				Eventually(actual).Should(Receive(And(
+					HaveField("ID", "101"),
+					HaveField("Content", "切断中 1"),
+				)))
+				Eventually(actual).Should(Receive(And(
+					HaveField("ID", "102"),
+					HaveField("Content", "切断中 2"),
+				)))
+				Eventually(ctx.Done()).Should(BeClosed())
+			})
+		})
+
+		Context("reconnecting before any statuses are published", func() {
+			var (
+				server *ghttp.Server
+				state  string
+			)
+
+			BeforeEach(func() {
+				server = ghttp.NewServer()
+				serverURL = server.URL()
+				accessToken = "token"
+				state = filepath.Join(GinkgoT().TempDir(), "polling.json")
+				mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(state))
+				ctx, cancel = context.WithCancel(context.Background())
+
+				streamingURL = strings.Replace(serverURL, "http", "ws", 1) + "/api/v1/streaming?access_token=token"
+				d.EXPECT().DialContext(ctx, streamingURL, nil).Return(
+					conn,
+					&http.Response{
+						Header: http.Header{
+							"X-Served-By": []string{"192.0.2.1:4000"},
+						},
+					},
+					nil,
+				).Times(2)
+
+				conn.EXPECT().WriteJSON(streaming.StreamRequest{Type: "subscribe", Stream: "user"}).Return(nil).Times(2)
+
+				message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown")
+				gomock.InOrder(
+					// (1)
+					conn.EXPECT().ReadJSON(&stream).Return(errors.New("unexpected EOF")),
+					conn.EXPECT().WriteMessage(websocket.CloseMessage, message),
+					conn.EXPECT().Close().Return(nil),
+					// (2)
+					conn.EXPECT().ReadJSON(&stream).Do(func(*streaming.StreamEvent) {
+						cancel()
+					}).Return(context.Canceled),
+				)
+
+				server.AppendHandlers(
+					// (1)
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"100","account":{"id":"1","acct":"@test"},"content":"<p>接続前</p>"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"200","type":"follow","account":{"id":"2","acct":"@follower"}}
+						]`),
+					),
+					// (2)
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "min_id=100&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"101","account":{"id":"1","acct":"@test"},"content":"<p>切断中</p>"}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "min_id=200&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+				)
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("sends statuses posted after the newest ones on connection and saves the state", func() {
+				actual := mastodon.Statuses()
+				go func() {
+					defer GinkgoRecover()
+
+					err := mastodon.Run(ctx)
+					Expect(err).To(Equal(context.Canceled))
+				}()
+
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: "192.0.2.1:4000",
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Disconnection{
+					Err: errors.New("unexpected EOF"),
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: "192.0.2.1:4000",
+				})))
+				Eventually(actual).Should(Receive(And(
+					HaveField("ID", "101"),
+					HaveField("Content", "切断中"),
+				)))
+				Eventually(ctx.Done()).Should(BeClosed())
+
+				Expect(os.ReadFile(state)).To(MatchJSON(`{"home":"101","notifications":"200"}`))
+			})
+		})
 	})
 
 	Describe("Close()", func() {
@@ -911,7 +1732,7 @@ var _ = Describe("Mastodon", func() {
 				BeforeEach(func() {
 					serverURL = ":/"
 					accessToken = "token"
-					mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, "user")
+					mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(""))
 				})
 
 				It("does nothing", func() {
@@ -926,7 +1747,7 @@ var _ = Describe("Mastodon", func() {
 				BeforeEach(func() {
 					serverURL = ":/"
 					accessToken = "token"
-					mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, "user")
+					mastodon = streaming.NewMastodon(d, t, serverURL, accessToken, []streaming.Stream{{Name: "user"}}, streaming.NewPollingState(""))
 				})
 
 				It("does nothing", func() {
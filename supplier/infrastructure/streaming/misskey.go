@@ -0,0 +1,492 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	MisskeyChannels = []string{"main", "homeTimeline", "hybridTimeline"}
+
+	MisskeyMentionRegexp = regexp.MustCompile(`@([A-Za-z0-9_]+)(?:@([A-Za-z0-9.-]+[A-Za-z0-9]))?`)
+	MisskeyEmojiRegexp   = regexp.MustCompile(`:([A-Za-z0-9_+-]+):`)
+)
+
+type MisskeyRequest struct {
+	Type string             `json:"type"`
+	Body MisskeyRequestBody `json:"body"`
+}
+
+type MisskeyRequestBody struct {
+	Channel string `json:"channel"`
+	ID      string `json:"id"`
+}
+
+type MisskeyEvent struct {
+	Type string `json:"type"`
+	Body struct {
+		ID   string          `json:"id"`
+		Type string          `json:"type"`
+		Body json.RawMessage `json:"body"`
+	} `json:"body"`
+}
+
+type MisskeyUser struct {
+	ID       string  `json:"id"`
+	Name     *string `json:"name"`
+	Username string  `json:"username"`
+	Host     *string `json:"host"`
+}
+
+type MisskeyNote struct {
+	ID         string      `json:"id"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  *time.Time  `json:"updatedAt"`
+	User       MisskeyUser `json:"user"`
+	Text       *string     `json:"text"`
+	Visibility string      `json:"visibility"`
+	ReplyID    *string     `json:"replyId"`
+	RenoteID   *string     `json:"renoteId"`
+	Mentions   []string    `json:"mentions"`
+	Tags       []string    `json:"tags"`
+}
+
+type MisskeyNotification struct {
+	ID        string       `json:"id"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Type      string       `json:"type"`
+	User      *MisskeyUser `json:"user"`
+	Note      *MisskeyNote `json:"note"`
+}
+
+const (
+	MisskeyUserCacheSize = 1000
+)
+
+type misskey struct {
+	ch        chan service.Status
+	conn      wrapper.Conn
+	userIDs   map[string]string
+	Dialer    wrapper.Dialer
+	Timer     wrapper.Timer
+	Client    *http.Client
+	ServerURL string
+	Token     string
+	Channels  []string
+}
+
+func ParseMisskeyChannels(specs []string) (channels []string, err error) {
+	for _, spec := range specs {
+		if !slices.Contains(MisskeyChannels, spec) {
+			return nil, fmt.Errorf("unknown channel: %q", spec)
+		}
+		channels = append(channels, spec)
+	}
+	return
+}
+
+func NewMisskey(
+	dialer wrapper.Dialer,
+	timer wrapper.Timer,
+	client *http.Client,
+	serverURL, accessToken string,
+	channels []string,
+) service.Streaming {
+	return &misskey{
+		ch:        make(chan service.Status),
+		userIDs:   map[string]string{},
+		Dialer:    dialer,
+		Timer:     timer,
+		Client:    client,
+		ServerURL: serverURL,
+		Token:     accessToken,
+		Channels:  channels,
+	}
+}
+
+func convertMisskeyAccount(user MisskeyUser) service.Account {
+	account := service.Account{
+		ID:          user.ID,
+		Acct:        user.Username,
+		DisplayName: user.Username,
+		Username:    user.Username,
+	}
+	if user.Host != nil {
+		account.Acct += "@" + *user.Host
+	}
+	if user.Name != nil {
+		account.DisplayName = *user.Name
+	}
+	return account
+}
+
+func convertMisskeyVisibility(visibility string) string {
+	switch visibility {
+	case "home":
+		return "unlisted"
+	case "followers":
+		return "private"
+	case "specified":
+		return "direct"
+	default:
+		return "public"
+	}
+}
+
+func convertMisskeyEmojis(text string) []service.Emoji {
+	result := []service.Emoji{}
+	for _, m := range MisskeyEmojiRegexp.FindAllStringSubmatch(text, -1) {
+		emoji := service.Emoji{Shortcode: m[1]}
+		if !slices.Contains(result, emoji) {
+			result = append(result, emoji)
+		}
+	}
+	return result
+}
+
+// convertMisskeyMentions extracts mentions from text, as notes only carry the IDs of mentioned users.
+// The IDs are left empty to be resolved by the usernames and hosts.
+func convertMisskeyMentions(text string) []service.Mention {
+	result := []service.Mention{}
+	for _, m := range MisskeyMentionRegexp.FindAllStringSubmatch(text, -1) {
+		mention := service.Mention{
+			Acct:     m[1],
+			Username: m[1],
+		}
+		if m[2] != "" {
+			mention.Acct += "@" + m[2]
+		}
+		if !slices.ContainsFunc(result, func(v service.Mention) bool { return v.Acct == mention.Acct }) {
+			result = append(result, mention)
+		}
+	}
+	return result
+}
+
+func convertMisskeyTags(tags []string) []service.Tag {
+	result := make([]service.Tag, 0, len(tags))
+	for _, v := range tags {
+		result = append(result, service.Tag{
+			Name: v,
+		})
+	}
+	return result
+}
+
+func convertMisskeyNote(note MisskeyNote) service.Message {
+	var text string
+	if note.Text != nil {
+		text = *note.Text
+	}
+
+	message := service.Message{
+		ID:         note.ID,
+		Account:    convertMisskeyAccount(note.User),
+		CreatedAt:  note.CreatedAt,
+		Content:    text,
+		Emojis:     convertMisskeyEmojis(text),
+		IsReblog:   note.RenoteID != nil && note.Text == nil,
+		Mentions:   convertMisskeyMentions(text),
+		Tags:       convertMisskeyTags(note.Tags),
+		Visibility: convertMisskeyVisibility(note.Visibility),
+	}
+
+	if note.UpdatedAt != nil {
+		message.EditedAt = *note.UpdatedAt
+	}
+	if note.ReplyID != nil {
+		message.InReplyToID = *note.ReplyID
+	}
+
+	return message
+}
+
+func convertMisskeyNotification(notification MisskeyNotification) service.Status {
+	var t string
+	switch notification.Type {
+	case "follow":
+		t = "follow"
+	case "reaction":
+		t = "favourite"
+	case "renote":
+		t = "reblog"
+	default:
+		return nil
+	}
+
+	result := service.Notification{
+		ID:        notification.ID,
+		Type:      t,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.User != nil {
+		result.Account = convertMisskeyAccount(*notification.User)
+	}
+	if notification.Note != nil {
+		result.StatusID = notification.Note.ID
+	}
+
+	return result
+}
+
+// userID looks up the ID of the user by the username and host of the mention.
+func (m *misskey) userID(ctx context.Context, mention service.Mention) (string, error) {
+	if id, ok := m.userIDs[mention.Acct]; ok {
+		return id, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, LookupTimeout)
+	defer cancel()
+
+	params := map[string]any{
+		"i":        m.Token,
+		"username": mention.Username,
+		"host":     nil,
+	}
+	if _, host, ok := strings.Cut(mention.Acct, "@"); ok {
+		params["host"] = host
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	u, err := url.Parse(m.ServerURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, "/api/users/show")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := m.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call users/show: %w", err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 399 {
+		return "", fmt.Errorf("failed response from users/show (%v)", res.Status)
+	}
+
+	var user MisskeyUser
+	err = json.NewDecoder(res.Body).Decode(&user)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode response from users/show: %w", err)
+	}
+
+	if len(m.userIDs) >= MisskeyUserCacheSize {
+		clear(m.userIDs)
+	}
+	m.userIDs[mention.Acct] = user.ID
+	return user.ID, nil
+}
+
+// resolveMentions fills in the IDs of the mentions with the users looked up by their usernames and hosts,
+// dropping ones that cannot be looked up or are not among the IDs carried by the note as they are not actually mentioned.
+func (m *misskey) resolveMentions(ctx context.Context, mentions []service.Mention, ids []string) []service.Mention {
+	resolved := []service.Mention{}
+	if len(ids) == 0 {
+		return resolved
+	}
+
+	for _, mention := range mentions {
+		id, err := m.userID(ctx, mention)
+		if err != nil {
+			slog.Warn("Failed to resolve mention", slog.String("acct", mention.Acct), slog.Any("err", err))
+			continue
+		}
+		if slices.Contains(ids, id) {
+			mention.ID = id
+			resolved = append(resolved, mention)
+		}
+	}
+	return resolved
+}
+
+func (m *misskey) Statuses() <-chan service.Status {
+	return m.ch
+}
+
+func (m *misskey) Close(exit bool) error {
+	if exit {
+		close(m.ch)
+		m.ch = nil
+	}
+	if m.conn != nil {
+		_ = m.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "Shutdown"))
+		return m.conn.Close()
+	}
+	return nil
+}
+
+func (m *misskey) reconnect(ctx context.Context, current time.Duration, err error) (time.Duration, error) {
+	return reconnect(ctx, m.ch, m.Timer, current, err)
+}
+
+func (m *misskey) disconnect(ctx context.Context, err error) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case m.ch <- service.Disconnection{Err: err}:
+		err = m.Close(false)
+		if err == nil {
+			return nil
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case m.ch <- service.Error{Err: err}:
+		m.conn = nil
+		return nil
+	}
+}
+
+func (m *misskey) subscribe() error {
+	for _, channel := range m.Channels {
+		StreamingStreamMessageTotal.WithLabelValues(channel)
+
+		err := m.conn.WriteJSON(MisskeyRequest{
+			Type: "connect",
+			Body: MisskeyRequestBody{
+				Channel: channel,
+				ID:      channel,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+		}
+	}
+	return nil
+}
+
+func (m *misskey) Run(ctx context.Context) error {
+	reconnect := ReconnectNone
+
+	params := url.Values{}
+	params.Set("i", m.Token)
+
+	u, err := url.Parse(m.ServerURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse server URL: %w", err)
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = path.Join(u.Path, "/streaming")
+	u.RawQuery = params.Encode()
+
+	server := u.Host
+	for {
+		slog.Debug("Connecting to streaming...")
+
+		var res *http.Response
+		m.conn, res, err = m.Dialer.DialContext(ctx, u.String(), nil)
+		if err != nil {
+			if res != nil {
+				err = fmt.Errorf("failed to connect: %v: %w", res.Status, err)
+			}
+
+			reconnect, err = m.reconnect(ctx, reconnect, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		reconnect = ReconnectNone
+		StreamingMessageTotal.WithLabelValues(server)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case m.ch <- service.Connection{Server: server}:
+			break
+		}
+
+		err = m.subscribe()
+		if err != nil {
+			err := m.disconnect(ctx, err)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for {
+			var event MisskeyEvent
+			err := m.conn.ReadJSON(&event)
+			if err != nil {
+				err := m.disconnect(ctx, err)
+				if err != nil {
+					return err
+				}
+				break
+			}
+			if event.Type != "channel" {
+				continue
+			}
+
+			var packet service.Status
+			switch event.Body.Type {
+			case "note", "mention":
+				var note MisskeyNote
+				err = json.Unmarshal(event.Body.Body, &note)
+
+				message := convertMisskeyNote(note)
+				message.Mentions = m.resolveMentions(ctx, message.Mentions, note.Mentions)
+				packet = message
+
+			case "notification":
+				var notification MisskeyNotification
+				err = json.Unmarshal(event.Body.Body, &notification)
+				packet = convertMisskeyNotification(notification)
+			}
+
+			if err != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+
+				case m.ch <- service.Error{Err: err}:
+					continue
+				}
+			}
+
+			if packet == nil {
+				continue
+			}
+
+			StreamingMessageTotal.WithLabelValues(server).Inc()
+			StreamingStreamMessageTotal.WithLabelValues(event.Body.ID).Inc()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+
+			case m.ch <- packet:
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package streaming_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("ParseMisskeyChannels()", func() {
+	Context("valid channels are given", func() {
+		It("returns channels", func() {
+			actual, err := streaming.ParseMisskeyChannels([]string{"main", "homeTimeline", "hybridTimeline"})
+			Expect(actual).To(Equal([]string{"main", "homeTimeline", "hybridTimeline"}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("invalid channel is given", func() {
+		It("returns an error", func() {
+			_, err := streaming.ParseMisskeyChannels([]string{"main", "user"})
+			Expect(err).To(MatchError(`unknown channel: "user"`))
+		})
+	})
+})
+
+var _ = Describe("Misskey", func() {
+	var (
+		ctrl         *gomock.Controller
+		conn         *wrapper.MockConn
+		d            *wrapper.MockDialer
+		t            *wrapper.MockTimer
+		server       *ghttp.Server
+		serverHost   string
+		streamingURL string
+		misskey      service.Streaming
+		ctx          context.Context
+		cancel       context.CancelFunc
+		event        streaming.MisskeyEvent
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		conn = wrapper.NewMockConn(ctrl)
+		d = wrapper.NewMockDialer(ctrl)
+		t = wrapper.NewMockTimer(ctrl)
+		server = ghttp.NewServer()
+
+		u, err := url.Parse(server.URL())
+		Expect(err).NotTo(HaveOccurred())
+		serverHost = u.Host
+		streamingURL = strings.Replace(server.URL(), "http", "ws", 1) + "/streaming?i=token"
+
+		misskey = streaming.NewMisskey(d, t, http.DefaultClient, server.URL(), "token", []string{"main", "homeTimeline"})
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		ctrl.Finish()
+	})
+
+	Describe("Run()", func() {
+		Context("websocket connection fails", func() {
+			BeforeEach(func() {
+				ch := make(chan time.Time)
+				t.EXPECT().After(5 * time.Second).Return(ch)
+
+				gomock.InOrder(
+					d.EXPECT().DialContext(
+						ctx,
+						streamingURL,
+						nil,
+					).Do(func(context.Context, string, http.Header) {
+						go func() {
+							ch <- time.Now()
+						}()
+					}).Return(
+						nil,
+						nil,
+						errors.New("dial tcp [::1]:443: connect: connection refused"),
+					),
+					d.EXPECT().DialContext(
+						ctx,
+						streamingURL,
+						nil,
+					).Do(func(context.Context, string, http.Header) {
+						cancel()
+					}).Return(
+						nil,
+						nil,
+						context.Canceled,
+					),
+				)
+			})
+
+			It("sends reconnection and eventually exits", func() {
+				actual := misskey.Statuses()
+				go func() {
+					defer GinkgoRecover()
+
+					err := misskey.Run(ctx)
+					Expect(err).To(Equal(context.Canceled))
+				}()
+
+				Eventually(actual).Should(Receive(Equal(service.Error{
+					Err: errors.New("dial tcp [::1]:443: connect: connection refused"),
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Reconnection{
+					In: 5 * time.Second,
+				})))
+				Eventually(ctx.Done()).Should(BeClosed())
+			})
+		})
+
+		Context("websocket connection succeeds", func() {
+			BeforeEach(func() {
+				d.EXPECT().DialContext(
+					ctx,
+					streamingURL,
+					nil,
+				).Return(conn, &http.Response{}, nil)
+
+				gomock.InOrder(
+					conn.EXPECT().WriteJSON(streaming.MisskeyRequest{
+						Type: "connect",
+						Body: streaming.MisskeyRequestBody{Channel: "main", ID: "main"},
+					}).Return(nil),
+					conn.EXPECT().WriteJSON(streaming.MisskeyRequest{
+						Type: "connect",
+						Body: streaming.MisskeyRequestBody{Channel: "homeTimeline", ID: "homeTimeline"},
+					}).Return(nil),
+				)
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/api/users/show"),
+						ghttp.VerifyJSON(`{"i":"token","username":"ejaculation_counter","host":null}`),
+						ghttp.RespondWith(http.StatusOK, `{"id":"9k0fsrh3y1","name":null,"username":"ejaculation_counter","host":null}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/api/users/show"),
+						ghttp.VerifyJSON(`{"i":"token","username":"test2","host":null}`),
+						ghttp.RespondWith(http.StatusOK, `{"id":"9k0fsrh3y2","name":null,"username":"test2","host":null}`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodPost, "/api/users/show"),
+						ghttp.VerifyJSON(`{"i":"token","username":"unknown","host":null}`),
+						ghttp.RespondWith(http.StatusNotFound, `{"error":{"code":"NO_SUCH_USER"}}`),
+					),
+				)
+
+				gomock.InOrder(
+					// (1)
+					conn.EXPECT().ReadJSON(&event).Do(func(e *streaming.MisskeyEvent) {
+						err := json.Unmarshal([]byte(`
+							{
+								"type": "channel",
+								"body": {
+									"id": "homeTimeline",
+									"type": "note",
+									"body": {
+										"id": "9lr5n2h4ya",
+										"createdAt": "2024-01-02T15:04:05.000Z",
+										"user": {
+											"id": "9k0fsrh3y4",
+											"name": "テスト",
+											"username": "test",
+											"host": "misskey.example.net"
+										},
+										"text": "@ejaculation_counter @test2 @unknown :ios_big_sushi_1: #同人AVタイトルジェネレーター",
+										"visibility": "followers",
+										"replyId": "9lr5n2h4y9",
+										"renoteId": null,
+										"mentions": ["9k0fsrh3y2", "9k0fsrh3y1"],
+										"tags": ["同人avタイトルジェネレーター"]
+									}
+								}
+							}
+						`), e)
+						Expect(err).NotTo(HaveOccurred())
+					}).Return(nil),
+					// (2)
+					conn.EXPECT().ReadJSON(&event).Do(func(e *streaming.MisskeyEvent) {
+						err := json.Unmarshal([]byte(`
+							{
+								"type": "channel",
+								"body": {
+									"id": "main",
+									"type": "notification",
+									"body": {
+										"id": "9lr5n2h4yc",
+										"createdAt": "2024-01-02T15:04:05.000Z",
+										"type": "follow",
+										"user": {
+											"id": "9k0fsrh3y3",
+											"name": null,
+											"username": "test3",
+											"host": null
+										}
+									}
+								}
+							}
+						`), e)
+						Expect(err).NotTo(HaveOccurred())
+					}).Return(nil),
+					// (3)
+					conn.EXPECT().ReadJSON(&event).Do(func(*streaming.MisskeyEvent) {
+						cancel()
+					}).Return(context.Canceled),
+				)
+			})
+
+			It("sends statuses and eventually exits", func() {
+				actual := misskey.Statuses()
+				go func() {
+					defer GinkgoRecover()
+
+					err := misskey.Run(ctx)
+					Expect(err).To(Equal(context.Canceled))
+				}()
+
+				Eventually(actual).Should(Receive(Equal(service.Connection{
+					Server: serverHost,
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Message{
+					ID: "9lr5n2h4ya",
+					Account: service.Account{
+						ID:          "9k0fsrh3y4",
+						Acct:        "test@misskey.example.net",
+						DisplayName: "テスト",
+						Username:    "test",
+					},
+					CreatedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+					Content:   "@ejaculation_counter @test2 @unknown :ios_big_sushi_1: #同人AVタイトルジェネレーター",
+					Emojis: []service.Emoji{
+						{Shortcode: "ios_big_sushi_1"},
+					},
+					InReplyToID: "9lr5n2h4y9",
+					Mentions: []service.Mention{
+						{ID: "9k0fsrh3y1", Acct: "ejaculation_counter", Username: "ejaculation_counter"},
+						{ID: "9k0fsrh3y2", Acct: "test2", Username: "test2"},
+					},
+					Tags: []service.Tag{
+						{Name: "同人avタイトルジェネレーター"},
+					},
+					Visibility: "private",
+				})))
+				Eventually(actual).Should(Receive(Equal(service.Notification{
+					ID:   "9lr5n2h4yc",
+					Type: "follow",
+					Account: service.Account{
+						ID:          "9k0fsrh3y3",
+						Acct:        "test3",
+						DisplayName: "test3",
+						Username:    "test3",
+					},
+					CreatedAt: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+				})))
+				Eventually(ctx.Done()).Should(BeClosed())
+			})
+		})
+	})
+})
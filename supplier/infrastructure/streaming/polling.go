@@ -0,0 +1,317 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	mast "github.com/mattn/go-mastodon"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	PollingRequestTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "polling_request_total",
+		Help:      "Total number of requests for polling.",
+	}, []string{"source"})
+	PollingRequestErrorTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "ejaculation_counter",
+		Name:      "polling_request_error_total",
+		Help:      "Total number of errors in requests for polling.",
+	}, []string{"source"})
+)
+
+const (
+	PollingServer          = "polling"
+	PollingLimit           = 40
+	DefaultPollingInterval = 30 * time.Second
+)
+
+type pollingItem struct {
+	ID     mast.ID
+	Status service.Status
+}
+
+type pollingSource struct {
+	Name  string
+	Fetch func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error)
+}
+
+type polling struct {
+	ch       chan service.Status
+	sources  []pollingSource
+	Client   *mast.Client
+	Timer    wrapper.Timer
+	Interval time.Duration
+	Streams  []Stream
+	State    PollingState
+}
+
+func NewPolling(
+	timer wrapper.Timer,
+	serverURL, accessToken string,
+	streams []Stream,
+	interval time.Duration,
+	state PollingState,
+) service.Streaming {
+	p := &polling{
+		ch: make(chan service.Status),
+		Client: mast.NewClient(&mast.Config{
+			Server:      serverURL,
+			AccessToken: accessToken,
+		}),
+		Timer:    timer,
+		Interval: interval,
+		Streams:  streams,
+		State:    state,
+	}
+	p.sources = newPollingSources(p.Client, streams)
+	return p
+}
+
+func fromStatuses(statuses []*mast.Status) []pollingItem {
+	items := make([]pollingItem, 0, len(statuses))
+	for _, status := range statuses {
+		items = append(items, pollingItem{
+			ID:     status.ID,
+			Status: convertMessage(*status),
+		})
+	}
+	return items
+}
+
+func fromNotifications(notifications []*mast.Notification) []pollingItem {
+	items := make([]pollingItem, 0, len(notifications))
+	for _, notification := range notifications {
+		items = append(items, pollingItem{
+			ID:     notification.ID,
+			Status: convertNotificationStatus(*notification),
+		})
+	}
+	return items
+}
+
+// newPollingSources returns the REST API counterparts of the given streams.
+func newPollingSources(client *mast.Client, streams []Stream) []pollingSource {
+	var sources []pollingSource
+	add := func(source pollingSource) {
+		if !slices.ContainsFunc(sources, func(s pollingSource) bool { return s.Name == source.Name }) {
+			sources = append(sources, source)
+		}
+	}
+
+	home := pollingSource{
+		Name: "home",
+		Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+			statuses, err := client.GetTimelineHome(ctx, pg)
+			return fromStatuses(statuses), err
+		},
+	}
+	notifications := pollingSource{
+		Name: "notifications",
+		Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+			notifications, err := client.GetNotifications(ctx, pg)
+			return fromNotifications(notifications), err
+		},
+	}
+
+	for _, stream := range streams {
+		switch stream.Name {
+		case "user":
+			add(home)
+			add(notifications)
+
+		case "user:notification":
+			add(notifications)
+
+		case "direct":
+			add(pollingSource{
+				Name: stream.String(),
+				Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+					statuses, err := client.GetTimelineDirect(ctx, pg)
+					return fromStatuses(statuses), err
+				},
+			})
+
+		case "hashtag", "hashtag:local":
+			add(pollingSource{
+				Name: stream.String(),
+				Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+					statuses, err := client.GetTimelineHashtag(ctx, stream.Tag, stream.Name == "hashtag:local", pg)
+					return fromStatuses(statuses), err
+				},
+			})
+
+		case "list":
+			add(pollingSource{
+				Name: stream.String(),
+				Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+					statuses, err := client.GetTimelineList(ctx, mast.ID(stream.List), pg)
+					return fromStatuses(statuses), err
+				},
+			})
+
+		default:
+			add(pollingSource{
+				Name: stream.String(),
+				Fetch: func(ctx context.Context, pg *mast.Pagination) ([]pollingItem, error) {
+					statuses, err := client.GetTimelinePublic(ctx, stream.Name == "public:local" || stream.Name == "public:local:media", pg)
+					return fromStatuses(statuses), err
+				},
+			})
+		}
+	}
+
+	return sources
+}
+
+// compareID compares Mastodon IDs, which are numeric strings without leading zeros.
+func compareID(a, b mast.ID) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (p *polling) send(ctx context.Context, status service.Status) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case p.ch <- status:
+		return nil
+	}
+}
+
+// fetch fetches items of the source in chronological order.
+func fetch(ctx context.Context, source pollingSource, pg *mast.Pagination) ([]pollingItem, error) {
+	PollingRequestTotal.WithLabelValues(source.Name).Inc()
+	items, err := source.Fetch(ctx, pg)
+	if err != nil {
+		PollingRequestErrorTotal.WithLabelValues(source.Name).Inc()
+		return nil, fmt.Errorf("failed to fetch %s: %w", source.Name, err)
+	}
+
+	slices.SortFunc(items, func(a, b pollingItem) int {
+		return compareID(a.ID, b.ID)
+	})
+	return items, nil
+}
+
+// fetchSince fetches all items newer than since page by page and passes each page to fn.
+func fetchSince(ctx context.Context, source pollingSource, since mast.ID, fn func(items []pollingItem) error) error {
+	for {
+		items, err := fetch(ctx, source, &mast.Pagination{
+			MinID: since,
+			Limit: PollingLimit,
+		})
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		err = fn(items)
+		if err != nil {
+			return err
+		}
+		if len(items) < PollingLimit {
+			return nil
+		}
+		since = items[len(items)-1].ID
+	}
+}
+
+// seed records the newest ID of the source as the last seen one so that old items are not replayed.
+func seed(ctx context.Context, source pollingSource, state PollingState) error {
+	items, err := fetch(ctx, source, &mast.Pagination{Limit: 1})
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	return state.Save(source.Name, items[len(items)-1].ID)
+}
+
+// poll fetches items newer than the last seen ID of the source and sends them in chronological order.
+// When no ID has been seen yet, only the newest ID is recorded so that old items are not replayed.
+func (p *polling) poll(ctx context.Context, source pollingSource) error {
+	since, ok := p.State.ID(source.Name)
+	if !ok {
+		return seed(ctx, source, p.State)
+	}
+
+	return fetchSince(ctx, source, since, func(items []pollingItem) error {
+		for _, item := range items {
+			if item.Status == nil {
+				continue
+			}
+			err := p.send(ctx, item.Status)
+			if err != nil {
+				return err
+			}
+		}
+		return p.State.Save(source.Name, items[len(items)-1].ID)
+	})
+}
+
+func (p *polling) Statuses() <-chan service.Status {
+	return p.ch
+}
+
+func (p *polling) Close(exit bool) error {
+	if exit {
+		close(p.ch)
+		p.ch = nil
+	}
+	return nil
+}
+
+func (p *polling) Run(ctx context.Context) error {
+	err := p.State.Load()
+	if err != nil {
+		return err
+	}
+
+	err = p.send(ctx, service.Connection{Server: PollingServer})
+	if err != nil {
+		return err
+	}
+
+	for {
+		for _, source := range p.sources {
+			err := p.poll(ctx, source)
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			if err != nil {
+				slog.Debug("Failed to poll", slog.String("source", source.Name), slog.Any("err", err))
+
+				err := p.send(ctx, service.Error{Err: err})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-p.Timer.After(p.Interval):
+		}
+	}
+}
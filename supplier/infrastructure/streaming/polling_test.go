@@ -0,0 +1,163 @@
+package streaming_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Polling", func() {
+	var (
+		ctrl    *gomock.Controller
+		t       *wrapper.MockTimer
+		server  *ghttp.Server
+		state   string
+		polling service.Streaming
+		ctx     context.Context
+		cancel  context.CancelFunc
+		done    chan error
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		t = wrapper.NewMockTimer(ctrl)
+		server = ghttp.NewServer()
+		state = filepath.Join(GinkgoT().TempDir(), "polling.json")
+		polling = streaming.NewPolling(t, server.URL(), "token", []streaming.Stream{{Name: "user"}}, 30*time.Second, streaming.NewPollingState(state))
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan error, 1)
+
+		t.EXPECT().After(30 * time.Second).DoAndReturn(func(time.Duration) <-chan time.Time {
+			cancel()
+			return make(chan time.Time)
+		})
+	})
+
+	AfterEach(func() {
+		cancel()
+		server.Close()
+		ctrl.Finish()
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			done <- polling.Run(ctx)
+		}()
+	})
+
+	Describe("Run()", func() {
+		Context("state file does not exist", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[{"id":"100","content":"最新のトゥート","account":{"id":"1","acct":"test"}}]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "limit=1"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+				)
+			})
+
+			It("records the newest ID without sending old statuses", func() {
+				Eventually(polling.Statuses()).Should(Receive(Equal(service.Connection{Server: "polling"})))
+				Eventually(done).Should(Receive(MatchError(context.Canceled)))
+				Expect(polling.Statuses()).NotTo(Receive())
+
+				actual, err := os.ReadFile(state)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(MatchJSON(`{"home":"100"}`))
+			})
+		})
+
+		Context("state file exists", func() {
+			BeforeEach(func() {
+				err := os.WriteFile(state, []byte(`{"home":"100","notifications":"200"}`), 0o644)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "min_id=100&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"102","content":"二番目のトゥート","account":{"id":"1","acct":"test"}},
+							{"id":"101","content":"一番目のトゥート","account":{"id":"1","acct":"test"}}
+						]`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "min_id=200&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[
+							{"id":"202","type":"poll","account":{"id":"3","acct":"test3"}},
+							{"id":"201","type":"follow","account":{"id":"2","acct":"test2"}}
+						]`),
+					),
+				)
+			})
+
+			It("sends statuses in chronological order and saves the last IDs", func() {
+				Eventually(polling.Statuses()).Should(Receive(Equal(service.Connection{Server: "polling"})))
+				Eventually(polling.Statuses()).Should(Receive(And(
+					BeAssignableToTypeOf(service.Message{}),
+					HaveField("ID", "101"),
+					HaveField("Content", "一番目のトゥート"),
+				)))
+				Eventually(polling.Statuses()).Should(Receive(And(
+					BeAssignableToTypeOf(service.Message{}),
+					HaveField("ID", "102"),
+					HaveField("Content", "二番目のトゥート"),
+				)))
+				Eventually(polling.Statuses()).Should(Receive(And(
+					BeAssignableToTypeOf(service.Notification{}),
+					HaveField("ID", "201"),
+					HaveField("Type", "follow"),
+				)))
+				Eventually(done).Should(Receive(MatchError(context.Canceled)))
+
+				actual, err := os.ReadFile(state)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(MatchJSON(`{"home":"102","notifications":"202"}`))
+			})
+		})
+
+		Context("fetching fails", func() {
+			BeforeEach(func() {
+				err := os.WriteFile(state, []byte(`{"home":"100","notifications":"200"}`), 0o644)
+				Expect(err).NotTo(HaveOccurred())
+
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/timelines/home", "min_id=100&limit=40"),
+						ghttp.RespondWith(http.StatusBadGateway, `<html>Bad Gateway</html>`),
+					),
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest(http.MethodGet, "/api/v1/notifications", "min_id=200&limit=40"),
+						ghttp.RespondWith(http.StatusOK, `[]`),
+					),
+				)
+			})
+
+			It("sends an error and keeps the state", func() {
+				Eventually(polling.Statuses()).Should(Receive(Equal(service.Connection{Server: "polling"})))
+				Eventually(polling.Statuses()).Should(Receive(And(
+					BeAssignableToTypeOf(service.Error{}),
+					HaveField("Err", MatchError(HavePrefix("failed to fetch home:"))),
+				)))
+				Eventually(done).Should(Receive(MatchError(context.Canceled)))
+
+				actual, err := os.ReadFile(state)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actual).To(MatchJSON(`{"home":"100","notifications":"200"}`))
+			})
+		})
+	})
+})
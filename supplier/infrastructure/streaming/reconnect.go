@@ -0,0 +1,48 @@
+package streaming
+
+import (
+	"context"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+)
+
+// reconnect reports err and waits with exponential backoff before the next attempt to connect.
+func reconnect(ctx context.Context, ch chan<- service.Status, timer wrapper.Timer, current time.Duration, err error) (time.Duration, error) {
+	var next time.Duration
+	select {
+	case <-ctx.Done():
+		return next, ctx.Err()
+
+	case ch <- service.Error{Err: err}:
+		break
+	}
+
+	next = time.Duration(
+		min(
+			max(
+				current*2,
+				ReconnectInitial,
+			),
+			ReconnectMax,
+		),
+	)
+	StreamingRetryTotal.Inc()
+
+	select {
+	case <-ctx.Done():
+		return next, ctx.Err()
+
+	case ch <- service.Reconnection{In: next}:
+		break
+	}
+
+	select {
+	case <-ctx.Done():
+		return next, ctx.Err()
+
+	case <-timer.After(next):
+		return next, nil
+	}
+}
@@ -0,0 +1,162 @@
+package streaming
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+)
+
+type replay struct {
+	streaming service.Streaming
+	frames    []wrapper.Frame
+	done      chan struct{}
+	finished  bool
+	finish    sync.Once
+	Timer     wrapper.Timer
+	Speed     float64
+}
+
+type replayDialer struct {
+	r *replay
+}
+
+type replayConn struct {
+	ctx context.Context
+	r   *replay
+	pos int
+}
+
+// ReadFrames reads frames recorded by wrapper.NewRecordingDialer.
+func ReadFrames(r io.Reader) ([]wrapper.Frame, error) {
+	var frames []wrapper.Frame
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var frame wrapper.Frame
+		err := json.Unmarshal(scanner.Bytes(), &frame)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %w", len(frames)+1, err)
+		}
+		frames = append(frames, frame)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frames: %w", err)
+	}
+	return frames, nil
+}
+
+// NewReplay returns a streaming that plays the recorded frames through the streaming built by newStreaming,
+// waiting between frames for the recorded interval divided by speed (no wait if speed is not positive).
+func NewReplay(
+	timer wrapper.Timer,
+	frames []wrapper.Frame,
+	speed float64,
+	newStreaming func(dialer wrapper.Dialer) service.Streaming,
+) service.Streaming {
+	r := &replay{
+		frames: frames,
+		done:   make(chan struct{}),
+		Timer:  timer,
+		Speed:  speed,
+	}
+	r.streaming = newStreaming(&replayDialer{r})
+	return r
+}
+
+func (r *replay) Statuses() <-chan service.Status {
+	return r.streaming.Statuses()
+}
+
+func (r *replay) Close(exit bool) error {
+	return r.streaming.Close(exit)
+}
+
+func (r *replay) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.streaming.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-r.done:
+		slog.Info("Finished replaying streaming", slog.Int("frames", len(r.frames)))
+		cancel()
+
+		err := <-done
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+}
+
+func (d *replayDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (wrapper.Conn, *http.Response, error) {
+	if d.r.finished {
+		// The recording has been played to the end and the streaming has handled the disconnection,
+		// so let Run stop it instead of reconnecting.
+		d.r.finish.Do(func() {
+			close(d.r.done)
+		})
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}
+	return &replayConn{ctx: ctx, r: d.r}, &http.Response{Header: http.Header{}}, nil
+}
+
+func (c *replayConn) Close() error {
+	return nil
+}
+
+func (c *replayConn) WriteJSON(v any) error {
+	return nil
+}
+
+func (c *replayConn) WriteMessage(messageType int, data []byte) error {
+	return nil
+}
+
+func (c *replayConn) ReadJSON(v any) error {
+	if c.pos >= len(c.r.frames) {
+		c.r.finished = true
+		return io.EOF
+	}
+
+	frame := c.r.frames[c.pos]
+	if c.pos > 0 && c.r.Speed > 0 {
+		wait := time.Duration(float64(frame.Time.Sub(c.r.frames[c.pos-1].Time)) / c.r.Speed)
+		if wait > 0 {
+			select {
+			case <-c.ctx.Done():
+				return c.ctx.Err()
+
+			case <-c.r.Timer.After(wait):
+			}
+		}
+	}
+	c.pos++
+
+	return json.Unmarshal(frame.Data, v)
+}
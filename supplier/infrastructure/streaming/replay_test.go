@@ -0,0 +1,135 @@
+package streaming_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/wrapper"
+	"github.com/chitoku-k/ejaculation-counter/supplier/service"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Replay", func() {
+	var (
+		ctrl *gomock.Controller
+		conn *wrapper.MockConn
+		d    *wrapper.MockDialer
+		t    *wrapper.MockTimer
+		ctx  context.Context
+	)
+
+	note := `{"type":"channel","body":{"id":"homeTimeline","type":"note","body":{"id":"9lr5n2h4ya","createdAt":"2024-01-02T15:04:05.000Z","user":{"id":"9k0fsrh3y4","name":"テスト","username":"test","host":null},"text":"ちんぽ揃えゲーム","visibility":"public","replyId":null,"renoteId":null,"mentions":[],"tags":[]}}}`
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		conn = wrapper.NewMockConn(ctrl)
+		d = wrapper.NewMockDialer(ctrl)
+		t = wrapper.NewMockTimer(ctrl)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Describe("NewRecordingDialer()", func() {
+		var (
+			buf   bytes.Buffer
+			event streaming.MisskeyEvent
+		)
+
+		BeforeEach(func() {
+			buf.Reset()
+			d.EXPECT().DialContext(ctx, "wss://misskey.example.com/streaming?i=token", nil).Return(conn, &http.Response{}, nil)
+			conn.EXPECT().ReadJSON(gomock.Any()).Do(func(v *json.RawMessage) {
+				*v = json.RawMessage(note)
+			}).Return(nil)
+		})
+
+		It("records frames that are read", func() {
+			recording := wrapper.NewRecordingDialer(d, &buf)
+			c, _, err := recording.DialContext(ctx, "wss://misskey.example.com/streaming?i=token", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = c.ReadJSON(&event)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Body.ID).To(Equal("homeTimeline"))
+
+			frames, err := streaming.ReadFrames(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Data).To(MatchJSON(note))
+		})
+	})
+
+	Describe("ReadFrames()", func() {
+		Context("invalid frame is given", func() {
+			It("returns an error", func() {
+				_, err := streaming.ReadFrames(bytes.NewBufferString("{\"time\":\"2024-01-02T15:04:05Z\",\"data\":{}}\n{\n"))
+				Expect(err).To(MatchError(HavePrefix("failed to decode frame 2:")))
+			})
+		})
+	})
+
+	Describe("Run()", func() {
+		var (
+			replay service.Streaming
+		)
+
+		BeforeEach(func() {
+			frames := []wrapper.Frame{
+				{Time: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), Data: json.RawMessage(note)},
+				{Time: time.Date(2024, 1, 2, 15, 4, 9, 0, time.UTC), Data: json.RawMessage(note)},
+			}
+			replay = streaming.NewReplay(t, frames, 2, func(dialer wrapper.Dialer) service.Streaming {
+				return streaming.NewMisskey(dialer, t, http.DefaultClient, "https://misskey.example.com", "token", []string{"homeTimeline"})
+			})
+
+			ch := make(chan time.Time, 1)
+			ch <- time.Now()
+			t.EXPECT().After(2 * time.Second).Return(ch)
+		})
+
+		It("plays frames with the scaled interval and exits", func() {
+			actual := replay.Statuses()
+			go func() {
+				defer GinkgoRecover()
+
+				err := replay.Run(ctx)
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			message := service.Message{
+				ID: "9lr5n2h4ya",
+				Account: service.Account{
+					ID:          "9k0fsrh3y4",
+					Acct:        "test",
+					DisplayName: "テスト",
+					Username:    "test",
+				},
+				CreatedAt:  time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+				Content:    "ちんぽ揃えゲーム",
+				Emojis:     []service.Emoji{},
+				Mentions:   []service.Mention{},
+				Tags:       []service.Tag{},
+				Visibility: "public",
+			}
+
+			Eventually(actual).Should(Receive(Equal(service.Connection{
+				Server: "misskey.example.com",
+			})))
+			Eventually(actual).Should(Receive(Equal(message)))
+			Eventually(actual).Should(Receive(Equal(message)))
+			Eventually(actual).Should(Receive(Equal(service.Disconnection{
+				Err: io.EOF,
+			})))
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package streaming
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	mast "github.com/mattn/go-mastodon"
+)
+
+type pollingState struct {
+	mu    sync.Mutex
+	ids   map[string]mast.ID
+	dirty bool
+	Path  string
+}
+
+// PollingState keeps the last seen ID for each polling source, which is shared by streaming and polling
+// so that either of them can resume from where the other left off.
+type PollingState interface {
+	Load() error
+	ID(name string) (mast.ID, bool)
+	Update(name string, id mast.ID)
+	Flush() error
+	Save(name string, id mast.ID) error
+}
+
+// NewPollingState returns the state persisted to the file at path, or kept only in memory if path is empty.
+func NewPollingState(path string) PollingState {
+	return &pollingState{
+		Path: path,
+	}
+}
+
+// Load reads the state from the file unless it has been loaded already.
+func (s *pollingState) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ids != nil {
+		return nil
+	}
+	s.ids = map[string]mast.ID{}
+
+	if s.Path == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read polling state: %w", err)
+	}
+
+	err = json.Unmarshal(b, &s.ids)
+	if err != nil {
+		return fmt.Errorf("failed to decode polling state: %w", err)
+	}
+
+	return nil
+}
+
+func (s *pollingState) ID(name string) (mast.ID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.ids[name]
+	return id, ok
+}
+
+// Update records id as the last seen ID of the source unless a newer one has been recorded, without writing the file.
+func (s *pollingState) Update(name string, id mast.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(name, id)
+}
+
+func (s *pollingState) update(name string, id mast.ID) {
+	if s.ids == nil {
+		s.ids = map[string]mast.ID{}
+	}
+	if compareID(id, s.ids[name]) <= 0 {
+		return
+	}
+
+	s.ids[name] = id
+	s.dirty = true
+}
+
+// Flush writes the state to the file if it has been updated since the last write.
+func (s *pollingState) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// Save records id as the last seen ID of the source unless a newer one has been recorded, and writes the state to the file.
+func (s *pollingState) Save(name string, id mast.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(name, id)
+	return s.flush()
+}
+
+func (s *pollingState) flush() error {
+	if !s.dirty || s.Path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(s.ids)
+	if err != nil {
+		return fmt.Errorf("failed to encode polling state: %w", err)
+	}
+
+	tmp := s.Path + ".tmp"
+	err = os.WriteFile(tmp, b, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to save polling state: %w", err)
+	}
+
+	err = os.Rename(tmp, s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to save polling state: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
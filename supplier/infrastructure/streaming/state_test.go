@@ -0,0 +1,56 @@
+package streaming_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/chitoku-k/ejaculation-counter/supplier/infrastructure/streaming"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PollingState", func() {
+	var (
+		path  string
+		state streaming.PollingState
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "polling.json")
+		state = streaming.NewPollingState(path)
+		Expect(state.Load()).To(Succeed())
+	})
+
+	Describe("Update()", func() {
+		It("records the newest ID without writing the file", func() {
+			state.Update("home", "101")
+			state.Update("home", "100")
+
+			id, ok := state.ID("home")
+			Expect(id).To(BeEquivalentTo("101"))
+			Expect(ok).To(BeTrue())
+			Expect(path).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("Flush()", func() {
+		It("writes the updated IDs to the file", func() {
+			state.Update("home", "101")
+			state.Update("notifications", "200")
+			Expect(state.Flush()).To(Succeed())
+
+			Expect(os.ReadFile(path)).To(MatchJSON(`{"home":"101","notifications":"200"}`))
+		})
+
+		Context("nothing has been updated since the last write", func() {
+			It("does not write the file", func() {
+				Expect(state.Save("home", "101")).To(Succeed())
+				Expect(os.Remove(path)).To(Succeed())
+
+				state.Update("home", "100")
+				Expect(state.Flush()).To(Succeed())
+				Expect(path).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Frame is a raw frame from streaming recorded along with the time it was read.
+type Frame struct {
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+type recordingDialer struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	Dialer Dialer
+}
+
+type recordingConn struct {
+	Conn
+	dialer *recordingDialer
+}
+
+// NewRecordingDialer returns a dialer whose connections write every frame they read to w as JSON Lines.
+func NewRecordingDialer(d Dialer, w io.Writer) Dialer {
+	return &recordingDialer{
+		enc:    json.NewEncoder(w),
+		Dialer: d,
+	}
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (Conn, *http.Response, error) {
+	conn, res, err := d.Dialer.DialContext(ctx, urlStr, requestHeader)
+	if err != nil {
+		return conn, res, err
+	}
+	return &recordingConn{Conn: conn, dialer: d}, res, nil
+}
+
+func (d *recordingDialer) record(frame Frame) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.enc.Encode(frame)
+	if err != nil {
+		return fmt.Errorf("failed to record frame: %w", err)
+	}
+	return nil
+}
+
+func (c *recordingConn) ReadJSON(v any) error {
+	var data json.RawMessage
+	err := c.Conn.ReadJSON(&data)
+	if err != nil {
+		return err
+	}
+
+	err = c.dialer.record(Frame{
+		Time: time.Now(),
+		Data: data,
+	})
+	if err != nil {
+		// Recording is best-effort and must not interrupt streaming.
+		slog.Error("Failed to record streaming", slog.Any("err", err))
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -28,5 +28,6 @@ func (d *dialer) DialContext(ctx context.Context, urlStr string, requestHeader h
 type Conn interface {
 	Close() error
 	ReadJSON(v any) error
+	WriteJSON(v any) error
 	WriteMessage(messageType int, data []byte) error
 }
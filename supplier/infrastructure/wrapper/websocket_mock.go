@@ -109,6 +109,20 @@ func (mr *MockConnMockRecorder) ReadJSON(v any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadJSON", reflect.TypeOf((*MockConn)(nil).ReadJSON), v)
 }
 
+// WriteJSON mocks base method.
+func (m *MockConn) WriteJSON(v any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteJSON", v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteJSON indicates an expected call of WriteJSON.
+func (mr *MockConnMockRecorder) WriteJSON(v any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteJSON", reflect.TypeOf((*MockConn)(nil).WriteJSON), v)
+}
+
 // WriteMessage mocks base method.
 func (m *MockConn) WriteMessage(messageType int, data []byte) error {
 	m.ctrl.T.Helper()
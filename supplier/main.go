@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -65,24 +66,157 @@ func main() {
 		s.Stop()
 	})
 
-	writer, err := queue.NewWriter(
-		ctx,
-		"ejaculation-counter.packets", "packets",
-		env.Queue.Host, env.Queue.Username, env.Queue.Password,
-		env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
-	)
+	outbox, err := queue.NewOutbox(env.Queue.OutboxFile, int(env.Queue.OutboxSize))
+	if err != nil {
+		slog.Error("Failed to initialize outbox", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	var writer service.QueueWriter
+	switch env.Queue.Backend {
+	case "", "rabbitmq":
+		writer, err = queue.NewWriter(
+			ctx,
+			"ejaculation-counter.packets", "packets",
+			env.Queue.Host, env.Queue.Username, env.Queue.Password,
+			env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
+			outbox,
+		)
+	case "nats":
+		writer, err = queue.NewNATSWriter(
+			ctx,
+			"ejaculation-counter-packets", "ejaculation-counter.packets",
+			env.Queue.Host, env.Queue.Username, env.Queue.Password,
+			env.Queue.SSLCert, env.Queue.SSLKey, env.Queue.SSLRootCert,
+			outbox,
+		)
+	default:
+		slog.Error("Unknown queue backend", slog.String("backend", env.Queue.Backend))
+		os.Exit(1)
+	}
 	if err != nil {
 		slog.Error("Failed to initialize writer", slog.Any("err", err))
 		os.Exit(1)
 	}
 
-	mastodon := streaming.NewMastodon(
-		wrapper.NewDialer(websocket.DefaultDialer),
-		wrapper.NewTimer(),
-		env.Mastodon.ServerURL,
-		env.Mastodon.AccessToken,
-		env.Mastodon.Stream,
-	)
+	c := &http.Client{
+		Timeout: streaming.LookupTimeout,
+	}
+
+	var newStreaming func(dialer wrapper.Dialer) service.Streaming
+	switch env.Platform {
+	case "", "mastodon":
+		streams, err := streaming.ParseStreams(env.Mastodon.Streams)
+		if err != nil {
+			slog.Error("Failed to parse streams", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		state := streaming.NewPollingState(env.Mastodon.PollingStateFile)
+		newStreaming = func(dialer wrapper.Dialer) service.Streaming {
+			return streaming.NewMastodon(
+				dialer,
+				wrapper.NewTimer(),
+				env.Mastodon.ServerURL,
+				env.Mastodon.AccessToken,
+				streams,
+				state,
+			)
+		}
+		if env.Mastodon.FallbackThreshold > 0 && env.Mastodon.ReplayFile == "" {
+			interval := env.Mastodon.PollingInterval
+			if interval <= 0 {
+				interval = streaming.DefaultPollingInterval
+			}
+			polling := streaming.NewPolling(
+				wrapper.NewTimer(),
+				env.Mastodon.ServerURL,
+				env.Mastodon.AccessToken,
+				streams,
+				interval,
+				state,
+			)
+			newMastodon := newStreaming
+			newStreaming = func(dialer wrapper.Dialer) service.Streaming {
+				return streaming.NewFailover(newMastodon(dialer), polling, int(env.Mastodon.FallbackThreshold))
+			}
+		}
+
+	case "misskey":
+		channels, err := streaming.ParseMisskeyChannels(env.Mastodon.Streams)
+		if err != nil {
+			slog.Error("Failed to parse channels", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		newStreaming = func(dialer wrapper.Dialer) service.Streaming {
+			return streaming.NewMisskey(
+				dialer,
+				wrapper.NewTimer(),
+				c,
+				env.Mastodon.ServerURL,
+				env.Mastodon.AccessToken,
+				channels,
+			)
+		}
+
+	case "bluesky":
+		dids, err := streaming.ParseDIDs(env.Mastodon.Streams)
+		if err != nil {
+			slog.Error("Failed to parse DIDs", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		newStreaming = func(dialer wrapper.Dialer) service.Streaming {
+			return streaming.NewJetstream(
+				dialer,
+				wrapper.NewTimer(),
+				c,
+				env.Mastodon.ServerURL,
+				streaming.BlueskyAppViewURL,
+				dids,
+			)
+		}
+
+	default:
+		slog.Error("Unknown platform", slog.String("platform", env.Platform))
+		os.Exit(1)
+	}
+
+	var mastodon service.Streaming
+	if env.Mastodon.ReplayFile != "" {
+		f, err := os.Open(env.Mastodon.ReplayFile)
+		if err != nil {
+			slog.Error("Failed to open replay file", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		frames, err := streaming.ReadFrames(f)
+		_ = f.Close()
+		if err != nil {
+			slog.Error("Failed to read replay file", slog.Any("err", err))
+			os.Exit(1)
+		}
+
+		speed := env.Mastodon.ReplaySpeed
+		if speed == 0 {
+			speed = 1
+		}
+		mastodon = streaming.NewReplay(wrapper.NewTimer(), frames, speed, newStreaming)
+	} else {
+		dialer := wrapper.NewDialer(websocket.DefaultDialer)
+		if env.Mastodon.RecordFile != "" {
+			f, err := os.OpenFile(env.Mastodon.RecordFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+			if err != nil {
+				slog.Error("Failed to open record file", slog.Any("err", err))
+				os.Exit(1)
+			}
+			defer f.Close()
+
+			dialer = wrapper.NewRecordingDialer(dialer, f)
+		}
+		mastodon = newStreaming(dialer)
+	}
 
 	wg.Go(func() {
 		err := mastodon.Run(ctx)
@@ -101,7 +235,7 @@ func main() {
 	})
 
 	wg.Go(func() {
-		ps := service.NewProcessor(writer)
+		ps := service.NewProcessor(writer, env.Mastodon.UserID)
 		ps.Execute(ctx, tick, mastodon.Statuses())
 
 		err := writer.Close()
@@ -1,6 +1,8 @@
 package service_test
 
 import (
+	"time"
+
 	"github.com/chitoku-k/ejaculation-counter/supplier/service"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -86,5 +88,122 @@ var _ = Describe("Message", func() {
 				Expect(actual).To(Equal(int64(6759)))
 			})
 		})
+
+		Context("when message is edited", func() {
+			BeforeEach(func() {
+				m = service.Message{
+					ID: "1",
+					Account: service.Account{
+						ID: "1",
+					},
+					EditedAt: time.UnixMilli(1),
+				}
+			})
+
+			It("returns code", func() {
+				actual := m.HashCode()
+				Expect(actual).To(Equal(int64(209530)))
+			})
+		})
+
+		Context("when IDs are not numeric", func() {
+			It("returns different codes for different IDs", func() {
+				a := service.Message{
+					ID: "9lr5n2h4ya",
+					Account: service.Account{
+						ID: "9k0fsrh3y4",
+					},
+				}
+				b := service.Message{
+					ID: "9lr5n2h4yb",
+					Account: service.Account{
+						ID: "9k0fsrh3y4",
+					},
+				}
+				Expect(a.HashCode()).NotTo(Equal(b.HashCode()))
+				Expect(a.HashCode()).To(Equal(a.HashCode()))
+			})
+		})
+	})
+})
+
+var _ = Describe("Notification", func() {
+	Context("Name()", func() {
+		var (
+			n service.Notification
+		)
+
+		It("returns packet name", func() {
+			actual := n.Name()
+			Expect(actual).To(Equal("packets.notification"))
+		})
+	})
+
+	Context("HashCode()", func() {
+		var (
+			n service.Notification
+		)
+
+		Context("when values are default", func() {
+			It("returns code", func() {
+				actual := n.HashCode()
+				Expect(actual).To(Equal(int64(6727)))
+			})
+		})
+
+		Context("when values are set", func() {
+			BeforeEach(func() {
+				n = service.Notification{
+					ID: "1",
+					Account: service.Account{
+						ID: "1",
+					},
+				}
+			})
+
+			It("returns code", func() {
+				actual := n.HashCode()
+				Expect(actual).To(Equal(int64(6759)))
+			})
+		})
+	})
+})
+
+var _ = Describe("Deletion", func() {
+	Context("Name()", func() {
+		var (
+			d service.Deletion
+		)
+
+		It("returns packet name", func() {
+			actual := d.Name()
+			Expect(actual).To(Equal("packets.deletion"))
+		})
+	})
+
+	Context("HashCode()", func() {
+		var (
+			d service.Deletion
+		)
+
+		Context("when values are default", func() {
+			It("returns code", func() {
+				actual := d.HashCode()
+				Expect(actual).To(Equal(int64(217)))
+			})
+		})
+
+		Context("when values are set", func() {
+			BeforeEach(func() {
+				d = service.Deletion{
+					ID: "1",
+				}
+			})
+
+			It("returns code", func() {
+				actual := d.HashCode()
+				Expect(actual).To(Equal(int64(218)))
+			})
+		})
 	})
 })
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"hash/fnv"
 	"strconv"
 	"time"
 )
@@ -11,10 +12,24 @@ type Packet interface {
 	HashCode() int64
 }
 
+// hashID returns the numeric value of id, or the FNV-1a hash of id when it is not numeric (e.g. Misskey).
+func hashID(id string) int64 {
+	if id == "" {
+		return 0
+	}
+	if v, err := strconv.ParseInt(id, 10, 64); err == nil {
+		return v
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	return int64(h.Sum64())
+}
+
 type Tick struct {
-	Year  int `json:"year"`
-	Month int `json:"month"`
-	Day   int `json:"day"`
+	Year  int
+	Month int
+	Day   int
 }
 
 func (t Tick) status() {}
@@ -36,30 +51,38 @@ func (t Tick) HashCode() int64 {
 }
 
 type Message struct {
-	ID          string    `json:"id"`
-	Account     Account   `json:"account"`
-	CreatedAt   time.Time `json:"created_at"`
-	Content     string    `json:"content"`
-	Emojis      []Emoji   `json:"emojis"`
-	InReplyToID string    `json:"in_reply_to_id"`
-	IsReblog    bool      `json:"is_reblog"`
-	Tags        []Tag     `json:"tags"`
-	Visibility  string    `json:"visibility"`
+	ID          string
+	Account     Account
+	CreatedAt   time.Time
+	EditedAt    time.Time
+	Content     string
+	Emojis      []Emoji
+	InReplyToID string
+	IsReblog    bool
+	Mentions    []Mention
+	Tags        []Tag
+	Visibility  string
 }
 
 type Account struct {
-	ID          string `json:"id"`
-	Acct        string `json:"acct"`
-	DisplayName string `json:"display_name"`
-	Username    string `json:"user_name"`
+	ID          string
+	Acct        string
+	DisplayName string
+	Username    string
+}
+
+type Mention struct {
+	ID       string
+	Acct     string
+	Username string
 }
 
 type Emoji struct {
-	Shortcode string `json:"shortcode"`
+	Shortcode string
 }
 
 type Tag struct {
-	Name string `json:"name"`
+	Name string
 }
 
 func (m Message) status() {}
@@ -73,12 +96,68 @@ func (m Message) Timestamp() time.Time {
 }
 
 func (m Message) HashCode() int64 {
-	id, _ := strconv.ParseInt(m.ID, 10, 64)
-	accountID, _ := strconv.ParseInt(m.Account.ID, 10, 64)
+	id := hashID(m.ID)
+	accountID := hashID(m.Account.ID)
 
 	var hash int64
 	hash = 7
 	hash = 31*hash + id
 	hash = 31*hash + accountID
+	if !m.EditedAt.IsZero() {
+		hash = 31*hash + m.EditedAt.UnixMilli()
+	}
+	return hash
+}
+
+type Notification struct {
+	ID        string
+	Type      string
+	Account   Account
+	CreatedAt time.Time
+	StatusID  string
+}
+
+func (n Notification) status() {}
+
+func (n Notification) Name() string {
+	return "packets.notification"
+}
+
+func (n Notification) Timestamp() time.Time {
+	return n.CreatedAt
+}
+
+func (n Notification) HashCode() int64 {
+	id := hashID(n.ID)
+	accountID := hashID(n.Account.ID)
+
+	var hash int64
+	hash = 7
+	hash = 31*hash + id
+	hash = 31*hash + accountID
+	return hash
+}
+
+type Deletion struct {
+	ID        string
+	DeletedAt time.Time
+}
+
+func (d Deletion) status() {}
+
+func (d Deletion) Name() string {
+	return "packets.deletion"
+}
+
+func (d Deletion) Timestamp() time.Time {
+	return d.DeletedAt
+}
+
+func (d Deletion) HashCode() int64 {
+	id := hashID(d.ID)
+
+	var hash int64
+	hash = 7
+	hash = 31*hash + id
 	return hash
 }
@@ -10,18 +10,35 @@ type processor struct {
 	Scheduler Scheduler
 	Streaming Streaming
 	Writer    QueueWriter
+	OwnerID   string
 }
 
 type Processor interface {
 	Execute(ctx context.Context, scheduler <-chan Tick, stream <-chan Status)
 }
 
-func NewProcessor(writer QueueWriter) Processor {
+func NewProcessor(writer QueueWriter, ownerID string) Processor {
 	return &processor{
-		Writer: writer,
+		Writer:  writer,
+		OwnerID: ownerID,
 	}
 }
 
+// priority returns PriorityHigh for ticks and messages posted by the owner so that the rollover and the owner commands
+// are not delayed by the other traffic.
+func (ps *processor) priority(packet Packet) Priority {
+	switch packet := packet.(type) {
+	case Tick:
+		return PriorityHigh
+
+	case Message:
+		if ps.OwnerID != "" && packet.Account.ID == ps.OwnerID {
+			return PriorityHigh
+		}
+	}
+	return PriorityNormal
+}
+
 func (ps *processor) Execute(ctx context.Context, scheduler <-chan Tick, stream <-chan Status) {
 	for scheduler != nil && stream != nil {
 		select {
@@ -30,7 +47,7 @@ func (ps *processor) Execute(ctx context.Context, scheduler <-chan Tick, stream
 				scheduler = nil
 				continue
 			}
-			err := ps.Writer.Publish(ctx, tick)
+			err := ps.Writer.Publish(ctx, tick, ps.priority(tick))
 			if err != nil {
 				slog.Error("Error in queueing", slog.Any("err", err))
 			}
@@ -54,7 +71,19 @@ func (ps *processor) Execute(ctx context.Context, scheduler <-chan Tick, stream
 				slog.Info(fmt.Sprintf("Reconnecting to streaming in %v...", status.In))
 
 			case Message:
-				err := ps.Writer.Publish(ctx, status)
+				err := ps.Writer.Publish(ctx, status, ps.priority(status))
+				if err != nil {
+					slog.Error("Error in publishing", slog.Any("err", err))
+				}
+
+			case Notification:
+				err := ps.Writer.Publish(ctx, status, ps.priority(status))
+				if err != nil {
+					slog.Error("Error in publishing", slog.Any("err", err))
+				}
+
+			case Deletion:
+				err := ps.Writer.Publish(ctx, status, ps.priority(status))
 				if err != nil {
 					slog.Error("Error in publishing", slog.Any("err", err))
 				}
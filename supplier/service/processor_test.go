@@ -21,7 +21,7 @@ var _ = Describe("Processor", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		qw = service.NewMockQueueWriter(ctrl)
-		processor = service.NewProcessor(qw)
+		processor = service.NewProcessor(qw, "100")
 	})
 
 	AfterEach(func() {
@@ -64,7 +64,7 @@ var _ = Describe("Processor", func() {
 							Year:  2006,
 							Month: 1,
 							Day:   2,
-						}).Do(func(context.Context, service.Packet) {
+						}, service.PriorityHigh).Do(func(context.Context, service.Packet, service.Priority) {
 							cancel()
 						}).Return(
 							errors.New("dial tcp [::1]:5672: connect: connection refused"),
@@ -94,7 +94,7 @@ var _ = Describe("Processor", func() {
 							Year:  2006,
 							Month: 1,
 							Day:   2,
-						}).Do(func(context.Context, service.Packet) {
+						}, service.PriorityHigh).Do(func(context.Context, service.Packet, service.Priority) {
 							cancel()
 						}).Return(nil)
 					})
@@ -224,7 +224,7 @@ var _ = Describe("Processor", func() {
 								Content: "test",
 							}
 
-							qw.EXPECT().Publish(ctx, message).Do(func(context.Context, service.Packet) {
+							qw.EXPECT().Publish(ctx, message, service.PriorityNormal).Do(func(context.Context, service.Packet, service.Priority) {
 								cancel()
 							}).Return(
 								errors.New("dial tcp [::1]:5672: connect: connection refused"),
@@ -261,7 +261,7 @@ var _ = Describe("Processor", func() {
 								Content: "test",
 							}
 
-							qw.EXPECT().Publish(ctx, message).Do(func(context.Context, service.Packet) {
+							qw.EXPECT().Publish(ctx, message, service.PriorityNormal).Do(func(context.Context, service.Packet, service.Priority) {
 								cancel()
 							}).Return(nil)
 						})
@@ -274,6 +274,76 @@ var _ = Describe("Processor", func() {
 							Eventually(stream).ShouldNot(Receive())
 						})
 					})
+
+					Context("message is posted by owner", func() {
+						var (
+							message service.Message
+						)
+
+						BeforeEach(func() {
+							ctx, cancel = context.WithCancel(context.Background())
+							scheduler = make(chan service.Tick)
+							stream = make(chan service.Status)
+
+							message = service.Message{
+								ID: "1",
+								Account: service.Account{
+									ID:          "100",
+									Acct:        "@owner",
+									DisplayName: "オーナー",
+									Username:    "owner",
+								},
+								Content: "ぴゅっ♡",
+							}
+
+							qw.EXPECT().Publish(ctx, message, service.PriorityHigh).Do(func(context.Context, service.Packet, service.Priority) {
+								cancel()
+							}).Return(nil)
+						})
+
+						It("processes a message with high priority and eventually exits", func() {
+							go processor.Execute(ctx, scheduler, stream)
+
+							stream <- message
+
+							Eventually(stream).ShouldNot(Receive())
+						})
+					})
+				})
+
+				Context("status is notification", func() {
+					var (
+						notification service.Notification
+					)
+
+					BeforeEach(func() {
+						ctx, cancel = context.WithCancel(context.Background())
+						scheduler = make(chan service.Tick)
+						stream = make(chan service.Status)
+
+						notification = service.Notification{
+							ID:   "1",
+							Type: "follow",
+							Account: service.Account{
+								ID:          "1",
+								Acct:        "@test",
+								DisplayName: "テスト",
+								Username:    "test",
+							},
+						}
+
+						qw.EXPECT().Publish(ctx, notification, service.PriorityNormal).Do(func(context.Context, service.Packet, service.Priority) {
+							cancel()
+						}).Return(nil)
+					})
+
+					It("processes a notification and eventually exits", func() {
+						go processor.Execute(ctx, scheduler, stream)
+
+						stream <- notification
+
+						Eventually(stream).ShouldNot(Receive())
+					})
 				})
 			})
 		})
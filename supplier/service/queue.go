@@ -4,7 +4,15 @@ package service
 
 import "context"
 
+// Priority is the order in which packets are consumed, where packets with PriorityHigh are consumed ahead of the others.
+type Priority int
+
+const (
+	PriorityNormal Priority = iota
+	PriorityHigh
+)
+
 type QueueWriter interface {
-	Publish(ctx context.Context, packet Packet) error
+	Publish(ctx context.Context, packet Packet, priority Priority) error
 	Close() error
 }
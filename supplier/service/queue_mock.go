@@ -55,15 +55,15 @@ func (mr *MockQueueWriterMockRecorder) Close() *gomock.Call {
 }
 
 // Publish mocks base method.
-func (m *MockQueueWriter) Publish(ctx context.Context, packet Packet) error {
+func (m *MockQueueWriter) Publish(ctx context.Context, packet Packet, priority Priority) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Publish", ctx, packet)
+	ret := m.ctrl.Call(m, "Publish", ctx, packet, priority)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Publish indicates an expected call of Publish.
-func (mr *MockQueueWriterMockRecorder) Publish(ctx, packet any) *gomock.Call {
+func (mr *MockQueueWriterMockRecorder) Publish(ctx, packet, priority any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockQueueWriter)(nil).Publish), ctx, packet)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockQueueWriter)(nil).Publish), ctx, packet, priority)
 }